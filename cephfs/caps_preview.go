@@ -0,0 +1,82 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <stdlib.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// The file capability bits, as defined by the CephFS protocol. They are not
+// part of the public libcephfs headers.
+const (
+	capFileShift  = 8
+	capFileShared = 1 << capFileShift
+	capFileExcl   = 2 << capFileShift
+	capFileCache  = 4 << capFileShift
+	capFileRead   = 8 << capFileShift
+	capFileWrite  = 16 << capFileShift
+	capFileBuffer = 32 << capFileShift
+)
+
+// CapInfo reports the file capabilities (caps) the client holds for an
+// inode. The MDS grants these caps to manage concurrent access of many
+// clients to the same file.
+type CapInfo struct {
+	// Raw is the complete bitmask of caps held, including the caps for
+	// other parts of the inode than the file data.
+	Raw uint32
+	// Shared is true if the client may read the file metadata.
+	Shared bool
+	// Exclusive is true if the client holds exclusive access to the file.
+	Exclusive bool
+	// Read is true if the client may read the file data.
+	Read bool
+	// Write is true if the client may write the file data.
+	Write bool
+	// Cache is true if the client may cache the file data it reads.
+	Cache bool
+	// Buffer is true if the client may buffer writes to the file data.
+	Buffer bool
+}
+
+func newCapInfo(caps uint32) CapInfo {
+	return CapInfo{
+		Raw:       caps,
+		Shared:    caps&capFileShared != 0,
+		Exclusive: caps&capFileExcl != 0,
+		Read:      caps&capFileRead != 0,
+		Write:     caps&capFileWrite != 0,
+		Cache:     caps&capFileCache != 0,
+		Buffer:    caps&capFileBuffer != 0,
+	}
+}
+
+// GetCaps returns the caps the client currently holds for the inode at the
+// given path. This is meant for diagnosing contention between clients; the
+// caps can change at any time.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_debug_get_file_caps(struct ceph_mount_info *cmount, const char *path);
+func (mount *MountInfo) GetCaps(path string) (CapInfo, error) {
+	if err := mount.validate(); err != nil {
+		return CapInfo{}, err
+	}
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	ret := C.ceph_debug_get_file_caps(mount.mount, cPath)
+	if ret < 0 {
+		return CapInfo{}, getError(ret)
+	}
+	return newCapInfo(uint32(ret)), nil
+}
@@ -0,0 +1,52 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCaps(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "TestGetCaps.txt"
+	f, err := mount.Open(fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+	_, err = f.Write([]byte("hold some caps"))
+	assert.NoError(t, err)
+
+	caps, err := mount.GetCaps(fname)
+	assert.NoError(t, err)
+	assert.True(t, caps.Write, "caps: %#x", caps.Raw)
+	assert.NotZero(t, caps.Raw)
+	assert.NoError(t, f.Close())
+
+	_, err = mount.GetCaps("TestGetCaps.none")
+	assert.Equal(t, errNoEntry, err)
+
+	_, err = (&MountInfo{}).GetCaps(fname)
+	assert.Equal(t, ErrNotConnected, err)
+}
+
+func TestNewCapInfo(t *testing.T) {
+	c := newCapInfo(capFileRead | capFileCache | 1)
+	assert.Equal(t, CapInfo{
+		Raw:   capFileRead | capFileCache | 1,
+		Read:  true,
+		Cache: true,
+	}, c)
+	c = newCapInfo(capFileWrite | capFileBuffer | capFileExcl | capFileShared)
+	assert.True(t, c.Write)
+	assert.True(t, c.Buffer)
+	assert.True(t, c.Exclusive)
+	assert.True(t, c.Shared)
+	assert.False(t, c.Read)
+}
@@ -0,0 +1,81 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"context"
+)
+
+// withContext runs fn in a new goroutine and waits for it to return or for
+// the context to be done, whichever happens first. If the context is already
+// done fn is not run at all.
+func withContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ch := make(chan error, 1)
+	go func() {
+		ch <- fn()
+	}()
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// MountContext mounts the file system like Mount but returns early with the
+// error of the context if the context is done before the mount completes.
+// Note that when MountContext returns early the underlying call continues to
+// run in the background and the file system may still become mounted later.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_mount(struct ceph_mount_info *cmount, const char *root);
+func (mount *MountInfo) MountContext(ctx context.Context) error {
+	if mount.mount == nil {
+		return ErrNotConnected
+	}
+	return withContext(ctx, mount.Mount)
+}
+
+// UnmountContext unmounts the file system like Unmount but returns early with
+// the error of the context if the context is done before the unmount
+// completes. Note that when UnmountContext returns early the underlying call
+// continues to run in the background and the file system may still become
+// unmounted later. The mount must not be released until the unmount has
+// finished.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_unmount(struct ceph_mount_info *cmount);
+func (mount *MountInfo) UnmountContext(ctx context.Context) error {
+	if mount.mount == nil {
+		return ErrNotConnected
+	}
+	return withContext(ctx, mount.Unmount)
+}
+
+// SetUmask sets the file mode creation mask of the mount, which is applied
+// to the mode of files and directories subsequently created through the
+// mount, and returns the previous mask. Zero is returned, and nothing is
+// changed, if the mount is not valid.
+//  PREVIEW
+//
+// Implements:
+//  mode_t ceph_umask(struct ceph_mount_info *cmount, mode_t mode);
+func (mount *MountInfo) SetUmask(mask uint32) uint32 {
+	if err := mount.validate(); err != nil {
+		return 0
+	}
+	return uint32(C.ceph_umask(mount.mount, C.mode_t(mask)))
+}
@@ -0,0 +1,146 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountUnmountContext(t *testing.T) {
+	t.Run("mountAndUnmount", func(t *testing.T) {
+		mount, err := CreateMount()
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Release()) }()
+		require.NoError(t, mount.ReadDefaultConfigFile())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err = mount.MountContext(ctx)
+		require.NoError(t, err)
+		assert.True(t, mount.IsMounted())
+
+		err = mount.UnmountContext(ctx)
+		assert.NoError(t, err)
+		assert.False(t, mount.IsMounted())
+	})
+
+	t.Run("cancelledUnmount", func(t *testing.T) {
+		mount := fsConnect(t)
+		defer fsDisconnect(t, mount)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		start := time.Now()
+		err := mount.UnmountContext(ctx)
+		assert.Equal(t, context.Canceled, err)
+		assert.True(t, time.Since(start) < time.Second)
+		// the unmount was never started
+		assert.True(t, mount.IsMounted())
+	})
+
+	t.Run("cancelledMount", func(t *testing.T) {
+		mount, err := CreateMount()
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Release()) }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err = mount.MountContext(ctx)
+		assert.Equal(t, context.Canceled, err)
+		assert.False(t, mount.IsMounted())
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		assert.Equal(t, ErrNotConnected, m.MountContext(context.Background()))
+		assert.Equal(t, ErrNotConnected, m.UnmountContext(context.Background()))
+	})
+}
+
+func TestWithContext(t *testing.T) {
+	t.Run("completes", func(t *testing.T) {
+		err := withContext(context.Background(), func() error { return errInvalid })
+		assert.Equal(t, errInvalid, err)
+	})
+
+	t.Run("cancelledInFlight", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		finished := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-started
+			cancel()
+		}()
+
+		err := withContext(ctx, func() error {
+			defer close(finished)
+			close(started)
+			<-release
+			return nil
+		})
+		assert.Equal(t, context.Canceled, err)
+
+		// the call keeps running after withContext returned
+		select {
+		case <-finished:
+			t.Fatal("call finished before it was released")
+		default:
+		}
+		close(release)
+		<-finished
+	})
+}
+
+func TestSetUmask(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	orig := mount.SetUmask(022)
+	defer mount.SetUmask(orig)
+	assert.Equal(t, uint32(022), mount.SetUmask(022))
+
+	fname := "/umask.file"
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+	require.NoError(t, err)
+	assert.NoError(t, f.Close())
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+	dname := "/umask.dir"
+	require.NoError(t, mount.MakeDir(dname, 0777))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	st, err := mount.Statx(fname, StatxMode, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0644), st.Mode&0777)
+	st, err = mount.Statx(dname, StatxMode, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0755), st.Mode&0777)
+
+	t.Run("noMask", func(t *testing.T) {
+		assert.Equal(t, uint32(022), mount.SetUmask(0))
+		defer mount.SetUmask(022)
+
+		fname := "/umask.nomask"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+		st, err := mount.Statx(fname, StatxMode, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint16(0666), st.Mode&0777)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		assert.Equal(t, uint32(0), m.SetUmask(022))
+	})
+}
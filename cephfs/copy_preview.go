@@ -0,0 +1,96 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <stdlib.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"io"
+	"os"
+	"unsafe"
+
+	ts "github.com/ceph/go-ceph/internal/timespec"
+)
+
+// copyFileBufferSize is the size of the chunks CopyFile copies data in.
+const copyFileBufferSize = 1 << 20
+
+// CopyFileOptions controls the behavior of CopyFile.
+type CopyFileOptions struct {
+	// PreserveTimes carries the access, modification and birth times of
+	// the source file over to the destination.
+	PreserveTimes bool
+}
+
+// CopyFile copies the data of the file at src to the file at dst. The
+// destination is created with the permission bits of the source if it does
+// not exist, and truncated if it does. With PreserveTimes set the times of
+// the source are set on the destination, with nanosecond precision, once
+// all data has been written. The birth time is carried over only if the file
+// system reports it for the source.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_setattrx(struct ceph_mount_info *cmount, const char *relpath,
+//                    struct ceph_statx *stx, int mask, int flags);
+func (mount *MountInfo) CopyFile(src, dst string, opts CopyFileOptions) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	st, err := mount.Statx(
+		src, StatxMode|StatxAtime|StatxMtime|StatxBtime, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := mount.copyFileData(src, dst, uint32(st.Mode&0777)); err != nil {
+		return err
+	}
+	if !opts.PreserveTimes {
+		return nil
+	}
+	return mount.setFileTimes(dst, st)
+}
+
+func (mount *MountInfo) copyFileData(src, dst string, mode uint32) error {
+	sf, err := mount.Open(src, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+	df, err := mount.Open(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyBuffer(df, sf, make([]byte, copyFileBufferSize))
+	if cerr := df.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// setFileTimes sets the access and modification times, and the birth time if
+// known, of st on the file at path. It is called after the file was closed
+// so that no buffered write changes the times afterwards.
+func (mount *MountInfo) setFileTimes(path string, st *CephStatx) error {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	var stx C.struct_ceph_statx
+	ts.CopyToCStruct(ts.Timespec(st.Atime), ts.CTimespecPtr(&stx.stx_atime))
+	ts.CopyToCStruct(ts.Timespec(st.Mtime), ts.CTimespecPtr(&stx.stx_mtime))
+	mask := C.CEPH_SETATTR_ATIME | C.CEPH_SETATTR_MTIME
+	if st.Mask&StatxBtime != 0 {
+		ts.CopyToCStruct(ts.Timespec(st.Btime), ts.CTimespecPtr(&stx.stx_btime))
+		mask |= C.CEPH_SETATTR_BTIME
+	}
+	ret := C.ceph_setattrx(mount.mount, cPath, &stx, C.int(mask), 0)
+	return getError(ret)
+}
@@ -0,0 +1,78 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFile(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	src := "TestCopyFile.src"
+	f, err := mount.Open(src, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.Unlink(src)) }()
+	data := make([]byte, copyFileBufferSize+1234)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// make sure the copy happens at a noticeably later time
+	time.Sleep(10 * time.Millisecond)
+
+	readAll := func(path string) []byte {
+		f, err := mount.Open(path, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, f.Close()) }()
+		buf := make([]byte, len(data)+10)
+		n, err := f.ReadAt(buf, 0)
+		require.NoError(t, err)
+		return buf[:n]
+	}
+	srcSt, err := mount.Statx(src, StatxBasicStats|StatxBtime, 0)
+	require.NoError(t, err)
+
+	t.Run("preserveTimes", func(t *testing.T) {
+		dst := "TestCopyFile.preserved"
+		err := mount.CopyFile(src, dst, CopyFileOptions{PreserveTimes: true})
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Unlink(dst)) }()
+
+		assert.Equal(t, data, readAll(dst))
+		st, err := mount.Statx(dst, StatxBasicStats|StatxBtime, 0)
+		require.NoError(t, err)
+		assert.Equal(t, srcSt.Mtime, st.Mtime)
+		assert.Equal(t, uint16(0640), st.Mode&0777)
+		if srcSt.Mask&StatxBtime != 0 {
+			assert.Equal(t, srcSt.Btime, st.Btime)
+		}
+	})
+
+	t.Run("noPreserve", func(t *testing.T) {
+		dst := "TestCopyFile.plain"
+		err := mount.CopyFile(src, dst, CopyFileOptions{})
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Unlink(dst)) }()
+
+		assert.Equal(t, data, readAll(dst))
+		st, err := mount.Statx(dst, StatxBasicStats, 0)
+		require.NoError(t, err)
+		assert.NotEqual(t, srcSt.Mtime, st.Mtime)
+	})
+
+	t.Run("missingSource", func(t *testing.T) {
+		err := mount.CopyFile("TestCopyFile.none", "TestCopyFile.x", CopyFileOptions{})
+		assert.Equal(t, errNoEntry, err)
+	})
+}
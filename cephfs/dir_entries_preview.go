@@ -0,0 +1,45 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	dirFilesXattr   = "ceph.dir.files"
+	dirSubdirsXattr = "ceph.dir.subdirs"
+)
+
+// dirCountXattr returns the value of one of the directory entry count
+// vxattrs of the directory at path.
+func (mount *MountInfo) dirCountXattr(path, name string) (uint64, error) {
+	value, err := mount.GetXattr(path, name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(value)), 10, 64)
+}
+
+// CountDirEntries returns the number of files and of subdirectories
+// directly within the directory at path, without listing the directory.
+// Any entry that is not a directory, such as a symbolic link, is counted as
+// a file. The counts are read from the ceph.dir.files and ceph.dir.subdirs
+// vxattrs, which are maintained by the MDS.
+//  PREVIEW
+func (mount *MountInfo) CountDirEntries(path string) (files, subdirs uint64, err error) {
+	if err := mount.validate(); err != nil {
+		return 0, 0, err
+	}
+	files, err = mount.dirCountXattr(path, dirFilesXattr)
+	if err != nil {
+		return 0, 0, err
+	}
+	subdirs, err = mount.dirCountXattr(path, dirSubdirsXattr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return files, subdirs, nil
+}
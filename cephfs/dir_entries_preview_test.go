@@ -0,0 +1,74 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountDirEntries(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestCountDirEntries"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveAll(dname)) }()
+
+	files, subdirs, err := mount.CountDirEntries(dname)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, files)
+	assert.EqualValues(t, 0, subdirs)
+
+	for i := 0; i < 5; i++ {
+		f, err := mount.Open(fmt.Sprintf("%s/file%d", dname, i),
+			os.O_WRONLY|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, mount.MakeDir(fmt.Sprintf("%s/dir%d", dname, i), 0755))
+	}
+	require.NoError(t, mount.Symlink("file0", dname+"/link"))
+	// entries of subdirectories are not counted
+	require.NoError(t, mount.MakeDir(dname+"/dir0/nested", 0755))
+
+	// count the entries of a full listing
+	dir, err := mount.OpenDir(dname)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, dir.Close()) }()
+	var listedFiles, listedSubdirs uint64
+	for {
+		entry, err := dir.ReadDir()
+		require.NoError(t, err)
+		if entry == nil {
+			break
+		}
+		switch name := entry.Name(); {
+		case name == "." || name == "..":
+		case entry.DType() == DTypeDir:
+			listedSubdirs++
+		default:
+			listedFiles++
+		}
+	}
+	assert.EqualValues(t, 6, listedFiles)
+	assert.EqualValues(t, 3, listedSubdirs)
+
+	files, subdirs, err = mount.CountDirEntries(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, listedFiles, files)
+	assert.Equal(t, listedSubdirs, subdirs)
+
+	_, _, err = mount.CountDirEntries(dname + "/missing")
+	assert.Error(t, err)
+
+	m := &MountInfo{}
+	_, _, err = m.CountDirEntries(dname)
+	assert.Equal(t, ErrNotConnected, err)
+}
@@ -0,0 +1,291 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <stdlib.h>
+#include <fcntl.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// DirHandle is an open directory relative to which files and directories
+// can be created and looked up, in the manner of the POSIX *at functions.
+// The handle refers to the inode of the directory, so it keeps referring to
+// the same directory if the directory is renamed while the handle is open.
+//
+// Names are resolved one component at a time starting at the directory, or
+// at the root of the file system for absolute names. Symbolic links are not
+// followed while resolving a name.
+//  PREVIEW
+type DirHandle struct {
+	mount *MountInfo
+	inode *C.struct_Inode
+}
+
+// OpenDirHandle opens the directory at path and returns a handle that can
+// be used to operate on entries relative to it. A relative path is
+// interpreted relative to the current working directory of the mount. The
+// handle must be closed with Close.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_walk(struct ceph_mount_info *cmount, const char* name, Inode **i,
+//                   struct ceph_statx *stx, unsigned int want, unsigned int flags,
+//                   const UserPerm *perms);
+func (mount *MountInfo) OpenDirHandle(path string) (*DirHandle, error) {
+	if err := mount.validate(); err != nil {
+		return nil, err
+	}
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	var inode *C.struct_Inode
+	var stx C.struct_ceph_statx
+	ret := C.ceph_ll_walk(
+		mount.mount,
+		cPath,
+		&inode,
+		&stx,
+		C.uint(StatxMode),
+		0,
+		C.ceph_mount_perms(mount.mount))
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	if uint16(stx.stx_mode)&modeIFMT != modeIFDIR {
+		C.ceph_ll_put(mount.mount, inode)
+		return nil, errNotDir
+	}
+	return &DirHandle{mount: mount, inode: inode}, nil
+}
+
+func (d *DirHandle) validate() error {
+	if d.inode == nil {
+		return ErrNotConnected
+	}
+	return d.mount.validate()
+}
+
+// lookup looks up the entry named name in the directory with the given
+// inode. The returned inode must be released with ceph_ll_put.
+//
+// Implements:
+//  int ceph_ll_lookup(struct ceph_mount_info *cmount, Inode *parent, const char *name,
+//                     Inode **out, struct ceph_statx *stx, unsigned want, unsigned flags,
+//                     const UserPerm *perms);
+func (d *DirHandle) lookup(
+	parent *C.struct_Inode, name string, stx *C.struct_ceph_statx,
+	want StatxMask, flags AtFlags) (*C.struct_Inode, error) {
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var inode *C.struct_Inode
+	ret := C.ceph_ll_lookup(
+		d.mount.mount,
+		parent,
+		cName,
+		&inode,
+		stx,
+		C.uint(want),
+		C.uint(flags),
+		C.ceph_mount_perms(d.mount.mount))
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	return inode, nil
+}
+
+// resolveParent looks up the directory holding the final component of name
+// and returns its inode along with the final component. The returned inode
+// must be released with ceph_ll_put.
+//
+// Implements:
+//  int ceph_ll_lookup_root(struct ceph_mount_info *cmount, Inode **parent);
+func (d *DirHandle) resolveParent(name string) (*C.struct_Inode, string, error) {
+	var parent *C.struct_Inode
+	if strings.HasPrefix(name, "/") {
+		ret := C.ceph_ll_lookup_root(d.mount.mount, &parent)
+		if ret < 0 {
+			return nil, "", getError(ret)
+		}
+	} else {
+		// take a reference of our own so that the caller can release the
+		// returned inode in every case
+		var stx C.struct_ceph_statx
+		inode, err := d.lookup(d.inode, ".", &stx, 0, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		parent = inode
+	}
+
+	components := []string{}
+	for _, c := range strings.Split(name, "/") {
+		if c != "" {
+			components = append(components, c)
+		}
+	}
+	if len(components) == 0 {
+		return parent, ".", nil
+	}
+	for _, c := range components[:len(components)-1] {
+		var stx C.struct_ceph_statx
+		inode, err := d.lookup(parent, c, &stx, 0, 0)
+		C.ceph_ll_put(d.mount.mount, parent)
+		if err != nil {
+			return nil, "", err
+		}
+		parent = inode
+	}
+	return parent, components[len(components)-1], nil
+}
+
+// MakeDir creates a directory named name relative to the directory.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_mkdir(struct ceph_mount_info *cmount, Inode *parent, const char *name,
+//                    mode_t mode, Inode **out, struct ceph_statx *stx, unsigned want,
+//                    unsigned flags, const UserPerm *perms);
+func (d *DirHandle) MakeDir(name string, mode uint32) error {
+	if err := d.validate(); err != nil {
+		return err
+	}
+	parent, base, err := d.resolveParent(name)
+	if err != nil {
+		return err
+	}
+	defer C.ceph_ll_put(d.mount.mount, parent)
+
+	cName := C.CString(base)
+	defer C.free(unsafe.Pointer(cName))
+
+	var inode *C.struct_Inode
+	var stx C.struct_ceph_statx
+	ret := C.ceph_ll_mkdir(
+		d.mount.mount,
+		parent,
+		cName,
+		C.mode_t(mode),
+		&inode,
+		&stx,
+		0,
+		0,
+		C.ceph_mount_perms(d.mount.mount))
+	if ret < 0 {
+		return getError(ret)
+	}
+	C.ceph_ll_put(d.mount.mount, inode)
+	return nil
+}
+
+// Open opens the file named name relative to the directory. The flags and
+// mode are the same as for MountInfo.Open. The file is opened through the
+// low-level API, like files opened with OpenByInode.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_create(struct ceph_mount_info *cmount, Inode *parent, const char *name,
+//                     mode_t mode, int oflags, Inode **outp, Fh **fhp,
+//                     struct ceph_statx *stx, unsigned want, unsigned lflags,
+//                     const UserPerm *perms);
+//  int ceph_ll_open(struct ceph_mount_info *cmount, struct Inode *in, int flags, struct Fh **fh,
+//                   const UserPerm *perms);
+func (d *DirHandle) Open(name string, flags int, mode uint32) (*FileHandle, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	parent, base, err := d.resolveParent(name)
+	if err != nil {
+		return nil, err
+	}
+	defer C.ceph_ll_put(d.mount.mount, parent)
+
+	var (
+		inode *C.struct_Inode
+		fh    *C.struct_Fh
+		stx   C.struct_ceph_statx
+	)
+	if flags&C.O_CREAT != 0 {
+		cName := C.CString(base)
+		defer C.free(unsafe.Pointer(cName))
+		ret := C.ceph_ll_create(
+			d.mount.mount,
+			parent,
+			cName,
+			C.mode_t(mode),
+			C.int(flags),
+			&inode,
+			&fh,
+			&stx,
+			0,
+			0,
+			C.ceph_mount_perms(d.mount.mount))
+		if ret < 0 {
+			return nil, getError(ret)
+		}
+		C.ceph_ll_put(d.mount.mount, inode)
+		return newFileHandle(d.mount, fh), nil
+	}
+
+	inode, err = d.lookup(parent, base, &stx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer C.ceph_ll_put(d.mount.mount, inode)
+	ret := C.ceph_ll_open(
+		d.mount.mount, inode, C.int(flags), &fh, C.ceph_mount_perms(d.mount.mount))
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	return newFileHandle(d.mount, fh), nil
+}
+
+// Statx returns information about the file or directory named name
+// relative to the directory.
+//  PREVIEW
+func (d *DirHandle) Statx(name string, want StatxMask, flags AtFlags) (*CephStatx, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	parent, base, err := d.resolveParent(name)
+	if err != nil {
+		return nil, err
+	}
+	defer C.ceph_ll_put(d.mount.mount, parent)
+
+	var stx C.struct_ceph_statx
+	inode, err := d.lookup(parent, base, &stx, want, flags)
+	if err != nil {
+		return nil, err
+	}
+	C.ceph_ll_put(d.mount.mount, inode)
+	return cStructToCephStatx(stx), nil
+}
+
+// Close releases the directory handle.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_put(struct ceph_mount_info *cmount, struct Inode *in);
+func (d *DirHandle) Close() error {
+	if d.inode == nil {
+		return nil
+	}
+	if err := d.mount.validate(); err != nil {
+		return err
+	}
+	ret := C.ceph_ll_put(d.mount.mount, d.inode)
+	d.inode = nil
+	return getError(ret)
+}
@@ -0,0 +1,119 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirHandle(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestDirHandle"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveAll(dname)) }()
+
+	d, err := mount.OpenDirHandle(dname)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, d.Close()) }()
+
+	t.Run("relative", func(t *testing.T) {
+		require.NoError(t, d.MakeDir("sub", 0755))
+		f, err := d.Open("sub/file", os.O_WRONLY|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("hello"))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+
+		st, err := d.Statx("sub/file", StatxBasicStats, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), st.Size)
+
+		// the entries appear at the expected absolute paths
+		st, err = mount.Statx(dname+"/sub", StatxBasicStats, 0)
+		require.NoError(t, err)
+		assert.Equal(t, modeIFDIR, st.Mode&modeIFMT)
+		st, err = mount.Statx(dname+"/sub/file", StatxBasicStats, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), st.Size)
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		st, err := d.Statx("/", StatxBasicStats, 0)
+		require.NoError(t, err)
+		assert.Equal(t, modeIFDIR, st.Mode&modeIFMT)
+	})
+
+	t.Run("relativeToCwd", func(t *testing.T) {
+		require.NoError(t, mount.ChangeDir(dname))
+		defer func() { assert.NoError(t, mount.ChangeDir("/")) }()
+
+		sub, err := mount.OpenDirHandle("sub")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, sub.Close()) }()
+
+		_, err = sub.Statx("file", StatxBasicStats, 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("renamed", func(t *testing.T) {
+		require.NoError(t, mount.MakeDir(dname+"/before", 0755))
+		h, err := mount.OpenDirHandle(dname + "/before")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, h.Close()) }()
+
+		// the handle follows the directory to its new name
+		require.NoError(t, mount.Rename(dname+"/before", dname+"/after"))
+		f, err := h.Open("file", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		require.NoError(t, h.MakeDir("dir", 0755))
+
+		_, err = mount.Statx(dname+"/after/file", StatxBasicStats, 0)
+		assert.NoError(t, err)
+		_, err = mount.Statx(dname+"/after/dir", StatxBasicStats, 0)
+		assert.NoError(t, err)
+		_, err = mount.Statx(dname+"/before", StatxBasicStats, 0)
+		assert.Equal(t, errNoEntry, err)
+
+		_, err = h.Open("file", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		assert.Equal(t, errExist, err)
+		f, err = h.Open("file", os.O_RDONLY, 0)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := mount.OpenDirHandle(dname + "/nope")
+		assert.Equal(t, errNoEntry, err)
+		_, err = mount.OpenDirHandle(dname + "/sub/file")
+		assert.Equal(t, errNotDir, err)
+		_, err = d.Open("sub/file/x", os.O_RDONLY, 0)
+		assert.Error(t, err)
+
+		_, err = d.Statx("nope", StatxBasicStats, 0)
+		assert.Equal(t, errNoEntry, err)
+
+		m := &MountInfo{}
+		_, err = m.OpenDirHandle(dname)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		c, err := mount.OpenDirHandle(dname)
+		require.NoError(t, err)
+		assert.NoError(t, c.Close())
+		assert.NoError(t, c.Close())
+		assert.Equal(t, ErrNotConnected, c.MakeDir("x", 0755))
+		_, err = c.Open("x", os.O_RDONLY, 0)
+		assert.Equal(t, ErrNotConnected, err)
+		_, err = c.Statx("x", StatxBasicStats, 0)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
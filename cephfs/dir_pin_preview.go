@@ -0,0 +1,37 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"strconv"
+	"strings"
+)
+
+const dirPinXattr = "ceph.dir.pin"
+
+// DirPinNone is the MDS rank of a directory that is not pinned.
+const DirPinNone = -1
+
+// SetDirPin pins the directory at the given path, and the subtree below it,
+// to the MDS with the given rank. Setting the rank to DirPinNone removes the
+// pin.
+//  PREVIEW
+func (mount *MountInfo) SetDirPin(path string, rank int) error {
+	if rank < DirPinNone {
+		return errInvalid
+	}
+	return mount.SetXattr(
+		path, dirPinXattr, []byte(strconv.Itoa(rank)), XattrDefault)
+}
+
+// GetDirPin returns the MDS rank the directory at the given path is pinned
+// to, or DirPinNone if it is not pinned.
+//  PREVIEW
+func (mount *MountInfo) GetDirPin(path string) (int, error) {
+	value, err := mount.GetXattr(path, dirPinXattr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(value)))
+}
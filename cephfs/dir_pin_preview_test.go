@@ -0,0 +1,50 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirPin(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestDirPin"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	rank, err := mount.GetDirPin(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, DirPinNone, rank)
+
+	err = mount.SetDirPin(dname, 0)
+	assert.NoError(t, err)
+	rank, err = mount.GetDirPin(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rank)
+
+	err = mount.SetDirPin(dname, DirPinNone)
+	assert.NoError(t, err)
+	rank, err = mount.GetDirPin(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, DirPinNone, rank)
+
+	err = mount.SetDirPin(dname, -2)
+	assert.Equal(t, errInvalid, err)
+
+	_, err = mount.GetDirPin("/TestDirPin.missing")
+	assert.Error(t, err)
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		err := m.SetDirPin(dname, 0)
+		assert.Error(t, err)
+		_, err = m.GetDirPin(dname)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,40 @@
+//go:build !nautilus && ceph_preview
+// +build !nautilus,ceph_preview
+
+package cephfs
+
+import (
+	"strconv"
+	"strings"
+)
+
+const dirPinRandomXattr = "ceph.dir.pin.random"
+
+// SetEphemeralRandomPin sets the ratio of the directories below the
+// directory at the given path that are ephemerally pinned to a randomly
+// chosen MDS rank. The ratio must be between 0 and 1; a ratio of 0 removes
+// the policy.
+//  PREVIEW
+func (mount *MountInfo) SetEphemeralRandomPin(path string, ratio float64) error {
+	// written so that NaN is rejected too
+	if !(ratio >= 0 && ratio <= 1) {
+		return errInvalid
+	}
+	return mount.SetXattr(
+		path,
+		dirPinRandomXattr,
+		[]byte(strconv.FormatFloat(ratio, 'g', -1, 64)),
+		XattrDefault)
+}
+
+// GetEphemeralRandomPin returns the ratio of the directories below the
+// directory at the given path that are ephemerally pinned to a randomly
+// chosen MDS rank.
+//  PREVIEW
+func (mount *MountInfo) GetEphemeralRandomPin(path string) (float64, error) {
+	value, err := mount.GetXattr(path, dirPinRandomXattr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(value)), 64)
+}
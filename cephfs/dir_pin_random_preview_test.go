@@ -0,0 +1,49 @@
+//go:build !nautilus && ceph_preview
+// +build !nautilus,ceph_preview
+
+package cephfs
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEphemeralRandomPin(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestEphemeralRandomPin"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	err := mount.SetEphemeralRandomPin(dname, 0.5)
+	assert.NoError(t, err)
+	ratio, err := mount.GetEphemeralRandomPin(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, ratio)
+
+	err = mount.SetEphemeralRandomPin(dname, 0)
+	assert.NoError(t, err)
+	ratio, err = mount.GetEphemeralRandomPin(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, ratio)
+
+	for _, r := range []float64{-0.1, 1.5, math.NaN()} {
+		err = mount.SetEphemeralRandomPin(dname, r)
+		assert.Equal(t, errInvalid, err)
+	}
+
+	_, err = mount.GetEphemeralRandomPin("/TestEphemeralRandomPin.missing")
+	assert.Error(t, err)
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		err := m.SetEphemeralRandomPin(dname, 0.5)
+		assert.Error(t, err)
+		_, err = m.GetEphemeralRandomPin(dname)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,61 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dirRctimeXattr = "ceph.dir.rctime"
+
+// parseRctime parses the value of the ceph.dir.rctime vxattr, the seconds
+// and fractional seconds since the epoch separated by a dot.
+func parseRctime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	secs, frac := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		secs, frac = value[:i], value[i+1:]
+	}
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nsec int64
+	if frac != "" {
+		// scale the fraction to nanoseconds
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		frac += strings.Repeat("0", 9-len(frac))
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// dirRctime returns the recursive change time of the directory at path, the
+// latest change time of the directory and of anything below it.
+func (mount *MountInfo) dirRctime(path string) (time.Time, error) {
+	value, err := mount.GetXattr(path, dirRctimeXattr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseRctime(string(value))
+}
+
+// DirChangedSince returns true if the directory at the given path, or any
+// file or directory below it, changed after the given time. It is based on
+// the recursive change time of the directory, which the MDS updates lazily,
+// so very recent changes may not be reported yet.
+//  PREVIEW
+func (mount *MountInfo) DirChangedSince(path string, since time.Time) (bool, error) {
+	rctime, err := mount.dirRctime(path)
+	if err != nil {
+		return false, err
+	}
+	return rctime.After(since), nil
+}
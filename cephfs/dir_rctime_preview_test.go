@@ -0,0 +1,76 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRctime(t *testing.T) {
+	ts, err := parseRctime("1634567890.123456789")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1634567890, 123456789), ts)
+
+	ts, err = parseRctime("1634567890.5\n")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1634567890, 500000000), ts)
+
+	ts, err = parseRctime("1634567890")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1634567890, 0), ts)
+
+	_, err = parseRctime("yesterday")
+	assert.Error(t, err)
+	_, err = parseRctime("1634567890.x")
+	assert.Error(t, err)
+}
+
+func TestDirChangedSince(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestDirChangedSince"
+	deep := dname + "/a/b/c"
+	require.NoError(t, mount.MakeDirs(deep, 0755))
+	defer func() { assert.NoError(t, mount.RemoveAll(dname)) }()
+
+	since, err := mount.dirRctime(dname)
+	require.NoError(t, err)
+	changed, err := mount.DirChangedSince(dname, since)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// make sure the change happens at a later time
+	time.Sleep(10 * time.Millisecond)
+	f, err := mount.Open(deep+"/file", os.O_WRONLY|os.O_CREATE, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("changed"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Sync())
+	assert.NoError(t, f.Close())
+
+	// recursive statistics are propagated lazily
+	for i := 0; i < 30; i++ {
+		changed, err = mount.DirChangedSince(dname, since)
+		require.NoError(t, err)
+		if changed {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	assert.True(t, changed)
+
+	// nothing changed after a time in the future
+	changed, err = mount.DirChangedSince(dname+"/a/b", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	_, err = mount.DirChangedSince(dname+"/missing", since)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"strings"
+)
+
+const dirSubvolumeXattr = "ceph.dir.subvolume"
+
+// SetSubvolumeFlag marks, or unmarks, the directory at the given path as
+// the root of a subvolume.
+//  PREVIEW
+func (mount *MountInfo) SetSubvolumeFlag(path string, enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	return mount.SetXattr(path, dirSubvolumeXattr, []byte(value), XattrDefault)
+}
+
+// IsSubvolume returns true if the directory at the given path is marked as
+// the root of a subvolume.
+//  PREVIEW
+func (mount *MountInfo) IsSubvolume(path string) (bool, error) {
+	value, err := mount.GetXattr(path, dirSubvolumeXattr)
+	if err == errNoData {
+		// the flag has never been set on the directory
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch strings.TrimSpace(string(value)) {
+	case "1":
+		return true, nil
+	case "0", "":
+		return false, nil
+	}
+	return false, errInvalid
+}
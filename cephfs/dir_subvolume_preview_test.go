@@ -0,0 +1,39 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubvolumeFlag(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestSubvolumeFlag"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	isSubvol, err := mount.IsSubvolume(dname)
+	assert.NoError(t, err)
+	assert.False(t, isSubvol)
+
+	err = mount.SetSubvolumeFlag(dname, true)
+	assert.NoError(t, err)
+	isSubvol, err = mount.IsSubvolume(dname)
+	assert.NoError(t, err)
+	assert.True(t, isSubvol)
+
+	err = mount.SetSubvolumeFlag(dname, false)
+	assert.NoError(t, err)
+	isSubvol, err = mount.IsSubvolume(dname)
+	assert.NoError(t, err)
+	assert.False(t, isSubvol)
+
+	_, err = mount.IsSubvolume("/TestSubvolumeFlag.missing")
+	assert.Error(t, err)
+}
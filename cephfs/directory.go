@@ -17,6 +17,10 @@ import (
 type Directory struct {
 	mount *MountInfo
 	dir   *C.struct_ceph_dir_result
+	// stopEntries and entriesDone are set while a goroutine started by
+	// Entries is reading from the directory.
+	stopEntries chan struct{}
+	entriesDone chan struct{}
 }
 
 // OpenDir returns a new Directory handle open for I/O.
@@ -45,9 +49,22 @@ func (mount *MountInfo) OpenDir(path string) (*Directory, error) {
 // Implements:
 //  int ceph_closedir(struct ceph_mount_info *cmount, struct ceph_dir_result *dirp);
 func (dir *Directory) Close() error {
+	dir.stopEntryStream()
 	return getError(C.ceph_closedir(dir.mount.mount, dir.dir))
 }
 
+// stopEntryStream stops the goroutine started by Entries, if any, and waits
+// for it to exit.
+func (dir *Directory) stopEntryStream() {
+	if dir.stopEntries == nil {
+		return
+	}
+	close(dir.stopEntries)
+	<-dir.entriesDone
+	dir.stopEntries = nil
+	dir.entriesDone = nil
+}
+
 // Inode represents an inode number in the file system.
 type Inode uint64
 
@@ -0,0 +1,149 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <dirent.h>
+#include <cephfs/libcephfs.h>
+
+// readdirplus_n reads up to count entries from the directory, along with
+// their statx data, storing the number of entries read in n. It returns 0,
+// or the negative error code of the read that failed, in which case n still
+// counts the entries read before. Reading all of the entries in one call
+// from Go avoids the overhead of crossing the cgo boundary for every entry.
+static int readdirplus_n(struct ceph_mount_info *cmount,
+	struct ceph_dir_result *dirp, struct dirent *de, struct ceph_statx *stx,
+	int count, unsigned int want, unsigned int flags, int *n) {
+	int ret;
+	for (*n = 0; *n < count; (*n)++) {
+		ret = ceph_readdirplus_r(cmount, dirp, &de[*n], &stx[*n], want, flags,
+			NULL);
+		if (ret < 0) {
+			return ret;
+		}
+		if (ret == 0) {
+			break;
+		}
+	}
+	return 0;
+}
+*/
+import "C"
+
+// IsDirEmpty returns true if the directory at the given path contains no
+// entries other than "." and "..". Only as many entries as are needed to
+// make the determination are read from the directory.
+//  PREVIEW
+func (mount *MountInfo) IsDirEmpty(path string) (bool, error) {
+	if err := mount.validate(); err != nil {
+		return false, err
+	}
+	dir, err := mount.OpenDir(path)
+	if err != nil {
+		return false, err
+	}
+	defer dir.Close()
+
+	for {
+		entry, err := dir.ReadDir()
+		if err != nil {
+			return false, err
+		}
+		if entry == nil {
+			return true, nil
+		}
+		if name := entry.Name(); name != "." && name != ".." {
+			return false, nil
+		}
+	}
+}
+
+// ReadDirPlusN reads up to count directory entries, along with their stat
+// metadata, from the open Directory. It behaves like calling ReadDirPlus
+// count times but with less per-entry overhead. An empty slice is returned
+// when the Directory stream has been exhausted. If reading an entry fails
+// the entries read before are returned along with the error.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_readdirplus_r(struct ceph_mount_info *cmount, struct ceph_dir_result *dirp, struct dirent *de,
+//                         struct ceph_statx *stx, unsigned want, unsigned flags, struct Inode **out);
+func (dir *Directory) ReadDirPlusN(
+	count int, want StatxMask, flags AtFlags) ([]*DirEntryPlus, error) {
+
+	if count <= 0 {
+		return nil, errInvalid
+	}
+	des := make([]C.struct_dirent, count)
+	stxs := make([]C.struct_ceph_statx, count)
+	var n C.int
+	ret := C.readdirplus_n(
+		dir.mount.mount,
+		dir.dir,
+		&des[0],
+		&stxs[0],
+		C.int(count),
+		C.uint(want),
+		C.uint(flags),
+		&n)
+
+	entries := make([]*DirEntryPlus, n)
+	for i := range entries {
+		entries[i] = toDirEntryPlus(&des[i], stxs[i])
+	}
+	return entries, getError(ret)
+}
+
+// DirEntryResult is a value sent by the channel returned from Entries. It
+// holds either a directory entry or the error that ended the stream.
+//  PREVIEW
+type DirEntryResult struct {
+	Entry *DirEntry
+	Err   error
+}
+
+// Entries streams the entries of the directory, starting at the current
+// position of the directory stream, over the returned channel. The channel
+// is closed when the end of the directory is reached or after a result with
+// a non-nil Err has been sent.
+//
+// The entries are read by a goroutine that is started by Entries. While
+// the stream is active no other calls reading or rewinding the Directory may
+// be made. Calling Close on the Directory, or calling Entries again, stops
+// the goroutine and closes the channel even if not every entry has been
+// received.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_readdir_r(struct ceph_mount_info *cmount, struct ceph_dir_result *dirp, struct dirent *de);
+func (dir *Directory) Entries() <-chan DirEntryResult {
+	dir.stopEntryStream()
+
+	results := make(chan DirEntryResult)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	dir.stopEntries = stop
+	dir.entriesDone = done
+	go func() {
+		defer close(done)
+		defer close(results)
+		for {
+			entry, err := dir.ReadDir()
+			if entry == nil && err == nil {
+				return
+			}
+			select {
+			case results <- DirEntryResult{Entry: entry, Err: err}:
+			case <-stop:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return results
+}
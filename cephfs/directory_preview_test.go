@@ -0,0 +1,246 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDirEmpty(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dir1 := "/base"
+	err := mount.MakeDir(dir1, 0755)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.RemoveDir(dir1)) }()
+
+	t.Run("empty", func(t *testing.T) {
+		empty, err := mount.IsDirEmpty(dir1)
+		assert.NoError(t, err)
+		assert.True(t, empty)
+	})
+
+	t.Run("notEmpty", func(t *testing.T) {
+		fname := dir1 + "/file1"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		empty, err := mount.IsDirEmpty(dir1)
+		assert.NoError(t, err)
+		assert.False(t, empty)
+	})
+
+	t.Run("repeated", func(t *testing.T) {
+		// the directory handle is closed on every call so repeated calls
+		// must not exhaust any resources
+		for i := 0; i < 1000; i++ {
+			empty, err := mount.IsDirEmpty(dir1)
+			require.NoError(t, err)
+			require.True(t, empty)
+		}
+	})
+
+	t.Run("noSuchDir", func(t *testing.T) {
+		_, err := mount.IsDirEmpty("/no.such.dir")
+		assert.Error(t, err)
+	})
+
+	t.Run("notADir", func(t *testing.T) {
+		fname := dir1 + "/file2"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		_, err = mount.IsDirEmpty(fname)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		_, err := m.IsDirEmpty(dir1)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
+
+func makeDirEntries(t require.TestingT, mount *MountInfo, dir string, n int) {
+	require.NoError(t, mount.MakeDir(dir, 0755))
+	for i := 0; i < n; i++ {
+		f, err := mount.Open(
+			fmt.Sprintf("%s/file%05d", dir, i), os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		// give every file a distinct size so the stat data can be checked
+		_, err = f.Write(make([]byte, i%64+1))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+}
+
+func TestReadDirPlusN(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/readdirplusn"
+	makeDirEntries(t, mount, dname, 100)
+	defer func() { assert.NoError(t, mount.RemoveAll(dname)) }()
+
+	t.Run("matchesReadDirPlus", func(t *testing.T) {
+		dir, err := mount.OpenDir(dname)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, dir.Close()) }()
+
+		expected := map[string]int64{}
+		for {
+			entry, err := dir.ReadDirPlus(StatxBasicStats, AtSymlinkNofollow)
+			require.NoError(t, err)
+			if entry == nil {
+				break
+			}
+			expected[entry.Name()] = int64(entry.Statx().Size)
+		}
+		dir.RewindDir()
+
+		found := map[string]int64{}
+		for {
+			entries, err := dir.ReadDirPlusN(7, StatxBasicStats, AtSymlinkNofollow)
+			require.NoError(t, err)
+			if len(entries) == 0 {
+				break
+			}
+			assert.True(t, len(entries) <= 7)
+			for _, entry := range entries {
+				assert.NotContains(t, found, entry.Name())
+				found[entry.Name()] = int64(entry.Statx().Size)
+			}
+		}
+		// 100 files plus "." and ".."
+		assert.Len(t, found, 102)
+		assert.Equal(t, expected, found)
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, int64(i%64+1), found[fmt.Sprintf("file%05d", i)])
+		}
+	})
+
+	t.Run("largeCount", func(t *testing.T) {
+		dir, err := mount.OpenDir(dname)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, dir.Close()) }()
+
+		entries, err := dir.ReadDirPlusN(1000, StatxBasicStats, 0)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 102)
+		entries, err = dir.ReadDirPlusN(1000, StatxBasicStats, 0)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 0)
+	})
+
+	t.Run("invalidCount", func(t *testing.T) {
+		dir, err := mount.OpenDir(dname)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, dir.Close()) }()
+
+		_, err = dir.ReadDirPlusN(0, StatxBasicStats, 0)
+		assert.Error(t, err)
+	})
+}
+
+func benchmarkReadDirPlus(b *testing.B, readAll func(*Directory) int) {
+	const numEntries = 50000
+	mount := fsConnect(b)
+	defer fsDisconnect(b, mount)
+
+	dname := "/benchreaddirplus"
+	makeDirEntries(b, mount, dname, numEntries)
+	defer func() { assert.NoError(b, mount.RemoveAll(dname)) }()
+
+	dir, err := mount.OpenDir(dname)
+	require.NoError(b, err)
+	defer func() { assert.NoError(b, dir.Close()) }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dir.RewindDir()
+		n := readAll(dir)
+		require.Equal(b, numEntries+2, n)
+	}
+}
+
+func BenchmarkReadDirPlus(b *testing.B) {
+	benchmarkReadDirPlus(b, func(dir *Directory) int {
+		n := 0
+		for {
+			entry, err := dir.ReadDirPlus(StatxBasicStats, 0)
+			require.NoError(b, err)
+			if entry == nil {
+				return n
+			}
+			n++
+		}
+	})
+}
+
+func BenchmarkReadDirPlusN(b *testing.B) {
+	benchmarkReadDirPlus(b, func(dir *Directory) int {
+		n := 0
+		for {
+			entries, err := dir.ReadDirPlusN(1024, StatxBasicStats, 0)
+			require.NoError(b, err)
+			if len(entries) == 0 {
+				return n
+			}
+			n += len(entries)
+		}
+	})
+}
+
+func TestDirectoryEntries(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/direntries"
+	makeDirEntries(t, mount, dname, 1000)
+	defer func() { assert.NoError(t, mount.RemoveAll(dname)) }()
+
+	t.Run("all", func(t *testing.T) {
+		dir, err := mount.OpenDir(dname)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, dir.Close()) }()
+
+		found := map[string]bool{}
+		for r := range dir.Entries() {
+			require.NoError(t, r.Err)
+			assert.NotContains(t, found, r.Entry.Name())
+			found[r.Entry.Name()] = true
+		}
+		// 1000 files plus "." and ".."
+		assert.Len(t, found, 1002)
+		for i := 0; i < 1000; i++ {
+			assert.Contains(t, found, fmt.Sprintf("file%05d", i))
+		}
+	})
+
+	t.Run("closeEarly", func(t *testing.T) {
+		dir, err := mount.OpenDir(dname)
+		require.NoError(t, err)
+
+		entries := dir.Entries()
+		for i := 0; i < 10; i++ {
+			r, ok := <-entries
+			require.True(t, ok)
+			require.NoError(t, r.Err)
+		}
+		assert.NoError(t, dir.Close())
+		// the stream ends once the directory is closed
+		for range entries {
+		}
+	})
+}
@@ -59,8 +59,12 @@ const (
 // Private errors:
 
 const (
+	errExist       = cephFSError(-C.EEXIST)
 	errInvalid     = cephFSError(-C.EINVAL)
+	errLoop        = cephFSError(-C.ELOOP)
 	errNameTooLong = cephFSError(-C.ENAMETOOLONG)
+	errNoData      = cephFSError(-C.ENODATA)
 	errNoEntry     = cephFSError(-C.ENOENT)
+	errNotDir      = cephFSError(-C.ENOTDIR)
 	errRange       = cephFSError(-C.ERANGE)
 )
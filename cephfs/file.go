@@ -12,6 +12,7 @@ import "C"
 
 import (
 	"io"
+	"sync"
 	"unsafe"
 
 	"github.com/ceph/go-ceph/internal/cutil"
@@ -42,6 +43,14 @@ const (
 type File struct {
 	mount *MountInfo
 	fd    C.int
+	// flags are the flags the file was opened with.
+	flags int
+	// lockFh is a low-level handle to the same file, opened on demand and
+	// used to hold byte-range locks. It is protected by lockFhMutex.
+	lockFhMutex sync.Mutex
+	lockFh      *C.struct_Fh
+	// syncOnClose makes Close flush the file to stable storage first.
+	syncOnClose bool
 }
 
 // Open a file at the given path. The flags are the same os flags as
@@ -59,7 +68,7 @@ func (mount *MountInfo) Open(path string, flags int, mode uint32) (*File, error)
 	if ret < 0 {
 		return nil, getError(ret)
 	}
-	return &File{mount: mount, fd: ret}, nil
+	return &File{mount: mount, fd: ret, flags: flags}, nil
 }
 
 func (f *File) validate() error {
@@ -73,6 +82,8 @@ func (f *File) validate() error {
 //
 // Implements:
 //  int ceph_close(struct ceph_mount_info *cmount, int fd);
+//  int ceph_ll_close(struct ceph_mount_info *cmount, struct Fh* filehandle);
+//  int ceph_fsync(struct ceph_mount_info *cmount, int fd, int syncdataonly);
 func (f *File) Close() error {
 	if f.fd == -1 {
 		// already closed
@@ -81,11 +92,37 @@ func (f *File) Close() error {
 	if err := f.validate(); err != nil {
 		return err
 	}
+	var syncErr error
+	if f.syncOnClose {
+		// the file is closed even if the sync fails, to not leak it
+		syncErr = getError(C.ceph_fsync(f.mount.mount, f.fd, 0))
+	}
+	if err := f.close(); syncErr == nil {
+		return err
+	}
+	return syncErr
+}
+
+// close releases the file descriptor, and the lock handle if one was opened.
+// The descriptor is closed even if closing the lock handle fails, and the
+// first error is returned.
+func (f *File) close() error {
+	var lockErr error
+	f.lockFhMutex.Lock()
+	if f.lockFh != nil {
+		// closing the handle releases any byte-range locks held with it
+		lockErr = getError(C.ceph_ll_close(f.mount.mount, f.lockFh))
+		f.lockFh = nil
+	}
+	f.lockFhMutex.Unlock()
 	if err := getError(C.ceph_close(f.mount.mount, f.fd)); err != nil {
+		if lockErr != nil {
+			return lockErr
+		}
 		return err
 	}
 	f.fd = -1
-	return nil
+	return lockErr
 }
 
 // read directly wraps the ceph_read call. Because read is such a common
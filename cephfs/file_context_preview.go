@@ -0,0 +1,85 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"context"
+)
+
+type ioResult struct {
+	n   int
+	err error
+}
+
+// runIO runs the IO function in a separate goroutine and waits for it to
+// complete or for the context to be done, whichever comes first. If the
+// context is done first the IO function keeps running in the background
+// until the underlying C call returns.
+func runIO(ctx context.Context, io func() (int, error)) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	// buffered so that an abandoned goroutine does not block forever
+	done := make(chan ioResult, 1)
+	go func() {
+		n, err := io()
+		done <- ioResult{n, err}
+	}()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// ReadContext reads data from the file at the given offset into buf like
+// ReadAt, but gives up waiting once the context is done and returns the
+// error of the context. The read is performed into an internal buffer that
+// stays referenced until the C call returns, so buf may be reused as soon as
+// ReadContext returns. An abandoned read still holds the file, so the file
+// should not be closed while such a read may be outstanding.
+//  PREVIEW
+func (f *File) ReadContext(ctx context.Context, buf []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errInvalid
+	}
+	if err := f.validate(); err != nil {
+		return 0, err
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	tmp := make([]byte, len(buf))
+	n, err := runIO(ctx, func() (int, error) {
+		return f.read(tmp, offset)
+	})
+	// only copy once the read has completed, an abandoned read must never
+	// touch the caller's buffer
+	copy(buf, tmp[:n])
+	return n, err
+}
+
+// WriteContext writes buf to the file at the given offset like WriteAt, but
+// gives up waiting once the context is done and returns the error of the
+// context. The data is copied before it is written, so buf may be reused as
+// soon as WriteContext returns. A write that was given up on may still
+// complete later.
+//  PREVIEW
+func (f *File) WriteContext(ctx context.Context, buf []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errInvalid
+	}
+	if err := f.validate(); err != nil {
+		return 0, err
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	tmp := make([]byte, len(buf))
+	copy(tmp, buf)
+	return runIO(ctx, func() (int, error) {
+		return f.write(tmp, offset)
+	})
+}
@@ -0,0 +1,83 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileReadWriteContext(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "TestFileReadWriteContext.txt"
+	defer mount.Unlink(fname)
+
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, f.Close()) }()
+
+	ctx := context.Background()
+	n, err := f.WriteContext(ctx, []byte("hello context"), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 13, n)
+
+	buf := make([]byte, 32)
+	n, err = f.ReadContext(ctx, buf, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "context", string(buf[:n]))
+
+	_, err = f.ReadContext(ctx, buf, 13)
+	assert.Equal(t, io.EOF, err)
+
+	_, err = f.ReadContext(ctx, buf, -1)
+	assert.Equal(t, errInvalid, err)
+	_, err = f.WriteContext(ctx, buf, -1)
+	assert.Equal(t, errInvalid, err)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = f.ReadContext(cancelled, buf, 0)
+	assert.Equal(t, context.Canceled, err)
+	_, err = f.WriteContext(cancelled, []byte("nope"), 0)
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = (&File{}).ReadContext(ctx, buf, 0)
+	assert.Equal(t, ErrNotConnected, err)
+}
+
+func TestRunIOCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	blocked := func() (int, error) {
+		<-release
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, err := runIO(ctx, blocked)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	_, err = runIO(ctx2, blocked)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	n, err := runIO(context.Background(), func() (int, error) { return 3, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
@@ -0,0 +1,158 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <stdlib.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"io"
+	"sync"
+	"unsafe"
+)
+
+// FileHandle is a file opened through the low-level API of libcephfs, by
+// inode rather than by path, as returned by OpenByInode and DirHandle.Open.
+// Unlike a File it has no file descriptor, so only the operations below are
+// available.
+//  PREVIEW
+type FileHandle struct {
+	mount *MountInfo
+
+	lock   sync.Mutex
+	fh     *C.struct_Fh
+	offset int64
+}
+
+func newFileHandle(mount *MountInfo, fh *C.struct_Fh) *FileHandle {
+	return &FileHandle{mount: mount, fh: fh}
+}
+
+func (f *FileHandle) validate() error {
+	if f.mount == nil || f.fh == nil {
+		return ErrNotConnected
+	}
+	return f.mount.validate()
+}
+
+// Implements:
+//  int ceph_ll_read(struct ceph_mount_info *cmount, struct Fh* filehandle, int64_t off, uint64_t len,
+//                   char* buf);
+func (f *FileHandle) read(buf []byte, offset int64) (int, error) {
+	if err := f.validate(); err != nil {
+		return 0, err
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	bufptr := (*C.char)(unsafe.Pointer(&buf[0]))
+	ret := C.ceph_ll_read(
+		f.mount.mount, f.fh, C.int64_t(offset), C.uint64_t(len(buf)), bufptr)
+	switch {
+	case ret < 0:
+		return 0, getError(ret)
+	case ret == 0:
+		return 0, io.EOF
+	}
+	return int(ret), nil
+}
+
+// Implements:
+//  int ceph_ll_write(struct ceph_mount_info *cmount, struct Fh* filehandle, int64_t off, uint64_t len,
+//                    const char *data);
+func (f *FileHandle) write(buf []byte, offset int64) (int, error) {
+	if err := f.validate(); err != nil {
+		return 0, err
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	bufptr := (*C.char)(unsafe.Pointer(&buf[0]))
+	ret := C.ceph_ll_write(
+		f.mount.mount, f.fh, C.int64_t(offset), C.uint64_t(len(buf)), bufptr)
+	if ret < 0 {
+		return 0, getError(ret)
+	}
+	return int(ret), nil
+}
+
+// Read data from the file, starting at the current file offset, which is
+// advanced by the number of bytes read. When nothing is left to read from
+// the file, Read returns 0, io.EOF.
+//  PREVIEW
+func (f *FileHandle) Read(buf []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	n, err := f.read(buf, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt will read data from the file starting at the given offset. When
+// nothing is left to read from the file, ReadAt returns 0, io.EOF.
+//  PREVIEW
+func (f *FileHandle) ReadAt(buf []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errInvalid
+	}
+	return f.read(buf, offset)
+}
+
+// Write data from buf to the file at the current file offset, which is
+// advanced by the number of bytes written.
+//  PREVIEW
+func (f *FileHandle) Write(buf []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	n, err := f.write(buf, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// WriteAt writes data from buf to the file at the specified offset.
+//  PREVIEW
+func (f *FileHandle) WriteAt(buf []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errInvalid
+	}
+	return f.write(buf, offset)
+}
+
+// Fsync ensures the file content that may be cached is committed to stable
+// storage.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_fsync(struct ceph_mount_info *cmount, struct Fh *fh, int syncdataonly);
+func (f *FileHandle) Fsync(sync SyncChoice) error {
+	if err := f.validate(); err != nil {
+		return err
+	}
+	return getError(C.ceph_ll_fsync(f.mount.mount, f.fh, C.int(sync)))
+}
+
+// Close the file. Closing a file more than once does nothing.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_close(struct ceph_mount_info *cmount, struct Fh* filehandle);
+func (f *FileHandle) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.fh == nil {
+		// already closed
+		return nil
+	}
+	if err := f.validate(); err != nil {
+		return err
+	}
+	ret := C.ceph_ll_close(f.mount.mount, f.fh)
+	f.fh = nil
+	return getError(ret)
+}
@@ -0,0 +1,164 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <stdlib.h>
+#include <fcntl.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+// LockType is the type of a byte-range (record) lock.
+type LockType int
+
+const (
+	// LockTypeRead is a shared lock. Any number of handles may hold a read
+	// lock on the same range.
+	LockTypeRead = LockType(C.F_RDLCK)
+	// LockTypeWrite is an exclusive lock. Only one handle may hold a write
+	// lock on the range and no read locks may overlap it.
+	LockTypeWrite = LockType(C.F_WRLCK)
+	// LockTypeUnlock removes locks held on the range.
+	LockTypeUnlock = LockType(C.F_UNLCK)
+)
+
+// LockInfo describes a byte-range lock.
+type LockInfo struct {
+	// Type of the lock. When returned by GetLock a type of LockTypeUnlock
+	// indicates no conflicting lock exists.
+	Type LockType
+	// Start is the offset of the first byte of the locked range.
+	Start int64
+	// Length of the locked range. A length of zero extends the range to the
+	// end of the file.
+	Length int64
+	// PID of the lock holder.
+	PID int
+}
+
+// lockOwner returns the owner used for byte-range locks taken through the
+// file. Locks are owned by the open file rather than by the process, so two
+// Files conflict with each other even when they share a mount.
+func (f *File) lockOwner() C.uint64_t {
+	return C.uint64_t(f.fd)
+}
+
+// getLockFh returns the low-level handle used to hold byte-range locks,
+// opening it if needed. The handle is opened with the access mode of the
+// file, as the type of lock that may be taken depends on it.
+//
+// Implements:
+//  int ceph_ll_lookup_inode(struct ceph_mount_info *cmount, struct inodeno_t ino, Inode **inode);
+//  int ceph_ll_open(struct ceph_mount_info *cmount, struct Inode *in, int flags, struct Fh **fh,
+//                   const UserPerm *perms);
+func (f *File) getLockFh() (*C.struct_Fh, error) {
+	f.lockFhMutex.Lock()
+	defer f.lockFhMutex.Unlock()
+	if f.lockFh != nil {
+		return f.lockFh, nil
+	}
+	st, err := f.Fstatx(StatxIno, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var inode *C.struct_Inode
+	ino := C.struct_inodeno_t{val: C.uint64_t(st.Inode)}
+	ret := C.ceph_ll_lookup_inode(f.mount.mount, ino, &inode)
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	defer C.ceph_ll_put(f.mount.mount, inode)
+
+	var fh *C.struct_Fh
+	ret = C.ceph_ll_open(
+		f.mount.mount, inode, C.int(f.flags)&C.O_ACCMODE, &fh,
+		C.ceph_mount_perms(f.mount.mount))
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	f.lockFh = fh
+	return fh, nil
+}
+
+// SetLock acquires, or with LockTypeUnlock releases, a byte-range lock of
+// length bytes starting at start, with POSIX fcntl semantics. If wait is
+// false and a conflicting lock is held through another handle, an EAGAIN
+// error is returned. If wait is true the call blocks until the lock can be
+// acquired. Locks are released when the File is closed.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_setlk(struct ceph_mount_info *cmount, Fh *fh, struct flock *fl, uint64_t owner, int sleep);
+func (f *File) SetLock(lockType LockType, start, length int64, wait bool) error {
+	if err := f.validate(); err != nil {
+		return err
+	}
+	switch lockType {
+	case LockTypeRead, LockTypeWrite, LockTypeUnlock:
+	default:
+		return errInvalid
+	}
+	if start < 0 || length < 0 {
+		return errInvalid
+	}
+	fh, err := f.getLockFh()
+	if err != nil {
+		return err
+	}
+
+	fl := C.struct_flock{
+		l_type:   C.short(lockType),
+		l_whence: C.SEEK_SET,
+		l_start:  C.off_t(start),
+		l_len:    C.off_t(length),
+	}
+	sleep := C.int(0)
+	if wait {
+		sleep = 1
+	}
+	ret := C.ceph_ll_setlk(f.mount.mount, fh, &fl, f.lockOwner(), sleep)
+	return getError(ret)
+}
+
+// GetLock returns information about a lock, held through another handle,
+// that would conflict with a write lock of length bytes starting at start.
+// If no conflicting lock exists the returned LockInfo has the type
+// LockTypeUnlock.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_getlk(struct ceph_mount_info *cmount, Fh *fh, struct flock *fl, uint64_t owner);
+func (f *File) GetLock(start, length int64) (LockInfo, error) {
+	if err := f.validate(); err != nil {
+		return LockInfo{}, err
+	}
+	if start < 0 || length < 0 {
+		return LockInfo{}, errInvalid
+	}
+	fh, err := f.getLockFh()
+	if err != nil {
+		return LockInfo{}, err
+	}
+
+	fl := C.struct_flock{
+		l_type:   C.F_WRLCK,
+		l_whence: C.SEEK_SET,
+		l_start:  C.off_t(start),
+		l_len:    C.off_t(length),
+	}
+	ret := C.ceph_ll_getlk(f.mount.mount, fh, &fl, f.lockOwner())
+	if ret < 0 {
+		return LockInfo{}, getError(ret)
+	}
+	return LockInfo{
+		Type:   LockType(fl.l_type),
+		Start:  int64(fl.l_start),
+		Length: int64(fl.l_len),
+		PID:    int(fl.l_pid),
+	}, nil
+}
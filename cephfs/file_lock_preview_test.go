@@ -0,0 +1,136 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileByteRangeLocks(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "/TestFileByteRangeLocks.txt"
+	f1, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+	f2, err := mount.Open(fname, os.O_RDWR, 0666)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, f2.Close()) }()
+
+	errAgain := cephFSError(-int(syscall.EAGAIN))
+
+	t.Run("nonOverlapping", func(t *testing.T) {
+		err := f1.SetLock(LockTypeWrite, 0, 100, false)
+		assert.NoError(t, err)
+		err = f2.SetLock(LockTypeWrite, 100, 100, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("overlapping", func(t *testing.T) {
+		err := f2.SetLock(LockTypeWrite, 50, 10, false)
+		assert.Equal(t, errAgain, err)
+		err = f2.SetLock(LockTypeRead, 50, 10, false)
+		assert.Equal(t, errAgain, err)
+
+		li, err := f2.GetLock(50, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, LockTypeWrite, li.Type)
+		assert.Equal(t, int64(0), li.Start)
+		assert.Equal(t, int64(100), li.Length)
+
+		li, err = f2.GetLock(150, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, LockTypeUnlock, li.Type)
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		err := f1.SetLock(LockTypeUnlock, 0, 100, false)
+		assert.NoError(t, err)
+		err = f2.SetLock(LockTypeWrite, 50, 10, false)
+		assert.NoError(t, err)
+		err = f2.SetLock(LockTypeUnlock, 0, 0, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("releasedOnClose", func(t *testing.T) {
+		err := f1.SetLock(LockTypeWrite, 0, 0, false)
+		assert.NoError(t, err)
+		err = f2.SetLock(LockTypeWrite, 10, 10, false)
+		assert.Equal(t, errAgain, err)
+
+		assert.NoError(t, f1.Close())
+		err = f2.SetLock(LockTypeWrite, 10, 10, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		fname := "/TestFileByteRangeLocks.concurrent"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		// the lock handle is opened once, however many goroutines ask
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := f.GetLock(int64(i)*10, 10)
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		// closing releases both the lock handle and the descriptor
+		assert.NoError(t, f.SetLock(LockTypeWrite, 0, 10, false))
+		assert.NoError(t, f.Close())
+		assert.Nil(t, f.lockFh)
+		assert.EqualValues(t, -1, f.fd)
+
+		f3, err := mount.Open(fname, os.O_RDWR, 0666)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, f3.Close()) }()
+		assert.NoError(t, f3.SetLock(LockTypeWrite, 0, 10, false))
+	})
+
+	t.Run("writeOnly", func(t *testing.T) {
+		fname := "/TestFileByteRangeLocks.writeOnly"
+		f, err := mount.Open(fname, os.O_WRONLY|os.O_CREATE, 0200)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+		defer func() { assert.NoError(t, f.Close()) }()
+
+		// the lock handle of a file opened for writing only works for
+		// write locks
+		assert.NoError(t, f.SetLock(LockTypeWrite, 0, 10, false))
+		info, err := f.GetLock(0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, LockTypeUnlock, info.Type)
+		assert.NoError(t, f.SetLock(LockTypeUnlock, 0, 10, false))
+	})
+
+	t.Run("invalidArgs", func(t *testing.T) {
+		err := f2.SetLock(LockType(999), 0, 10, false)
+		assert.Equal(t, errInvalid, err)
+		err = f2.SetLock(LockTypeWrite, -1, 10, false)
+		assert.Equal(t, errInvalid, err)
+		_, err = f2.GetLock(0, -1)
+		assert.Equal(t, errInvalid, err)
+	})
+
+	t.Run("invalidFile", func(t *testing.T) {
+		f := &File{}
+		err := f.SetLock(LockTypeWrite, 0, 10, false)
+		assert.Error(t, err)
+		_, err = f.GetLock(0, 10)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,52 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/retry"
+)
+
+// GetPool returns the name and id of the data pool that holds the data of
+// the file, as chosen by its layout.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_get_file_pool(struct ceph_mount_info *cmount, int fh);
+//  int ceph_get_file_pool_name(struct ceph_mount_info *cmount, int fh, char *buf, size_t buflen);
+func (f *File) GetPool() (string, int64, error) {
+	if err := f.validate(); err != nil {
+		return "", 0, err
+	}
+
+	ret := C.ceph_get_file_pool(f.mount.mount, f.fd)
+	if err := getErrorIfNegative(ret); err != nil {
+		return "", 0, err
+	}
+	id := int64(ret)
+
+	var (
+		buf []byte
+		err error
+	)
+	retry.WithSizes(64, 4096, func(size int) retry.Hint {
+		buf = make([]byte, size)
+		ret = C.ceph_get_file_pool_name(
+			f.mount.mount, f.fd, (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(size))
+		err = getErrorIfNegative(ret)
+		return retry.DoubleSize.If(err == errRange)
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return C.GoStringN((*C.char)(unsafe.Pointer(&buf[0])), ret), id, nil
+}
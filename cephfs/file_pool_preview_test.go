@@ -0,0 +1,69 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileGetPool(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(t, conn.MakePool(pool))
+	defer conn.DeletePool(pool)
+	defer addFSDataPool(t, conn, pool)()
+	poolID, err := conn.GetPoolByName(pool)
+	require.NoError(t, err)
+
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestFileGetPool"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+	err = mount.SetXattr(dname, "ceph.dir.layout.pool", []byte(pool), XattrDefault)
+	require.NoError(t, err)
+
+	t.Run("customLayout", func(t *testing.T) {
+		fname := dname + "/file"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+		defer func() { assert.NoError(t, f.Close()) }()
+
+		name, id, err := f.GetPool()
+		assert.NoError(t, err)
+		assert.Equal(t, pool, name)
+		assert.Equal(t, poolID, id)
+	})
+
+	t.Run("defaultLayout", func(t *testing.T) {
+		fname := "/TestFileGetPool.file"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+		defer func() { assert.NoError(t, f.Close()) }()
+
+		name, id, err := f.GetPool()
+		assert.NoError(t, err)
+		assert.NotEqual(t, pool, name)
+		assert.NotEqual(t, poolID, id)
+		expected, err := mount.GetPathPoolName(fname)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, name)
+	})
+
+	t.Run("invalidFile", func(t *testing.T) {
+		f := &File{}
+		_, _, err := f.GetPool()
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
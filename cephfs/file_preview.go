@@ -0,0 +1,137 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <errno.h>
+#include <fcntl.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+// OpenNoFollow opens a file at the given path like Open but does not follow
+// the final component of the path if it is a symbolic link. Opening a path
+// whose final component is a symbolic link fails with an ELOOP error.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_open(struct ceph_mount_info *cmount, const char *path, int flags, mode_t mode);
+func (mount *MountInfo) OpenNoFollow(path string, flags int, mode uint32) (*File, error) {
+	return mount.Open(path, flags|int(C.O_NOFOLLOW), mode)
+}
+
+// OpenByInode opens an existing file given its inode number, as reported
+// by Statx, rather than its path. The file can be reopened this way after
+// it has been renamed. The flags are the same os flags as a local open call
+// but files can not be created this way.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_ll_lookup_inode(struct ceph_mount_info *cmount, struct inodeno_t ino, Inode **inode);
+//  int ceph_ll_open(struct ceph_mount_info *cmount, struct Inode *in, int flags, struct Fh **fh,
+//                   const UserPerm *perms);
+func (mount *MountInfo) OpenByInode(ino uint64, flags int) (*FileHandle, error) {
+	if err := mount.validate(); err != nil {
+		return nil, err
+	}
+	if flags&C.O_CREAT != 0 {
+		return nil, errInvalid
+	}
+
+	var inode *C.struct_Inode
+	cIno := C.struct_inodeno_t{val: C.uint64_t(ino)}
+	ret := C.ceph_ll_lookup_inode(mount.mount, cIno, &inode)
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	defer C.ceph_ll_put(mount.mount, inode)
+
+	var fh *C.struct_Fh
+	ret = C.ceph_ll_open(
+		mount.mount, inode, C.int(flags), &fh, C.ceph_mount_perms(mount.mount))
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	return newFileHandle(mount, fh), nil
+}
+
+// ReadV will read data from the file, starting at the current file offset,
+// into the byte-slice data buffers sequentially. The file offset is advanced
+// by the number of bytes read.
+// The number of bytes read will be returned.
+// When nothing is left to read from the file the return values will be:
+// 0, io.EOF.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_preadv(struct ceph_mount_info *cmount, int fd, const struct iovec *iov, int iovcnt,
+//                  int64_t offset);
+func (f *File) ReadV(data [][]byte) (int, error) {
+	return f.Preadv(data, -1)
+}
+
+// WriteV writes data from the slice of byte-slice buffers to the file at the
+// current file offset. The file offset is advanced by the number of bytes
+// written.
+// The number of bytes written is returned.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_pwritev(struct ceph_mount_info *cmount, int fd, const struct iovec *iov, int iovcnt,
+//                   int64_t offset);
+func (f *File) WriteV(data [][]byte) (int, error) {
+	return f.Pwritev(data, -1)
+}
+
+// GetInode returns the inode number of the open file.
+//  PREVIEW
+func (f *File) GetInode() (uint64, error) {
+	st, err := f.Fstatx(StatxIno, 0)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(st.Inode), nil
+}
+
+// WriteAtExtend writes data to the file at the given offset like WriteAt.
+// If the write ends past the end of the file, the file is first extended to
+// its new size with a single fallocate call so the space is allocated before
+// the data is written.
+// The number of bytes written is returned.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_fallocate(struct ceph_mount_info *cmount, int fd, int mode,
+//                     int64_t offset, int64_t length);
+//  int ceph_write(struct ceph_mount_info *cmount, int fd, const char *buf,
+//                 int64_t size, int64_t offset);
+func (f *File) WriteAtExtend(data []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errInvalid
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	st, err := f.Fstatx(StatxSize, 0)
+	if err != nil {
+		return 0, err
+	}
+	size := int64(st.Size)
+	if end := offset + int64(len(data)); end > size {
+		if err := f.Fallocate(FallocNoFlag, size, end-size); err != nil {
+			return 0, err
+		}
+	}
+	return f.WriteAt(data, offset)
+}
+
+// SetSyncOnClose controls whether Close flushes the data and metadata of the
+// file to stable storage before closing it. By default it does not. If the
+// flush fails the file is still closed and Close returns the error.
+//  PREVIEW
+func (f *File) SetSyncOnClose(enable bool) {
+	f.syncOnClose = enable
+}
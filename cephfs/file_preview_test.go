@@ -0,0 +1,362 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileReadVWriteV(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "TestFileReadVWriteV.txt"
+	defer mount.Unlink(fname)
+
+	t.Run("sequential", func(t *testing.T) {
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, f.Close()) }()
+
+		n, err := f.WriteV([][]byte{[]byte("foobar"), []byte("baz")})
+		assert.NoError(t, err)
+		assert.Equal(t, 9, n)
+		n, err = f.WriteV([][]byte{[]byte("alpha"), []byte("beta")})
+		assert.NoError(t, err)
+		assert.Equal(t, 9, n)
+
+		pos, err := f.Seek(0, SeekCur)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(18), pos)
+
+		_, err = f.Seek(0, SeekSet)
+		require.NoError(t, err)
+
+		o := [][]byte{make([]byte, 3), make([]byte, 3)}
+		n, err = f.ReadV(o)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, n)
+		assert.Equal(t, "foo", string(o[0]))
+		assert.Equal(t, "bar", string(o[1]))
+
+		o = [][]byte{make([]byte, 4), make([]byte, 8)}
+		n, err = f.ReadV(o)
+		assert.NoError(t, err)
+		assert.Equal(t, 12, n)
+		assert.Equal(t, "baza", string(o[0]))
+		assert.Equal(t, "lphabeta", string(o[1]))
+
+		n, err = f.ReadV(o)
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, 0, n)
+	})
+
+	t.Run("writeInvalidFile", func(t *testing.T) {
+		f := &File{}
+		_, err := f.WriteV([][]byte{})
+		assert.Error(t, err)
+	})
+
+	t.Run("readInvalidFile", func(t *testing.T) {
+		f := &File{}
+		_, err := f.ReadV([][]byte{})
+		assert.Error(t, err)
+	})
+}
+
+func TestOpenNoFollow(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "/nofollow.file"
+	lname := "/nofollow.link"
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+	require.NoError(t, err)
+	assert.NoError(t, f.Close())
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+	require.NoError(t, mount.Symlink(fname, lname))
+	defer func() { assert.NoError(t, mount.Unlink(lname)) }()
+
+	t.Run("regularFile", func(t *testing.T) {
+		f, err := mount.OpenNoFollow(fname, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		f, err := mount.OpenNoFollow(lname, os.O_RDONLY, 0)
+		assert.Equal(t, errLoop, err)
+		assert.Nil(t, f)
+
+		// without nofollow the link is followed
+		f, err = mount.Open(lname, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		_, err := m.OpenNoFollow(fname, os.O_RDONLY, 0)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
+
+func TestOpenByInode(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "/byinode.file"
+	rname := "/byinode.renamed"
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("stable handle"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	st, err := mount.Statx(fname, StatxIno, 0)
+	require.NoError(t, err)
+	require.NoError(t, mount.Rename(fname, rname))
+	defer func() { assert.NoError(t, mount.Unlink(rname)) }()
+
+	t.Run("read", func(t *testing.T) {
+		f, err := mount.OpenByInode(uint64(st.Inode), os.O_RDONLY)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, f.Close()) }()
+
+		buf := make([]byte, 64)
+		n, err := f.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "stable handle", string(buf[:n]))
+
+		// reads without an offset start at the beginning of the file
+		n, err = f.Read(buf[:6])
+		assert.NoError(t, err)
+		assert.Equal(t, "stable", string(buf[:n]))
+		n, err = f.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, " handle", string(buf[:n]))
+		_, err = f.Read(buf)
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		f, err := mount.OpenByInode(uint64(st.Inode), os.O_WRONLY)
+		require.NoError(t, err)
+		_, err = f.WriteAt([]byte("STABLE"), 0)
+		assert.NoError(t, err)
+		assert.NoError(t, f.Fsync(SyncAll))
+		assert.NoError(t, f.Close())
+		// closing twice is a no-op
+		assert.NoError(t, f.Close())
+
+		f2, err := mount.Open(rname, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, f2.Close()) }()
+		buf := make([]byte, 64)
+		n, err := f2.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "STABLE handle", string(buf[:n]))
+	})
+
+	t.Run("create", func(t *testing.T) {
+		_, err := mount.OpenByInode(uint64(st.Inode), os.O_RDWR|os.O_CREATE)
+		assert.Equal(t, errInvalid, err)
+	})
+
+	t.Run("missingInode", func(t *testing.T) {
+		_, err := mount.OpenByInode(0xffffffffff, os.O_RDONLY)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		_, err := m.OpenByInode(uint64(st.Inode), os.O_RDONLY)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		f, err := mount.OpenByInode(uint64(st.Inode), os.O_RDONLY)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		_, err = f.ReadAt(make([]byte, 8), 0)
+		assert.Equal(t, ErrNotConnected, err)
+		_, err = f.Write([]byte("x"))
+		assert.Equal(t, ErrNotConnected, err)
+		assert.Equal(t, ErrNotConnected, f.Fsync(SyncAll))
+	})
+}
+
+func TestWriteAtExtend(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "/writeatextend.file"
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+	defer func() { assert.NoError(t, f.Close()) }()
+
+	t.Run("appends", func(t *testing.T) {
+		var offset int64
+		for _, chunk := range []string{"one ", "two ", "three"} {
+			n, err := f.WriteAtExtend([]byte(chunk), offset)
+			assert.NoError(t, err)
+			assert.Equal(t, len(chunk), n)
+			offset += int64(n)
+		}
+		st, err := f.Fstatx(StatxSize, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(13), st.Size)
+
+		buf := make([]byte, 32)
+		n, err := f.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "one two three", string(buf[:n]))
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		n, err := f.WriteAtExtend([]byte("TWO"), 4)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		st, err := f.Fstatx(StatxSize, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(13), st.Size)
+	})
+
+	t.Run("pastEnd", func(t *testing.T) {
+		n, err := f.WriteAtExtend([]byte("end"), 20)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		st, err := f.Fstatx(StatxSize, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(23), st.Size)
+
+		buf := make([]byte, 32)
+		n, err = f.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "one TWO three\x00\x00\x00\x00\x00\x00\x00end", string(buf[:n]))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := f.WriteAtExtend([]byte("x"), -1)
+		assert.Equal(t, errInvalid, err)
+		n, err := f.WriteAtExtend(nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+	})
+}
+
+func benchmarkAppend(b *testing.B, write func(*File, []byte, int64) (int, error)) {
+	mount := fsConnect(b)
+	defer fsDisconnect(b, mount)
+
+	fname := "/benchappend.file"
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	require.NoError(b, err)
+	defer func() { assert.NoError(b, mount.Unlink(fname)) }()
+	defer func() { assert.NoError(b, f.Close()) }()
+
+	chunk := make([]byte, 4096)
+	b.SetBytes(int64(len(chunk)))
+	b.ResetTimer()
+	var offset int64
+	for i := 0; i < b.N; i++ {
+		n, err := write(f, chunk, offset)
+		require.NoError(b, err)
+		offset += int64(n)
+	}
+}
+
+func BenchmarkAppendWriteAt(b *testing.B) {
+	benchmarkAppend(b, (*File).WriteAt)
+}
+
+func BenchmarkAppendWriteAtExtend(b *testing.B) {
+	benchmarkAppend(b, (*File).WriteAtExtend)
+}
+
+// fsyncCount returns the number of file syncs made by the client whose admin
+// socket is at asok, as reported by its fsync latency counter.
+func fsyncCount(t *testing.T, asok string) uint64 {
+	buf, err := adminSocketCommand(asok, "perf dump")
+	require.NoError(t, err)
+	var dump struct {
+		Client struct {
+			Fsync timeAvgCounter `json:"fsync"`
+		} `json:"client"`
+	}
+	require.NoError(t, json.Unmarshal(buf, &dump))
+	return dump.Client.Fsync.AvgCount
+}
+
+func TestSetSyncOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-ceph-sync-on-close")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	asok := filepath.Join(dir, "client.asok")
+
+	// the file syncs of the mount are counted by the client
+	mount := fsConnectWithAdminSocket(t, asok)
+	defer fsDisconnect(t, mount)
+	mount2 := fsConnect(t)
+	defer fsDisconnect(t, mount2)
+
+	t.Run("enabled", func(t *testing.T) {
+		fname := "/TestSetSyncOnClose.on"
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		f, err := mount.Open(fname, os.O_WRONLY|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		f.SetSyncOnClose(true)
+		_, err = f.Write([]byte("durable data"))
+		assert.NoError(t, err)
+		before := fsyncCount(t, asok)
+		assert.NoError(t, f.Close())
+		assert.Equal(t, before+1, fsyncCount(t, asok))
+
+		f2, err := mount2.Open(fname, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, f2.Close()) }()
+		buf := make([]byte, 32)
+		n, err := f2.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "durable data", string(buf[:n]))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		fname := "/TestSetSyncOnClose.off"
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		f, err := mount.Open(fname, os.O_WRONLY|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		f.SetSyncOnClose(true)
+		f.SetSyncOnClose(false)
+		_, err = f.Write([]byte("data"))
+		assert.NoError(t, err)
+		before := fsyncCount(t, asok)
+		assert.NoError(t, f.Close())
+		assert.Equal(t, before, fsyncCount(t, asok))
+	})
+
+	t.Run("closeTwice", func(t *testing.T) {
+		fname := "/TestSetSyncOnClose.twice"
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		f, err := mount.Open(fname, os.O_WRONLY|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		f.SetSyncOnClose(true)
+		before := fsyncCount(t, asok)
+		assert.NoError(t, f.Close())
+		assert.NoError(t, f.Close())
+		assert.Equal(t, before+1, fsyncCount(t, asok))
+	})
+}
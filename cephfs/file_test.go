@@ -60,7 +60,7 @@ func TestFileOpen(t *testing.T) {
 	})
 
 	t.Run("invalidFdClose", func(t *testing.T) {
-		f := &File{mount, 1980}
+		f := &File{mount: mount, fd: 1980}
 		err := f.Close()
 		assert.Error(t, err)
 	})
@@ -409,6 +409,42 @@ func TestFchown(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestFchmodFchownFstatx(t *testing.T) {
+	fname := "TestFchmodFchownFstatx.txt"
+	// dockerfile creates bob user account
+	var bob uint32 = 1010
+
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	f1, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0644)
+	assert.NoError(t, err)
+	assert.NotNil(t, f1)
+	defer func() {
+		assert.NoError(t, f1.Close())
+		assert.NoError(t, mount.Unlink(fname))
+	}()
+
+	// changes made through the handle are visible through the handle
+	// without resolving the path again
+	err = f1.Fchmod(0600)
+	assert.NoError(t, err)
+	err = f1.Fchown(bob, bob)
+	assert.NoError(t, err)
+
+	sx, err := f1.Fstatx(StatxBasicStats, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0600), sx.Mode&0777)
+	assert.Equal(t, bob, sx.Uid)
+	assert.Equal(t, bob, sx.Gid)
+
+	err = f1.Fchmod(0640)
+	assert.NoError(t, err)
+	sx, err = f1.Fstatx(StatxMode, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0640), sx.Mode&0777)
+}
+
 func TestFstatx(t *testing.T) {
 	fname := "test_fstatx.txt"
 
@@ -0,0 +1,200 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	dirLayoutXattr               = "ceph.dir.layout"
+	fileLayoutXattr              = "ceph.file.layout"
+	dirLayoutPoolNamespaceXattr  = "ceph.dir.layout.pool_namespace"
+	fileLayoutPoolNamespaceXattr = "ceph.file.layout.pool_namespace"
+)
+
+// SetDirLayoutPoolNamespace sets the rados namespace, within the data pool,
+// that the layout of the directory at the given path places the objects of
+// new files in. An empty namespace removes the setting from the directory,
+// and new files then inherit the namespace of its parent directories.
+//  PREVIEW
+func (mount *MountInfo) SetDirLayoutPoolNamespace(path, ns string) error {
+	if ns == "" {
+		return mount.RemoveXattr(path, dirLayoutPoolNamespaceXattr)
+	}
+	return mount.SetXattr(
+		path, dirLayoutPoolNamespaceXattr, []byte(ns), XattrDefault)
+}
+
+// GetDirLayoutPoolNamespace returns the rados namespace set on the layout of
+// the directory at the given path. An empty string is returned if the
+// directory has no namespace of its own.
+//  PREVIEW
+func (mount *MountInfo) GetDirLayoutPoolNamespace(path string) (string, error) {
+	return mount.getLayoutXattr(path, dirLayoutPoolNamespaceXattr)
+}
+
+// SetFileLayoutPoolNamespace sets the rados namespace that the objects of
+// the file at the given path are placed in. The layout of a file can only be
+// changed while the file is empty.
+//  PREVIEW
+func (mount *MountInfo) SetFileLayoutPoolNamespace(path, ns string) error {
+	if ns == "" {
+		return mount.RemoveXattr(path, fileLayoutPoolNamespaceXattr)
+	}
+	return mount.SetXattr(
+		path, fileLayoutPoolNamespaceXattr, []byte(ns), XattrDefault)
+}
+
+// GetFileLayoutPoolNamespace returns the rados namespace that the objects of
+// the file at the given path are placed in. An empty string is returned for
+// files in the default namespace.
+//  PREVIEW
+func (mount *MountInfo) GetFileLayoutPoolNamespace(path string) (string, error) {
+	return mount.getLayoutXattr(path, fileLayoutPoolNamespaceXattr)
+}
+
+func (mount *MountInfo) getLayoutXattr(path, name string) (string, error) {
+	value, err := mount.GetXattr(path, name)
+	if err == errNoData {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// FileLayout describes how the data of files is striped over rados objects.
+// Fields left at their zero value are not set, and are inherited from the
+// parent directories or the file system defaults instead.
+type FileLayout struct {
+	// StripeUnit is the size, in bytes, of the blocks data is striped in.
+	StripeUnit uint64
+	// StripeCount is the number of objects a stripe is spread across.
+	StripeCount uint64
+	// ObjectSize is the size, in bytes, of the objects data is stored in.
+	ObjectSize uint64
+	// Pool is the name or ID of the data pool the objects are stored in.
+	Pool string
+	// PoolNamespace is the rados namespace within the pool.
+	PoolNamespace string
+}
+
+// xattrValue returns the layout in the form accepted by the layout xattrs,
+// with all fields set at once so that they are validated together.
+func (l *FileLayout) xattrValue() string {
+	fields := []string{}
+	if l.StripeUnit != 0 {
+		fields = append(fields, fmt.Sprintf("stripe_unit=%d", l.StripeUnit))
+	}
+	if l.StripeCount != 0 {
+		fields = append(fields, fmt.Sprintf("stripe_count=%d", l.StripeCount))
+	}
+	if l.ObjectSize != 0 {
+		fields = append(fields, fmt.Sprintf("object_size=%d", l.ObjectSize))
+	}
+	if l.Pool != "" {
+		fields = append(fields, "pool="+l.Pool)
+	}
+	if l.PoolNamespace != "" {
+		fields = append(fields, "pool_namespace="+l.PoolNamespace)
+	}
+	return strings.Join(fields, " ")
+}
+
+// MkdirWithLayout creates a directory and sets the given layout on it, so
+// that files created in the directory use the layout. If the layout can not
+// be set the directory is removed again and the error is returned. A nil
+// layout creates the directory like MakeDir.
+//  PREVIEW
+func (mount *MountInfo) MkdirWithLayout(path string, mode uint32, layout *FileLayout) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	if err := mount.MakeDir(path, mode); err != nil {
+		return err
+	}
+	if layout == nil {
+		return nil
+	}
+	value := layout.xattrValue()
+	if value == "" {
+		return nil
+	}
+	err := mount.SetXattr(path, dirLayoutXattr, []byte(value), XattrDefault)
+	if err != nil {
+		// the directory was just created, so it is still empty
+		mount.RemoveDir(path)
+		return err
+	}
+	return nil
+}
+
+// parseFileLayout parses the value of the ceph.file.layout xattr, a space
+// separated list of field=value pairs.
+func parseFileLayout(value string) (*FileLayout, error) {
+	l := &FileLayout{}
+	for _, field := range strings.Fields(value) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, errInvalid
+		}
+		var (
+			n   *uint64
+			err error
+		)
+		switch kv[0] {
+		case "stripe_unit":
+			n = &l.StripeUnit
+		case "stripe_count":
+			n = &l.StripeCount
+		case "object_size":
+			n = &l.ObjectSize
+		case "pool":
+			l.Pool = kv[1]
+		case "pool_namespace":
+			l.PoolNamespace = kv[1]
+		}
+		if n != nil {
+			if *n, err = strconv.ParseUint(kv[1], 10, 64); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l, nil
+}
+
+// GetFileLayout returns the layout of the file at the given path. All
+// fields are set, except for PoolNamespace for files in the default
+// namespace.
+//  PREVIEW
+func (mount *MountInfo) GetFileLayout(path string) (*FileLayout, error) {
+	value, err := mount.GetXattr(path, fileLayoutXattr)
+	if err != nil {
+		return nil, err
+	}
+	return parseFileLayout(string(value))
+}
+
+// CopyLayout sets the layout of the file at dstPath to the layout of the
+// file at srcPath. The layout of a file can only be changed while the file is
+// empty, so an error is returned if the destination holds any data.
+//  PREVIEW
+func (mount *MountInfo) CopyLayout(srcPath, dstPath string) error {
+	layout, err := mount.GetFileLayout(srcPath)
+	if err != nil {
+		return err
+	}
+	err = mount.SetXattr(
+		dstPath, fileLayoutXattr, []byte(layout.xattrValue()), XattrDefault)
+	if err != nil || layout.PoolNamespace != "" {
+		return err
+	}
+	// the layout value leaves out an empty namespace, it has to be cleared
+	// on its own so that dstPath does not keep a namespace it had before
+	return mount.SetFileLayoutPoolNamespace(dstPath, "")
+}
@@ -0,0 +1,236 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutPoolNamespace(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestLayoutPoolNamespace"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	ns, err := mount.GetDirLayoutPoolNamespace(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, "", ns)
+
+	err = mount.SetDirLayoutPoolNamespace(dname, "TestLayoutNS")
+	require.NoError(t, err)
+	ns, err = mount.GetDirLayoutPoolNamespace(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, "TestLayoutNS", ns)
+
+	fname := dname + "/file"
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0644)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+	_, err = f.Write([]byte("namespaced data"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Sync())
+	assert.NoError(t, f.Close())
+
+	ns, err = mount.GetFileLayoutPoolNamespace(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, "TestLayoutNS", ns)
+
+	t.Run("backingObjects", func(t *testing.T) {
+		conn := radosConnect(t)
+		defer conn.Shutdown()
+
+		pool, err := mount.GetPathPoolName(fname)
+		require.NoError(t, err)
+		st, err := mount.Statx(fname, StatxIno, 0)
+		require.NoError(t, err)
+		ioctx, err := conn.OpenIOContext(pool)
+		require.NoError(t, err)
+		defer ioctx.Destroy()
+
+		// the first data object of a file is named <inode>.00000000
+		oid := fmt.Sprintf("%x.%08x", st.Inode, 0)
+		_, err = ioctx.Stat(oid)
+		assert.Error(t, err)
+		ioctx.SetNamespace("TestLayoutNS")
+		stat, err := ioctx.Stat(oid)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len("namespaced data"), stat.Size)
+	})
+
+	t.Run("emptyFile", func(t *testing.T) {
+		fname := dname + "/empty"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		err = mount.SetFileLayoutPoolNamespace(fname, "OtherNS")
+		assert.NoError(t, err)
+		ns, err := mount.GetFileLayoutPoolNamespace(fname)
+		assert.NoError(t, err)
+		assert.Equal(t, "OtherNS", ns)
+	})
+
+	err = mount.SetDirLayoutPoolNamespace(dname, "")
+	assert.NoError(t, err)
+	ns, err = mount.GetDirLayoutPoolNamespace(dname)
+	assert.NoError(t, err)
+	assert.Equal(t, "", ns)
+}
+
+func TestMkdirWithLayout(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	layoutField := func(path, field string) string {
+		v, err := mount.GetXattr(path, "ceph.file.layout."+field)
+		require.NoError(t, err, field)
+		return string(v)
+	}
+
+	t.Run("inherited", func(t *testing.T) {
+		dname := "/TestMkdirWithLayout"
+		layout := &FileLayout{
+			StripeUnit:    1 << 20,
+			StripeCount:   2,
+			ObjectSize:    1 << 22,
+			PoolNamespace: "TestMkdirNS",
+		}
+		err := mount.MkdirWithLayout(dname, 0755, layout)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+		fname := dname + "/file"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		assert.Equal(t, "1048576", layoutField(fname, "stripe_unit"))
+		assert.Equal(t, "2", layoutField(fname, "stripe_count"))
+		assert.Equal(t, "4194304", layoutField(fname, "object_size"))
+		assert.Equal(t, "TestMkdirNS", layoutField(fname, "pool_namespace"))
+	})
+
+	t.Run("invalidLayout", func(t *testing.T) {
+		dname := "/TestMkdirWithLayoutInvalid"
+		// the object size must be a multiple of the stripe unit
+		layout := &FileLayout{StripeUnit: 1 << 20, ObjectSize: 3 << 19}
+		err := mount.MkdirWithLayout(dname, 0755, layout)
+		assert.Error(t, err)
+		_, err = mount.Statx(dname, StatxBasicStats, 0)
+		assert.Equal(t, errNoEntry, err)
+	})
+
+	t.Run("nilLayout", func(t *testing.T) {
+		dname := "/TestMkdirWithLayoutNil"
+		err := mount.MkdirWithLayout(dname, 0755, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, mount.RemoveDir(dname))
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		err := mount.MkdirWithLayout("/", 0755, &FileLayout{StripeCount: 1})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseFileLayout(t *testing.T) {
+	l, err := parseFileLayout("stripe_unit=1048576 stripe_count=2 " +
+		"object_size=4194304 pool=cephfs_data pool_namespace=ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, &FileLayout{
+		StripeUnit:    1 << 20,
+		StripeCount:   2,
+		ObjectSize:    1 << 22,
+		Pool:          "cephfs_data",
+		PoolNamespace: "ns1",
+	}, l)
+
+	_, err = parseFileLayout("stripe_unit")
+	assert.Error(t, err)
+	_, err = parseFileLayout("stripe_unit=big")
+	assert.Error(t, err)
+}
+
+func TestCopyLayout(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestCopyLayout"
+	layout := &FileLayout{
+		StripeUnit:  1 << 20,
+		StripeCount: 2,
+		ObjectSize:  1 << 22,
+	}
+	err := mount.MkdirWithLayout(dname, 0755, layout)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	create := func(path string, data []byte) {
+		f, err := mount.Open(path, os.O_RDWR|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		if len(data) > 0 {
+			_, err = f.Write(data)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, f.Close())
+	}
+	src := dname + "/src"
+	create(src, nil)
+	defer func() { assert.NoError(t, mount.Unlink(src)) }()
+	dst := "/TestCopyLayout.dst"
+	create(dst, nil)
+	defer func() { assert.NoError(t, mount.Unlink(dst)) }()
+
+	srcLayout, err := mount.GetFileLayout(src)
+	require.NoError(t, err)
+	dstLayout, err := mount.GetFileLayout(dst)
+	require.NoError(t, err)
+	assert.NotEqual(t, srcLayout, dstLayout)
+
+	err = mount.CopyLayout(src, dst)
+	assert.NoError(t, err)
+	dstLayout, err = mount.GetFileLayout(dst)
+	require.NoError(t, err)
+	assert.Equal(t, srcLayout, dstLayout)
+	assert.EqualValues(t, 1<<20, dstLayout.StripeUnit)
+	assert.EqualValues(t, 2, dstLayout.StripeCount)
+	assert.EqualValues(t, 1<<22, dstLayout.ObjectSize)
+	assert.NotEmpty(t, dstLayout.Pool)
+
+	t.Run("clearNamespace", func(t *testing.T) {
+		nsDst := "/TestCopyLayout.ns"
+		create(nsDst, nil)
+		defer func() { assert.NoError(t, mount.Unlink(nsDst)) }()
+		require.NoError(t, mount.SetFileLayoutPoolNamespace(nsDst, "ns1"))
+
+		require.Empty(t, srcLayout.PoolNamespace)
+		err := mount.CopyLayout(src, nsDst)
+		assert.NoError(t, err)
+		ns, err := mount.GetFileLayoutPoolNamespace(nsDst)
+		assert.NoError(t, err)
+		assert.Equal(t, "", ns)
+	})
+
+	t.Run("notEmpty", func(t *testing.T) {
+		full := "/TestCopyLayout.full"
+		create(full, []byte("data"))
+		defer func() { assert.NoError(t, mount.Unlink(full)) }()
+		err := mount.CopyLayout(src, full)
+		assert.Error(t, err)
+	})
+
+	t.Run("missingSource", func(t *testing.T) {
+		err := mount.CopyLayout(dname+"/missing", dst)
+		assert.Equal(t, errNoEntry, err)
+	})
+}
@@ -0,0 +1,158 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// adminSocketTimeout limits how long a command sent to the admin socket of
+// the client may take.
+const adminSocketTimeout = 10 * time.Second
+
+// ErrNoAdminSocket is returned by GetClientMetrics when the client was
+// mounted without an admin socket.
+var ErrNoAdminSocket = errors.New("client admin socket is not configured")
+
+// ClientMetrics is a snapshot of the performance counters and cache
+// statistics of a mounted client.
+//
+// Capability hits and misses are not included. The client does not report
+// them through its admin socket, neither in the "status" nor in the
+// "perf dump" output. It only sends them to the MDS along with its other
+// metrics, from where they reach the stats module of the manager.
+type ClientMetrics struct {
+	// Reads and Writes are the numbers of file data read and write
+	// operations.
+	Reads  uint64
+	Writes uint64
+	// ReadLatency and WriteLatency are the average latencies of the file
+	// data read and write operations.
+	ReadLatency  time.Duration
+	WriteLatency time.Duration
+	// MetadataRequests is the number of requests sent to the MDS and
+	// MetadataLatency their average latency.
+	MetadataRequests uint64
+	MetadataLatency  time.Duration
+	// Dentries and Inodes are the numbers of dentries and inodes in the
+	// cache of the client. PinnedDentries are dentries that can not be
+	// trimmed from the cache.
+	Dentries       uint64
+	PinnedDentries uint64
+	Inodes         uint64
+}
+
+// timeAvgCounter is a latency counter as reported by "perf dump".
+type timeAvgCounter struct {
+	AvgCount uint64  `json:"avgcount"`
+	AvgTime  float64 `json:"avgtime"`
+}
+
+func (c timeAvgCounter) average() time.Duration {
+	return time.Duration(c.AvgTime * float64(time.Second))
+}
+
+type clientPerfDump struct {
+	Client struct {
+		Lat   timeAvgCounter `json:"lat"`
+		RdLat timeAvgCounter `json:"rdlat"`
+		WrLat timeAvgCounter `json:"wrlat"`
+	} `json:"client"`
+}
+
+type clientStatus struct {
+	DentryCount       uint64 `json:"dentry_count"`
+	DentryPinnedCount uint64 `json:"dentry_pinned_count"`
+	InodeCount        uint64 `json:"inode_count"`
+}
+
+// parseClientMetrics combines the output of the "perf dump" and "status"
+// admin socket commands of a client.
+func parseClientMetrics(perfDump, status []byte) (ClientMetrics, error) {
+	var (
+		p clientPerfDump
+		s clientStatus
+		m ClientMetrics
+	)
+	if err := json.Unmarshal(perfDump, &p); err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(status, &s); err != nil {
+		return m, err
+	}
+	m.Reads = p.Client.RdLat.AvgCount
+	m.ReadLatency = p.Client.RdLat.average()
+	m.Writes = p.Client.WrLat.AvgCount
+	m.WriteLatency = p.Client.WrLat.average()
+	m.MetadataRequests = p.Client.Lat.AvgCount
+	m.MetadataLatency = p.Client.Lat.average()
+	m.Dentries = s.DentryCount
+	m.PinnedDentries = s.DentryPinnedCount
+	m.Inodes = s.InodeCount
+	return m, nil
+}
+
+// adminSocketCommand sends a command to the admin socket at path and
+// returns the response.
+func adminSocketCommand(path, prefix string) ([]byte, error) {
+	cmd, err := json.Marshal(map[string]string{"prefix": prefix})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", path, adminSocketTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(adminSocketTimeout)); err != nil {
+		return nil, err
+	}
+	// the command is terminated by a NUL byte and the response is
+	// preceded by its length as a big endian 32-bit integer
+	if _, err := conn.Write(append(cmd, 0)); err != nil {
+		return nil, err
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// GetClientMetrics returns the current performance counters and cache
+// statistics of the client. libcephfs does not expose these directly, so
+// they are read from the admin socket of the client, which must have been
+// enabled with the admin_socket configuration option before mounting.
+// ErrNoAdminSocket is returned if it was not.
+//  PREVIEW
+func (mount *MountInfo) GetClientMetrics() (ClientMetrics, error) {
+	if err := mount.validate(); err != nil {
+		return ClientMetrics{}, err
+	}
+	path, err := mount.GetConfigOption("admin_socket")
+	if err != nil {
+		return ClientMetrics{}, err
+	}
+	if path == "" {
+		return ClientMetrics{}, ErrNoAdminSocket
+	}
+	perfDump, err := adminSocketCommand(path, "perf dump")
+	if err != nil {
+		return ClientMetrics{}, err
+	}
+	status, err := adminSocketCommand(path, "status")
+	if err != nil {
+		return ClientMetrics{}, err
+	}
+	return parseClientMetrics(perfDump, status)
+}
@@ -0,0 +1,103 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClientMetrics(t *testing.T) {
+	perfDump := []byte(`{
+		"client": {
+			"reply": {"avgcount": 7, "sum": 0.7, "avgtime": 0.1},
+			"lat": {"avgcount": 8, "sum": 1.6, "avgtime": 0.2},
+			"wrlat": {"avgcount": 3, "sum": 0.003, "avgtime": 0.001},
+			"rdlat": {"avgcount": 2, "sum": 0.004, "avgtime": 0.002}
+		},
+		"objecter": {"op_active": 0}
+	}`)
+	status := []byte(`{
+		"dentry_count": 12,
+		"dentry_pinned_count": 4,
+		"id": 4242,
+		"inode_count": 13
+	}`)
+	m, err := parseClientMetrics(perfDump, status)
+	assert.NoError(t, err)
+	assert.Equal(t, ClientMetrics{
+		Reads:            2,
+		Writes:           3,
+		ReadLatency:      2 * time.Millisecond,
+		WriteLatency:     time.Millisecond,
+		MetadataRequests: 8,
+		MetadataLatency:  200 * time.Millisecond,
+		Dentries:         12,
+		PinnedDentries:   4,
+		Inodes:           13,
+	}, m)
+
+	_, err = parseClientMetrics([]byte("{"), status)
+	assert.Error(t, err)
+	_, err = parseClientMetrics(perfDump, []byte("nope"))
+	assert.Error(t, err)
+}
+
+// fsConnectWithAdminSocket mounts the file system with the client admin
+// socket enabled at the given path.
+func fsConnectWithAdminSocket(t *testing.T, asok string) *MountInfo {
+	mount, err := CreateMount()
+	require.NoError(t, err)
+	require.NoError(t, mount.ReadDefaultConfigFile())
+	require.NoError(t, mount.SetConfigOption("admin_socket", asok))
+	require.NoError(t, mount.Mount())
+	return mount
+}
+
+func TestGetClientMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-ceph-metrics")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mount := fsConnectWithAdminSocket(t, filepath.Join(dir, "client.asok"))
+	defer fsDisconnect(t, mount)
+
+	fname := "TestGetClientMetrics.txt"
+	f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+	_, err = f.Write([]byte("some data to count"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Fsync(SyncAll))
+	buf := make([]byte, 32)
+	_, err = f.ReadAt(buf, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	m, err := mount.GetClientMetrics()
+	require.NoError(t, err)
+	assert.NotZero(t, m.Reads)
+	assert.NotZero(t, m.Writes)
+	assert.NotZero(t, m.MetadataRequests)
+	assert.NotZero(t, m.Dentries)
+	assert.NotZero(t, m.Inodes)
+}
+
+func TestGetClientMetricsNoAdminSocket(t *testing.T) {
+	mount := fsConnectWithAdminSocket(t, "")
+	defer fsDisconnect(t, mount)
+
+	_, err := mount.GetClientMetrics()
+	assert.Equal(t, ErrNoAdminSocket, err)
+
+	m := &MountInfo{}
+	_, err = m.GetClientMetrics()
+	assert.Equal(t, ErrNotConnected, err)
+}
@@ -0,0 +1,166 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <errno.h>
+#include <stdlib.h>
+#include <sys/stat.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"strings"
+	"unsafe"
+)
+
+const (
+	modeIFMT  = uint16(C.S_IFMT)
+	modeIFDIR = uint16(C.S_IFDIR)
+	modeIFLNK = uint16(C.S_IFLNK)
+	modeIFIFO = uint16(C.S_IFIFO)
+)
+
+// MakeDirs creates a directory along with any missing parent directories,
+// similar to os.MkdirAll. Components of the path that already exist as
+// directories are skipped. If the full path already exists as a directory
+// nil is returned.
+//  PREVIEW
+func (mount *MountInfo) MakeDirs(path string, mode uint32) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	if path == "" {
+		return errNoEntry
+	}
+
+	prefix := ""
+	if strings.HasPrefix(path, "/") {
+		prefix = "/"
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		prefix += part
+		if err := mount.makeDirIfMissing(prefix, mode); err != nil {
+			return err
+		}
+		prefix += "/"
+	}
+	return nil
+}
+
+func (mount *MountInfo) makeDirIfMissing(path string, mode uint32) error {
+	err := mount.MakeDir(path, mode)
+	if err != errExist {
+		return err
+	}
+	st, err := mount.Statx(path, StatxMode, 0)
+	if err != nil {
+		return err
+	}
+	if st.Mode&modeIFMT != modeIFDIR {
+		return errNotDir
+	}
+	return nil
+}
+
+// RemoveAll removes the given path and any children it contains, similar to
+// os.RemoveAll. Symbolic links are removed rather than followed. If the path
+// does not exist nil is returned.
+//  PREVIEW
+func (mount *MountInfo) RemoveAll(path string) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	if path == "" {
+		return nil
+	}
+
+	st, err := mount.Statx(path, StatxMode, AtSymlinkNofollow)
+	if err == errNoEntry {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if st.Mode&modeIFMT != modeIFDIR {
+		return ignoreNoEntry(mount.Unlink(path))
+	}
+
+	names, err := mount.dirEntryNames(path)
+	if err != nil {
+		return ignoreNoEntry(err)
+	}
+	for _, name := range names {
+		if err := mount.RemoveAll(path + "/" + name); err != nil {
+			return err
+		}
+	}
+	return ignoreNoEntry(mount.RemoveDir(path))
+}
+
+// dirEntryNames returns the names of all entries in the directory at path,
+// excluding "." and "..".
+func (mount *MountInfo) dirEntryNames(path string) ([]string, error) {
+	dir, err := mount.OpenDir(path)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	names := []string{}
+	for {
+		entry, err := dir.ReadDir()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return names, nil
+		}
+		if name := entry.Name(); name != "." && name != ".." {
+			names = append(names, name)
+		}
+	}
+}
+
+func ignoreNoEntry(err error) error {
+	if err == errNoEntry {
+		return nil
+	}
+	return err
+}
+
+// GetInode returns the inode number of the file or directory at the given
+// path. Symbolic links are not followed.
+//  PREVIEW
+func (mount *MountInfo) GetInode(path string) (uint64, error) {
+	st, err := mount.Statx(path, StatxIno, AtSymlinkNofollow)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(st.Inode), nil
+}
+
+// Mknod creates a special file, such as a FIFO (named pipe), at the given
+// path. The mode contains both the file type, for example syscall.S_IFIFO,
+// and the permission bits. The rdev value is only used for device files.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_mknod(struct ceph_mount_info *cmount, const char *path,
+//                 mode_t mode, dev_t rdev);
+func (mount *MountInfo) Mknod(path string, mode uint32, rdev uint64) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	ret := C.ceph_mknod(mount.mount, cPath, C.mode_t(mode), C.dev_t(rdev))
+	return getError(ret)
+}
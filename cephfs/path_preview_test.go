@@ -0,0 +1,236 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeDirs(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dirs := []string{"/a", "/a/b", "/a/b/c", "/a/b/c/d"}
+	defer func() {
+		for i := len(dirs) - 1; i >= 0; i-- {
+			assert.NoError(t, mount.RemoveDir(dirs[i]))
+		}
+	}()
+
+	t.Run("create", func(t *testing.T) {
+		err := mount.MakeDirs("/a/b/c/d", 0755)
+		require.NoError(t, err)
+
+		for _, d := range dirs {
+			st, err := mount.Statx(d, StatxBasicStats, 0)
+			if assert.NoError(t, err, d) {
+				assert.Equal(t, modeIFDIR, st.Mode&modeIFMT, d)
+				assert.Equal(t, uint16(0755), st.Mode&0777, d)
+			}
+		}
+	})
+
+	t.Run("alreadyExists", func(t *testing.T) {
+		err := mount.MakeDirs("/a/b/c/d", 0755)
+		assert.NoError(t, err)
+		err = mount.MakeDirs("/a/b", 0755)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fileInPath", func(t *testing.T) {
+		fname := "/a/b/file1"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+		err = mount.MakeDirs(fname, 0755)
+		assert.Equal(t, errNotDir, err)
+		err = mount.MakeDirs(fname+"/x", 0755)
+		assert.Error(t, err)
+	})
+
+	t.Run("emptyPath", func(t *testing.T) {
+		err := mount.MakeDirs("", 0755)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		err := m.MakeDirs("/a", 0755)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
+
+func TestRemoveAll(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	t.Run("populatedTree", func(t *testing.T) {
+		require.NoError(t, mount.MakeDirs("/rmall/x/y", 0755))
+		require.NoError(t, mount.MakeDir("/rmall/z", 0755))
+		for _, fname := range []string{"/rmall/f1", "/rmall/x/f2", "/rmall/x/y/f3"} {
+			f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("hello"))
+			assert.NoError(t, err)
+			assert.NoError(t, f.Close())
+		}
+
+		err := mount.RemoveAll("/rmall")
+		assert.NoError(t, err)
+		_, err = mount.Statx("/rmall", StatxBasicStats, 0)
+		assert.Equal(t, errNoEntry, err)
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		// the link target must survive removal of a tree containing a
+		// link pointing at it
+		require.NoError(t, mount.MakeDirs("/rmall.target/keep", 0755))
+		defer func() { assert.NoError(t, mount.RemoveAll("/rmall.target")) }()
+		require.NoError(t, mount.MakeDir("/rmall.links", 0755))
+		require.NoError(t, mount.Symlink("/rmall.target", "/rmall.links/l1"))
+
+		err := mount.RemoveAll("/rmall.links")
+		assert.NoError(t, err)
+		_, err = mount.Statx("/rmall.links", StatxBasicStats, 0)
+		assert.Equal(t, errNoEntry, err)
+		_, err = mount.Statx("/rmall.target/keep", StatxBasicStats, 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("singleFile", func(t *testing.T) {
+		fname := "/rmall.file"
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+
+		err = mount.RemoveAll(fname)
+		assert.NoError(t, err)
+		_, err = mount.Statx(fname, StatxBasicStats, 0)
+		assert.Equal(t, errNoEntry, err)
+	})
+
+	t.Run("missingPath", func(t *testing.T) {
+		err := mount.RemoveAll("/rmall.no.such.path")
+		assert.NoError(t, err)
+		err = mount.RemoveAll("/rmall.no.such.path")
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		err := m.RemoveAll("/rmall")
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
+
+func TestGetInode(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname1 := "/inode.file1"
+	fname2 := "/inode.file2"
+	lname := "/inode.link1"
+	for _, fname := range []string{fname1, fname2} {
+		f, err := mount.Open(fname, os.O_RDWR|os.O_CREATE, 0666)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+	}
+	require.NoError(t, mount.Link(fname1, lname))
+	defer func() {
+		for _, fname := range []string{fname1, fname2, lname} {
+			assert.NoError(t, mount.Unlink(fname))
+		}
+	}()
+
+	ino1, err := mount.GetInode(fname1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uint64(0), ino1)
+	ino2, err := mount.GetInode(fname2)
+	assert.NoError(t, err)
+	inoLink, err := mount.GetInode(lname)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ino1, inoLink)
+	assert.NotEqual(t, ino1, ino2)
+
+	t.Run("file", func(t *testing.T) {
+		f, err := mount.Open(lname, os.O_RDONLY, 0)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, f.Close()) }()
+		ino, err := f.GetInode()
+		assert.NoError(t, err)
+		assert.Equal(t, ino1, ino)
+	})
+
+	t.Run("noSuchFile", func(t *testing.T) {
+		_, err := mount.GetInode("/inode.no.such.file")
+		assert.Equal(t, errNoEntry, err)
+	})
+
+	t.Run("invalidFile", func(t *testing.T) {
+		f := &File{}
+		_, err := f.GetInode()
+		assert.Error(t, err)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		_, err := m.GetInode(fname1)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
+
+func TestMknodFIFO(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	fname := "/TestMknodFIFO"
+	err := mount.Mknod(fname, syscall.S_IFIFO|0644, 0)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount.Unlink(fname)) }()
+
+	st, err := mount.Statx(fname, StatxMode, 0)
+	require.NoError(t, err)
+	assert.Equal(t, modeIFIFO, st.Mode&modeIFMT)
+	assert.EqualValues(t, 0644, st.Mode&0777)
+
+	err = mount.Mknod(fname, syscall.S_IFIFO|0644, 0)
+	assert.Equal(t, errExist, err)
+
+	t.Run("nonBlockingRead", func(t *testing.T) {
+		errAgain := cephFSError(-int(syscall.EAGAIN))
+		done := make(chan error, 1)
+		go func() {
+			f, err := mount.Open(fname, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+			if err != nil {
+				done <- err
+				return
+			}
+			defer f.Close()
+			buf := make([]byte, 16)
+			_, err = f.Read(buf)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			assert.Equal(t, errAgain, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("read of an empty FIFO blocked")
+		}
+	})
+
+	t.Run("notConnected", func(t *testing.T) {
+		m := &MountInfo{}
+		err := m.Mknod(fname, syscall.S_IFIFO|0644, 0)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
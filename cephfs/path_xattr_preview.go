@@ -0,0 +1,75 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"sort"
+)
+
+// setXattrsStep sets a single xattr on behalf of SetXattrs. It is a variable
+// so that tests can inject failures.
+var setXattrsStep = func(mount *MountInfo, path, name string, value []byte) error {
+	return mount.SetXattr(path, name, value, XattrDefault)
+}
+
+// xattrState records the value an xattr had before SetXattrs changed it.
+type xattrState struct {
+	name   string
+	value  []byte
+	exists bool
+}
+
+// SetXattrs sets all of the given extended attributes on the file or
+// directory at path. The xattrs are set one at a time, in the order of their
+// names. If setting one of them fails the xattrs already set are restored to
+// their previous values, or removed if they did not exist before, and the
+// error is returned.
+//
+// libcephfs has no call to set several xattrs at once, so the update is not
+// atomic: other clients may observe a partial update and a crash partway
+// leaves a partial update behind. Restoring the previous values is also done
+// on a best effort basis.
+//  PREVIEW
+func (mount *MountInfo) SetXattrs(path string, attrs map[string][]byte) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	done := make([]xattrState, 0, len(names))
+	for _, name := range names {
+		prev := xattrState{name: name}
+		value, err := mount.GetXattr(path, name)
+		switch err {
+		case nil:
+			prev.value, prev.exists = value, true
+		case errNoData:
+		default:
+			return mount.rollbackXattrs(path, done, err)
+		}
+		if err := setXattrsStep(mount, path, name, attrs[name]); err != nil {
+			return mount.rollbackXattrs(path, done, err)
+		}
+		done = append(done, prev)
+	}
+	return nil
+}
+
+// rollbackXattrs restores the xattrs in done to their previous state, most
+// recently set first, and returns err.
+func (mount *MountInfo) rollbackXattrs(path string, done []xattrState, err error) error {
+	for i := len(done) - 1; i >= 0; i-- {
+		prev := done[i]
+		if prev.exists {
+			_ = mount.SetXattr(path, prev.name, prev.value, XattrDefault)
+		} else {
+			_ = mount.RemoveXattr(path, prev.name)
+		}
+	}
+	return err
+}
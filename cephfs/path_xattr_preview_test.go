@@ -0,0 +1,110 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetXattrs(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+	fname := "TestSetXattrs.txt"
+
+	f1, err := mount.Open(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	require.NoError(t, err)
+	assert.NoError(t, f1.Close())
+	defer func() {
+		assert.NoError(t, mount.Unlink(fname))
+	}()
+
+	t.Run("setAll", func(t *testing.T) {
+		attrs := map[string][]byte{
+			"user.alpha": []byte("one"),
+			"user.beta":  []byte("two"),
+			"user.gamma": []byte("three"),
+		}
+		err := mount.SetXattrs(fname, attrs)
+		assert.NoError(t, err)
+		for name, value := range attrs {
+			b, err := mount.GetXattr(fname, name)
+			assert.NoError(t, err)
+			assert.Equal(t, value, b)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		origStep := setXattrsStep
+		defer func() { setXattrsStep = origStep }()
+		errFail := errors.New("injected failure")
+		calls := 0
+		setXattrsStep = func(mount *MountInfo, path, name string, value []byte) error {
+			calls++
+			if calls == 2 {
+				return errFail
+			}
+			return origStep(mount, path, name, value)
+		}
+
+		// user.alpha exists and is restored, user.aaa is new and removed
+		err := mount.SetXattrs(fname, map[string][]byte{
+			"user.aaa":   []byte("new"),
+			"user.alpha": []byte("changed"),
+			"user.zeta":  []byte("never set"),
+		})
+		assert.Equal(t, errFail, err)
+		assert.Equal(t, 2, calls)
+
+		_, err = mount.GetXattr(fname, "user.aaa")
+		assert.Equal(t, errNoData, err)
+		b, err := mount.GetXattr(fname, "user.alpha")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("one"), b)
+		_, err = mount.GetXattr(fname, "user.zeta")
+		assert.Equal(t, errNoData, err)
+	})
+
+	t.Run("rollbackRestoresReplaced", func(t *testing.T) {
+		origStep := setXattrsStep
+		defer func() { setXattrsStep = origStep }()
+		errFail := errors.New("injected failure")
+		setXattrsStep = func(mount *MountInfo, path, name string, value []byte) error {
+			if name == "user.gamma" {
+				return errFail
+			}
+			return origStep(mount, path, name, value)
+		}
+
+		err := mount.SetXattrs(fname, map[string][]byte{
+			"user.alpha": []byte("changed"),
+			"user.beta":  []byte("changed"),
+			"user.gamma": []byte("changed"),
+		})
+		assert.Equal(t, errFail, err)
+		for name, value := range map[string]string{
+			"user.alpha": "one",
+			"user.beta":  "two",
+			"user.gamma": "three",
+		} {
+			b, err := mount.GetXattr(fname, name)
+			assert.NoError(t, err)
+			assert.Equal(t, value, string(b))
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.NoError(t, mount.SetXattrs(fname, nil))
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		err := m.SetXattrs(fname, map[string][]byte{"user.x": []byte("x")})
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
@@ -0,0 +1,75 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+// walkTree calls fn for path and, if path is a directory, for everything
+// below it. Symbolic links are followed unless flags contains
+// AtSymlinkNofollow. Directories that were already visited, for example
+// through a symbolic link, are not descended into again.
+func (mount *MountInfo) walkTree(
+	path string, flags AtFlags, visited map[Inode]bool,
+	fn func(path string, st *CephStatx) error) error {
+
+	st, err := mount.Statx(path, StatxMode|StatxIno, flags)
+	if err != nil {
+		return err
+	}
+	if err := fn(path, st); err != nil {
+		return err
+	}
+	if st.Mode&modeIFMT != modeIFDIR || visited[st.Inode] {
+		return nil
+	}
+	visited[st.Inode] = true
+
+	names, err := mount.dirEntryNames(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		err := mount.walkTree(path+"/"+name, flags, visited, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChownAll changes the ownership of the given path and of everything below
+// it, similar to "chown -R". If flags contains AtSymlinkNofollow the
+// ownership of symbolic links themselves is changed and they are not
+// followed, otherwise the ownership of their targets is changed and
+// symbolic links to directories are descended into.
+//  PREVIEW
+func (mount *MountInfo) ChownAll(path string, uid, gid uint32, flags AtFlags) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	nofollow := flags&AtSymlinkNofollow != 0
+	return mount.walkTree(path, flags, map[Inode]bool{},
+		func(p string, st *CephStatx) error {
+			if nofollow {
+				return mount.Lchown(p, uid, gid)
+			}
+			return mount.Chown(p, uid, gid)
+		})
+}
+
+// ChmodAll changes the mode bits of the given path and of everything below
+// it, similar to "chmod -R". If flags contains AtSymlinkNofollow symbolic
+// links are skipped, otherwise the mode of their targets is changed and
+// symbolic links to directories are descended into.
+//  PREVIEW
+func (mount *MountInfo) ChmodAll(path string, mode uint32, flags AtFlags) error {
+	if err := mount.validate(); err != nil {
+		return err
+	}
+	return mount.walkTree(path, flags, map[Inode]bool{},
+		func(p string, st *CephStatx) error {
+			if st.Mode&modeIFMT == modeIFLNK {
+				return nil
+			}
+			return mount.Chmod(p, mode)
+		})
+}
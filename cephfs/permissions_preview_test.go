@@ -0,0 +1,102 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChownChmodAll(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	root := "/TestChownChmodAll"
+	outside := "/TestChownChmodAll.target"
+	require.NoError(t, mount.MakeDirs(root+"/d1/d2", 0755))
+	defer func() { assert.NoError(t, mount.RemoveAll(root)) }()
+	files := []string{outside, root + "/f1", root + "/d1/f2", root + "/d1/d2/f3"}
+	for _, name := range files {
+		f, err := mount.Open(name, os.O_WRONLY|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	defer func() { assert.NoError(t, mount.Unlink(outside)) }()
+	link := root + "/link"
+	require.NoError(t, mount.Symlink(outside, link))
+	// a loop back to the top of the tree
+	require.NoError(t, mount.Symlink(root, root+"/d1/up"))
+
+	nodes := []string{root, root + "/d1", root + "/d1/d2",
+		root + "/f1", root + "/d1/f2", root + "/d1/d2/f3"}
+
+	owner := func(path string, flags AtFlags) (uint32, uint32) {
+		st, err := mount.Statx(path, StatxBasicStats, flags)
+		require.NoError(t, err, path)
+		return st.Uid, st.Gid
+	}
+
+	outsideUID, _ := owner(outside, 0)
+
+	t.Run("chownNoFollow", func(t *testing.T) {
+		err := mount.ChownAll(root, 1234, 5678, AtSymlinkNofollow)
+		require.NoError(t, err)
+		for _, n := range append(nodes, link, root+"/d1/up") {
+			uid, gid := owner(n, AtSymlinkNofollow)
+			assert.Equal(t, uint32(1234), uid, n)
+			assert.Equal(t, uint32(5678), gid, n)
+		}
+		uid, _ := owner(outside, 0)
+		assert.Equal(t, outsideUID, uid)
+	})
+
+	t.Run("chownFollow", func(t *testing.T) {
+		err := mount.ChownAll(root, 4321, 8765, 0)
+		require.NoError(t, err)
+		for _, n := range append(nodes, outside) {
+			uid, gid := owner(n, 0)
+			assert.Equal(t, uint32(4321), uid, n)
+			assert.Equal(t, uint32(8765), gid, n)
+		}
+		// the links themselves keep the previous owner
+		uid, _ := owner(link, AtSymlinkNofollow)
+		assert.Equal(t, uint32(1234), uid)
+	})
+
+	t.Run("chmod", func(t *testing.T) {
+		require.NoError(t, mount.Chmod(outside, 0644))
+		err := mount.ChmodAll(root, 0750, AtSymlinkNofollow)
+		require.NoError(t, err)
+		for _, n := range nodes {
+			st, err := mount.Statx(n, StatxMode, 0)
+			require.NoError(t, err, n)
+			assert.Equal(t, uint16(0750), st.Mode&0777, n)
+		}
+		st, err := mount.Statx(outside, StatxMode, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint16(0644), st.Mode&0777)
+
+		err = mount.ChmodAll(root, 0700, 0)
+		require.NoError(t, err)
+		st, err = mount.Statx(outside, StatxMode, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint16(0700), st.Mode&0777)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		err := mount.ChownAll(root+"/nope", 0, 0, 0)
+		assert.Equal(t, errNoEntry, err)
+		err = mount.ChmodAll(root+"/nope", 0755, 0)
+		assert.Equal(t, errNoEntry, err)
+	})
+
+	t.Run("notConnected", func(t *testing.T) {
+		m := &MountInfo{}
+		assert.Equal(t, ErrNotConnected, m.ChownAll(root, 0, 0, 0))
+		assert.Equal(t, ErrNotConnected, m.ChmodAll(root, 0755, 0))
+	})
+}
@@ -0,0 +1,77 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// getUint64Xattr returns the numeric value of the named vxattr of path. An
+// unset vxattr has the value zero.
+func (mount *MountInfo) getUint64Xattr(path, name string) (uint64, error) {
+	value, err := mount.GetXattr(path, name)
+	if err == errNoData {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(value))
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// GetQuotaUsage returns the bytes and files used below the directory at the
+// given path, along with the byte and file limits of the quota of the
+// directory. A limit of zero means there is no limit. The usage is based on
+// the recursive statistics of the directory, which the MDS updates lazily,
+// and so it may briefly lag behind recent changes.
+//  PREVIEW
+func (mount *MountInfo) GetQuotaUsage(path string) (
+	usedBytes, maxBytes, usedFiles, maxFiles uint64, err error) {
+
+	values := []struct {
+		name  string
+		value *uint64
+	}{
+		{"ceph.dir.rbytes", &usedBytes},
+		{"ceph.quota.max_bytes", &maxBytes},
+		{"ceph.dir.rfiles", &usedFiles},
+		{"ceph.quota.max_files", &maxFiles},
+	}
+	for _, v := range values {
+		if *v.value, err = mount.getUint64Xattr(path, v.name); err != nil {
+			return 0, 0, 0, 0, err
+		}
+	}
+	return usedBytes, maxBytes, usedFiles, maxFiles, nil
+}
+
+// SetQuota sets the byte and file limits of the quota of the directory the
+// File was opened for, which must have been opened with O_DIRECTORY. A limit
+// of zero removes that limit.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_fsetxattr(struct ceph_mount_info *cmount, int fd, const char *name,
+//                     const void *value, size_t size, int flags);
+func (f *File) SetQuota(maxBytes, maxFiles uint64) error {
+	values := []struct {
+		name  string
+		value uint64
+	}{
+		{"ceph.quota.max_bytes", maxBytes},
+		{"ceph.quota.max_files", maxFiles},
+	}
+	for _, v := range values {
+		value := []byte(strconv.FormatUint(v.value, 10))
+		if err := f.SetXattr(v.name, value, XattrDefault); err != nil {
+			return err
+		}
+	}
+	return nil
+}
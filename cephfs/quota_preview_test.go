@@ -0,0 +1,102 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQuotaUsage(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestGetQuotaUsage"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveAll(dname)) }()
+
+	usedBytes, maxBytes, usedFiles, maxFiles, err := mount.GetQuotaUsage(dname)
+	assert.NoError(t, err)
+	assert.Zero(t, usedBytes)
+	assert.Zero(t, maxBytes)
+	assert.Zero(t, usedFiles)
+	assert.Zero(t, maxFiles)
+
+	err = mount.SetXattr(dname, "ceph.quota.max_bytes", []byte("104857600"), XattrDefault)
+	require.NoError(t, err)
+	err = mount.SetXattr(dname, "ceph.quota.max_files", []byte("100"), XattrDefault)
+	require.NoError(t, err)
+
+	const (
+		numFiles = 4
+		fileSize = 4096
+	)
+	data := make([]byte, fileSize)
+	for i := 0; i < numFiles; i++ {
+		f, err := mount.Open(fmt.Sprintf("%s/file%d", dname, i),
+			os.O_WRONLY|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, f.Sync())
+		assert.NoError(t, f.Close())
+	}
+
+	// recursive statistics are propagated lazily
+	for i := 0; i < 30; i++ {
+		usedBytes, maxBytes, usedFiles, maxFiles, err = mount.GetQuotaUsage(dname)
+		require.NoError(t, err)
+		if usedBytes == numFiles*fileSize && usedFiles == numFiles {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	assert.EqualValues(t, numFiles*fileSize, usedBytes)
+	assert.EqualValues(t, numFiles, usedFiles)
+	assert.EqualValues(t, 104857600, maxBytes)
+	assert.EqualValues(t, 100, maxFiles)
+
+	_, _, _, _, err = mount.GetQuotaUsage("/TestGetQuotaUsage.missing")
+	assert.Error(t, err)
+}
+
+func TestFileSetQuota(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestFileSetQuota"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	dir, err := mount.Open(dname, os.O_RDONLY|syscall.O_DIRECTORY, 0)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, dir.Close()) }()
+
+	err = dir.SetQuota(1<<30, 1000)
+	assert.NoError(t, err)
+	_, maxBytes, _, maxFiles, err := mount.GetQuotaUsage(dname)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1<<30, maxBytes)
+	assert.EqualValues(t, 1000, maxFiles)
+
+	// zero removes the limits
+	err = dir.SetQuota(0, 0)
+	assert.NoError(t, err)
+	_, maxBytes, _, maxFiles, err = mount.GetQuotaUsage(dname)
+	assert.NoError(t, err)
+	assert.Zero(t, maxBytes)
+	assert.Zero(t, maxFiles)
+
+	t.Run("invalidFile", func(t *testing.T) {
+		f := &File{}
+		err := f.SetQuota(1, 1)
+		assert.Error(t, err)
+	})
+}
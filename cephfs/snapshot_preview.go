@@ -0,0 +1,67 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	snapDirName    = ".snap"
+	snapBtimeXattr = "ceph.snap.btime"
+)
+
+// SnapInfo describes a snapshot of a directory.
+type SnapInfo struct {
+	// Name is the name of the snapshot within the .snap directory.
+	Name string
+	// Created is the time the snapshot was taken.
+	Created time.Time
+}
+
+// snapCreated returns the creation time of the snapshot directory at path.
+// Ceph versions lacking the ceph.snap.btime vxattr fall back to the birth
+// time of the snapshot directory.
+func (mount *MountInfo) snapCreated(path string) (time.Time, error) {
+	value, err := mount.GetXattr(path, snapBtimeXattr)
+	if err == nil {
+		return parseRctime(string(value))
+	}
+	if err != errNoData {
+		return time.Time{}, err
+	}
+	st, err := mount.Statx(path, StatxBtime, AtSymlinkNofollow)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(st.Btime.Sec), int64(st.Btime.Nsec)), nil
+}
+
+// ListSnapshotsWithInfo returns the snapshots of the directory at dirPath
+// along with their creation times, ordered from oldest to newest.
+//  PREVIEW
+func (mount *MountInfo) ListSnapshotsWithInfo(dirPath string) ([]SnapInfo, error) {
+	if err := mount.validate(); err != nil {
+		return nil, err
+	}
+	snapDir := dirPath + "/" + snapDirName
+	names, err := mount.dirEntryNames(snapDir)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := make([]SnapInfo, 0, len(names))
+	for _, name := range names {
+		created, err := mount.snapCreated(snapDir + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, SnapInfo{Name: name, Created: created})
+	}
+	sort.SliceStable(snaps, func(i, j int) bool {
+		return snaps[i].Created.Before(snaps[j].Created)
+	})
+	return snaps, nil
+}
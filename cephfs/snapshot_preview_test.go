@@ -0,0 +1,61 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSnapshotsWithInfo(t *testing.T) {
+	mount := fsConnect(t)
+	defer fsDisconnect(t, mount)
+
+	dname := "/TestListSnapshotsWithInfo"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+
+	t.Run("noSnapshots", func(t *testing.T) {
+		snaps, err := mount.ListSnapshotsWithInfo(dname)
+		assert.NoError(t, err)
+		assert.Len(t, snaps, 0)
+	})
+
+	t.Run("snapshots", func(t *testing.T) {
+		// allow for some clock skew between the client and the MDS
+		before := time.Now().Add(-time.Minute)
+		for _, name := range []string{"snap1", "snap2"} {
+			snapPath := dname + "/.snap/" + name
+			require.NoError(t, mount.MakeDir(snapPath, 0755))
+			defer func() { assert.NoError(t, mount.RemoveDir(snapPath)) }()
+		}
+		after := time.Now().Add(time.Minute)
+
+		snaps, err := mount.ListSnapshotsWithInfo(dname)
+		assert.NoError(t, err)
+		if assert.Len(t, snaps, 2) {
+			assert.Equal(t, "snap1", snaps[0].Name)
+			assert.Equal(t, "snap2", snaps[1].Name)
+			for _, s := range snaps {
+				assert.True(t, s.Created.After(before), s.Created)
+				assert.True(t, s.Created.Before(after), s.Created)
+			}
+			assert.False(t, snaps[1].Created.Before(snaps[0].Created))
+		}
+	})
+
+	t.Run("missingDir", func(t *testing.T) {
+		_, err := mount.ListSnapshotsWithInfo("/no/such/dir")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		_, err := m.ListSnapshotsWithInfo(dname)
+		assert.Error(t, err)
+	})
+}
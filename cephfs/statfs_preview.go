@@ -0,0 +1,101 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+/*
+#cgo LDFLAGS: -lcephfs
+#cgo CPPFLAGS: -D_FILE_OFFSET_BITS=64
+#include <stdlib.h>
+#include <cephfs/libcephfs.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/retry"
+	"github.com/ceph/go-ceph/rados"
+)
+
+var errPoolNotFound = errors.New("data pool not found in cluster usage statistics")
+
+// GetPathPoolName returns the name of the data pool that holds the data of
+// the file at the given path, as chosen by its layout.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_get_path_pool_name(struct ceph_mount_info *cmount, const char *path, char *buf, size_t buflen);
+func (mount *MountInfo) GetPathPoolName(path string) (string, error) {
+	if err := mount.validate(); err != nil {
+		return "", err
+	}
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	var (
+		buf []byte
+		ret C.int
+		err error
+	)
+	retry.WithSizes(64, 4096, func(size int) retry.Hint {
+		buf = make([]byte, size)
+		ret = C.ceph_get_path_pool_name(
+			mount.mount, cPath, (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(size))
+		err = getErrorIfNegative(ret)
+		return retry.DoubleSize.If(err == errRange)
+	})
+	if err != nil {
+		return "", err
+	}
+	return C.GoStringN((*C.char)(unsafe.Pointer(&buf[0])), ret), nil
+}
+
+// StatFSForPath returns file system statistics like StatFS, but with the
+// block counts describing the capacity of the data pool the path's layout
+// places its data in rather than that of the whole cluster. The total is
+// the data stored in the pool plus the space still available to it, taking
+// any pool quota into account.
+//
+// libcephfs does not give access to its connection to the cluster, so the
+// pool statistics are read using conn, typically the connection the mount
+// was created from with CreateFromRados.
+//  PREVIEW
+func (mount *MountInfo) StatFSForPath(conn *rados.Conn, path string) (*CephStatVFS, error) {
+	stat, err := mount.StatFS(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := mount.GetPathPoolName(path)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := poolUsage(conn, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	frsize := uint64(stat.Frsize)
+	if frsize == 0 {
+		frsize = 1
+	}
+	stat.Blocks = (usage.Stored + usage.MaxAvail) / frsize
+	stat.Bfree = usage.MaxAvail / frsize
+	stat.Bavail = stat.Bfree
+	return stat, nil
+}
+
+// poolUsage returns the usage statistics of the named pool.
+func poolUsage(conn *rados.Conn, pool string) (*rados.DFPoolUsage, error) {
+	df, err := conn.GetDF()
+	if err != nil {
+		return nil, err
+	}
+	for i := range df.Pools {
+		if df.Pools[i].Name == pool {
+			return &df.Pools[i].Stats, nil
+		}
+	}
+	return nil, errPoolNotFound
+}
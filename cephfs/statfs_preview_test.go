@@ -0,0 +1,112 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+func monCommand(t *testing.T, conn *rados.Conn, cmd map[string]string) []byte {
+	buf, err := json.Marshal(cmd)
+	require.NoError(t, err)
+	out, _, err := conn.MonCommand(buf)
+	require.NoError(t, err)
+	return out
+}
+
+// addFSDataPool adds the pool as a data pool of the first file system and
+// returns a function that removes it again.
+func addFSDataPool(t *testing.T, conn *rados.Conn, pool string) func() {
+	var filesystems []struct {
+		Name string `json:"name"`
+	}
+	out := monCommand(t, conn, map[string]string{
+		"prefix": "fs ls",
+		"format": "json",
+	})
+	require.NoError(t, json.Unmarshal(out, &filesystems))
+	require.NotEmpty(t, filesystems)
+	fsName := filesystems[0].Name
+	monCommand(t, conn, map[string]string{
+		"prefix":  "fs add_data_pool",
+		"fs_name": fsName,
+		"pool":    pool,
+	})
+	return func() {
+		monCommand(t, conn, map[string]string{
+			"prefix":  "fs rm_data_pool",
+			"fs_name": fsName,
+			"pool":    pool,
+		})
+	}
+}
+
+func TestStatFSForPath(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	// a small data pool, limited by a quota
+	const quota = 100 << 20
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(t, conn.MakePool(pool))
+	defer conn.DeletePool(pool)
+	monCommand(t, conn, map[string]string{
+		"prefix": "osd pool set-quota",
+		"pool":   pool,
+		"field":  "max_bytes",
+		"val":    "104857600",
+	})
+
+	defer addFSDataPool(t, conn, pool)()
+
+	mount, err := CreateFromRados(conn)
+	require.NoError(t, err)
+	require.NoError(t, mount.Mount())
+	defer fsDisconnect(t, mount)
+
+	dname := "/statfsforpath"
+	require.NoError(t, mount.MakeDir(dname, 0755))
+	defer func() { assert.NoError(t, mount.RemoveDir(dname)) }()
+	err = mount.SetXattr(dname, "ceph.dir.layout.pool", []byte(pool), XattrDefault)
+	require.NoError(t, err)
+
+	t.Run("poolName", func(t *testing.T) {
+		name, err := mount.GetPathPoolName(dname)
+		assert.NoError(t, err)
+		assert.Equal(t, pool, name)
+	})
+
+	t.Run("smallPool", func(t *testing.T) {
+		whole, err := mount.StatFS("/")
+		require.NoError(t, err)
+		stat, err := mount.StatFSForPath(conn, dname)
+		require.NoError(t, err)
+
+		total := stat.Blocks * uint64(stat.Frsize)
+		assert.True(t, total <= quota, "total %d exceeds quota", total)
+		assert.True(t, stat.Blocks < whole.Blocks)
+		assert.True(t, stat.Bfree <= stat.Blocks)
+		assert.Equal(t, stat.Bfree, stat.Bavail)
+	})
+
+	t.Run("missingPath", func(t *testing.T) {
+		_, err := mount.StatFSForPath(conn, "/no/such/path")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalidMount", func(t *testing.T) {
+		m := &MountInfo{}
+		_, err := m.StatFSForPath(conn, dname)
+		assert.Equal(t, ErrNotConnected, err)
+		_, err = m.GetPathPoolName(dname)
+		assert.Equal(t, ErrNotConnected, err)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+// StatxSync returns all known information about a file or directory, making
+// sure the values are current. Unlike a Statx call with AtNoAttrSync no
+// locally cached values are used when another client may have changed the
+// file, at the cost of a round trip to the MDS. Symbolic links are
+// followed.
+//  PREVIEW
+//
+// Implements:
+//  int ceph_statx(struct ceph_mount_info *cmount, const char *path, struct ceph_statx *stx,
+//                 unsigned int want, unsigned int flags);
+func (mount *MountInfo) StatxSync(path string) (*CephStatx, error) {
+	return mount.Statx(path, StatxAllStats, 0)
+}
@@ -0,0 +1,44 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package cephfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatxSync(t *testing.T) {
+	mount1 := fsConnect(t)
+	defer fsDisconnect(t, mount1)
+	mount2 := fsConnect(t)
+	defer fsDisconnect(t, mount2)
+
+	fname := "TestStatxSync.txt"
+	f1, err := mount1.Open(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, mount1.Unlink(fname)) }()
+	defer func() { assert.NoError(t, f1.Close()) }()
+
+	st, err := mount1.StatxSync(fname)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, st.Size)
+	assert.Equal(t, StatxAllStats, st.Mask&StatxAllStats)
+
+	// write from the other client, keeping the file open there
+	f2, err := mount2.Open(fname, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, f2.Close()) }()
+	_, err = f2.WriteAt([]byte("written by another mount"), 0)
+	require.NoError(t, err)
+
+	st, err = mount1.StatxSync(fname)
+	require.NoError(t, err)
+	assert.EqualValues(t, 24, st.Size)
+
+	_, err = mount1.StatxSync("TestStatxSync.none")
+	assert.Equal(t, errNoEntry, err)
+}
@@ -0,0 +1,274 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"errors"
+	"io"
+	"unsafe"
+)
+
+const (
+	// writeFromReaderInflight is the number of writes WriteFromReader keeps
+	// in flight at the same time.
+	writeFromReaderInflight = 8
+	// readToWriterInflight is the number of reads ReadToWriter keeps in
+	// flight at the same time.
+	readToWriterInflight = 8
+)
+
+var errInvalidChunkSize = errors.New("chunk size must be positive")
+
+// AppendAsync starts appending data to the object with key oid and returns
+// a Completion that can be used to wait for the result. The data is copied
+// before AppendAsync returns and so the caller may reuse the slice right
+// away. Each append is applied atomically, even when many appends to the
+// same object are in flight concurrently.
+//  PREVIEW
+//
+// Implements:
+//  int rados_aio_append(rados_ioctx_t io, const char *oid,
+//                       rados_completion_t completion,
+//                       const char *buf, size_t len);
+func (ioctx *IOContext) AppendAsync(oid string, data []byte) (*Completion, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyArgument
+	}
+
+	c, err := newCompletion(ioctx, C.CBytes(data))
+	if err != nil {
+		return nil, err
+	}
+
+	cOid := C.CString(oid)
+	defer C.free(unsafe.Pointer(cOid))
+
+	ret := C.rados_aio_append(
+		ioctx.ioctx,
+		cOid,
+		c.completion,
+		(*C.char)(c.buf),
+		C.size_t(len(data)))
+	if ret < 0 {
+		c.abort()
+		return nil, getError(ret)
+	}
+	return c, nil
+}
+
+// WriteAsync starts writing data to the object with key oid at the given
+// offset and returns a Completion that can be used to wait for the result.
+// The data is copied before WriteAsync returns and so the caller may reuse
+// the slice right away.
+//  PREVIEW
+//
+// Implements:
+//  int rados_aio_write(rados_ioctx_t io, const char *oid,
+//                      rados_completion_t completion,
+//                      const char *buf, size_t len, uint64_t off);
+func (ioctx *IOContext) WriteAsync(oid string, data []byte, offset uint64) (*Completion, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+
+	c, err := newCompletion(ioctx, C.CBytes(data))
+	if err != nil {
+		return nil, err
+	}
+
+	cOid := C.CString(oid)
+	defer C.free(unsafe.Pointer(cOid))
+
+	ret := C.rados_aio_write(
+		ioctx.ioctx,
+		cOid,
+		c.completion,
+		(*C.char)(c.buf),
+		C.size_t(len(data)),
+		C.uint64_t(offset))
+	if ret < 0 {
+		c.abort()
+		return nil, getError(ret)
+	}
+	return c, nil
+}
+
+// WriteFromReader streams the data read from r into the object with key
+// oid, starting at offset zero. The data is read in chunks of chunkSize
+// bytes and every chunk is written asynchronously at increasing offsets,
+// with a few writes in flight at once, so that the whole payload is never
+// buffered in memory. WriteFromReader returns once all writes completed.
+// Data of an existing object past the end of the streamed data is left in
+// place.
+//  PREVIEW
+func (ioctx *IOContext) WriteFromReader(oid string, r io.Reader, chunkSize int) error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	if chunkSize <= 0 {
+		return errInvalidChunkSize
+	}
+
+	inflight := make([]*Completion, 0, writeFromReaderInflight)
+	var writeErr error
+	wait := func(c *Completion) {
+		if err := c.Wait(); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+
+	buf := make([]byte, chunkSize)
+	var offset uint64
+	var readErr error
+	for writeErr == nil {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if len(inflight) == cap(inflight) {
+				wait(inflight[0])
+				inflight = append(inflight[:0], inflight[1:]...)
+			}
+			// the data is copied, so buf can be reused for the next chunk
+			c, err := ioctx.WriteAsync(oid, buf[:n], offset)
+			if err != nil {
+				writeErr = err
+				break
+			}
+			inflight = append(inflight, c)
+			offset += uint64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			readErr = err
+			break
+		}
+	}
+	for _, c := range inflight {
+		wait(c)
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return writeErr
+}
+
+// readAsync starts reading up to length bytes of the object with key oid
+// at the given offset. Once the returned Completion was waited on without
+// error the slice pointed to by data holds the bytes read.
+//
+// Implements:
+//  int rados_aio_read(rados_ioctx_t io, const char *oid,
+//                     rados_completion_t completion,
+//                     char *buf, size_t len, uint64_t off);
+func (ioctx *IOContext) readAsync(oid string, length int, offset uint64) (*Completion, *[]byte, error) {
+	c, err := newCompletion(ioctx, C.malloc(C.size_t(length)))
+	if err != nil {
+		return nil, nil, err
+	}
+	data := new([]byte)
+	c.onComplete = func(ret C.int) error {
+		if ret < 0 {
+			return getError(ret)
+		}
+		// copy the data before the C buffer is freed
+		*data = C.GoBytes(c.buf, ret)
+		return nil
+	}
+
+	cOid := C.CString(oid)
+	defer C.free(unsafe.Pointer(cOid))
+
+	ret := C.rados_aio_read(
+		ioctx.ioctx,
+		cOid,
+		c.completion,
+		(*C.char)(c.buf),
+		C.size_t(length),
+		C.uint64_t(offset))
+	if ret < 0 {
+		c.abort()
+		return nil, nil, getError(ret)
+	}
+	return c, data, nil
+}
+
+// ReadToWriter streams the data of the object with key oid, from offset
+// zero up to the end of the object, to w and returns the number of bytes
+// written. The object is read in chunks of chunkSize bytes with a few reads
+// at increasing offsets in flight at once. The chunks are written to w in
+// order, whatever order the reads complete in, so that the whole object is
+// never buffered in memory.
+//  PREVIEW
+func (ioctx *IOContext) ReadToWriter(oid string, w io.Writer, chunkSize int) (int64, error) {
+	if err := ioctx.validate(); err != nil {
+		return 0, err
+	}
+	if chunkSize <= 0 {
+		return 0, errInvalidChunkSize
+	}
+
+	type pendingRead struct {
+		c    *Completion
+		data *[]byte
+	}
+	inflight := make([]pendingRead, 0, readToWriterInflight)
+	var (
+		written int64
+		offset  uint64
+		err     error
+		eof     bool
+	)
+	// finish waits for the oldest read and writes its data, noticing the
+	// end of the object at the first short read
+	finish := func() {
+		p := inflight[0]
+		inflight = append(inflight[:0], inflight[1:]...)
+		rerr := p.c.Wait()
+		if err != nil || eof {
+			return
+		}
+		if rerr != nil {
+			err = rerr
+			return
+		}
+		if len(*p.data) > 0 {
+			n, werr := w.Write(*p.data)
+			written += int64(n)
+			if werr != nil {
+				err = werr
+				return
+			}
+		}
+		if len(*p.data) < chunkSize {
+			eof = true
+		}
+	}
+
+	for err == nil && !eof {
+		if len(inflight) == cap(inflight) {
+			finish()
+			continue
+		}
+		c, data, rerr := ioctx.readAsync(oid, chunkSize, offset)
+		if rerr != nil {
+			err = rerr
+			break
+		}
+		inflight = append(inflight, pendingRead{c, data})
+		offset += uint64(chunkSize)
+	}
+	for len(inflight) > 0 {
+		finish()
+	}
+	return written, err
+}
@@ -0,0 +1,276 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appendRecord returns a self-describing payload that can be located within
+// an object to check that appends were not interleaved.
+func appendRecord(i int) []byte {
+	body := bytes.Repeat([]byte{byte('a' + i%26)}, 10+i%50)
+	return []byte(fmt.Sprintf("<%04d:%s>", i, body))
+}
+
+func (suite *RadosTestSuite) TestAppendAsync() {
+	suite.SetupConnection()
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		ioctx := &IOContext{}
+		_, err := ioctx.AppendAsync("foo", []byte("bar"))
+		assert.Equal(t, ErrInvalidIOContext, err)
+	})
+
+	suite.T().Run("emptyData", func(t *testing.T) {
+		_, err := suite.ioctx.AppendAsync(suite.GenObjectName(), []byte{})
+		assert.Equal(t, ErrEmptyArgument, err)
+	})
+
+	suite.T().Run("single", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		data := []byte("hello")
+		c, err := suite.ioctx.AppendAsync(oid, data)
+		require.NoError(t, err)
+		// the data was copied and may be modified right away
+		copy(data, "xxxxx")
+		assert.NoError(t, c.Wait())
+		assert.True(t, c.IsComplete())
+		// waiting again returns the same result
+		assert.NoError(t, c.Wait())
+
+		buf := make([]byte, 16)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(buf[:n]))
+	})
+
+	suite.T().Run("concurrent", func(t *testing.T) {
+		const (
+			workers   = 16
+			perWorker = 25
+		)
+		oid := suite.GenObjectName()
+
+		var (
+			wg    sync.WaitGroup
+			lock  sync.Mutex
+			total int
+		)
+		errs := make(chan error, workers*perWorker)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				comps := []*Completion{}
+				for i := 0; i < perWorker; i++ {
+					rec := appendRecord(w*perWorker + i)
+					c, err := suite.ioctx.AppendAsync(oid, rec)
+					if err != nil {
+						errs <- err
+						continue
+					}
+					lock.Lock()
+					total += len(rec)
+					lock.Unlock()
+					comps = append(comps, c)
+				}
+				for _, c := range comps {
+					errs <- c.Wait()
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		stat, err := suite.ioctx.Stat(oid)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(total), stat.Size)
+
+		// every record must appear exactly once and intact
+		data := make([]byte, total)
+		n, err := suite.ioctx.Read(oid, data, 0)
+		require.NoError(t, err)
+		require.Equal(t, total, n)
+		seen := map[int]bool{}
+		for len(data) > 0 {
+			require.True(t, len(data) > 6 && data[0] == '<' && data[5] == ':')
+			i, err := strconv.Atoi(string(data[1:5]))
+			require.NoError(t, err)
+			rec := appendRecord(i)
+			require.True(t, bytes.HasPrefix(data, rec), "record %d is damaged", i)
+			assert.False(t, seen[i], "record %d repeated", i)
+			seen[i] = true
+			data = data[len(rec):]
+		}
+		assert.Len(t, seen, workers*perWorker)
+	})
+}
+
+func (suite *RadosTestSuite) TestWriteAsync() {
+	suite.SetupConnection()
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		ioctx := &IOContext{}
+		_, err := ioctx.WriteAsync("foo", []byte("bar"), 0)
+		assert.Equal(t, ErrInvalidIOContext, err)
+	})
+
+	suite.T().Run("offset", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		c, err := suite.ioctx.WriteAsync(oid, []byte("hello world"), 0)
+		require.NoError(t, err)
+		assert.NoError(t, c.Wait())
+		c, err = suite.ioctx.WriteAsync(oid, []byte("WORLD"), 6)
+		require.NoError(t, err)
+		assert.NoError(t, c.Wait())
+
+		buf := make([]byte, 16)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello WORLD", string(buf[:n]))
+	})
+}
+
+type failingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, f.err
+	}
+	return n, err
+}
+
+func (suite *RadosTestSuite) TestWriteFromReader() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	suite.T().Run("multiMegabyte", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		defer suite.ioctx.Delete(oid)
+		// not a multiple of the chunk size
+		data := suite.RandomBytes(5*1024*1024 + 123)
+		err := suite.ioctx.WriteFromReader(oid, bytes.NewReader(data), 256*1024)
+		require.NoError(t, err)
+
+		stat, err := suite.ioctx.Stat(oid)
+		require.NoError(t, err)
+		require.EqualValues(t, len(data), stat.Size)
+		out := make([]byte, len(data))
+		n, err := suite.ioctx.Read(oid, out, 0)
+		require.NoError(t, err)
+		assert.Equal(t, len(data), n)
+		assert.True(t, bytes.Equal(data, out))
+	})
+
+	suite.T().Run("empty", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		err := suite.ioctx.WriteFromReader(oid, bytes.NewReader(nil), 1024)
+		assert.NoError(t, err)
+		_, err = suite.ioctx.Stat(oid)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	suite.T().Run("readError", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		defer suite.ioctx.Delete(oid)
+		errBroken := errors.New("broken source")
+		r := &failingReader{r: bytes.NewReader(suite.RandomBytes(4096)), err: errBroken}
+		err := suite.ioctx.WriteFromReader(oid, r, 1000)
+		assert.Equal(t, errBroken, err)
+	})
+
+	err := suite.ioctx.WriteFromReader("foo", bytes.NewReader(nil), 0)
+	ta.Equal(errInvalidChunkSize, err)
+	err = (&IOContext{}).WriteFromReader("foo", bytes.NewReader(nil), 1024)
+	ta.Equal(ErrInvalidIOContext, err)
+}
+
+type failingWriter struct {
+	limit int
+	err   error
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n := f.limit
+		f.limit = 0
+		return n, f.err
+	}
+	f.limit -= len(p)
+	return len(p), nil
+}
+
+func (suite *RadosTestSuite) TestReadToWriter() {
+	suite.SetupConnection()
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		ioctx := &IOContext{}
+		_, err := ioctx.ReadToWriter("foo", &bytes.Buffer{}, 1024)
+		assert.Equal(t, ErrInvalidIOContext, err)
+	})
+
+	suite.T().Run("invalidChunkSize", func(t *testing.T) {
+		_, err := suite.ioctx.ReadToWriter("foo", &bytes.Buffer{}, 0)
+		assert.Equal(t, errInvalidChunkSize, err)
+	})
+
+	suite.T().Run("missingObject", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		n, err := suite.ioctx.ReadToWriter(suite.GenObjectName(), buf, 1024)
+		assert.Equal(t, ErrNotFound, err)
+		assert.Zero(t, n)
+		assert.Zero(t, buf.Len())
+	})
+
+	// sizes that end within, exactly at the end of, and after the first
+	// window of reads
+	for _, size := range []int{100, 4096, 64 * 1024, 64*1024 + 17, 5<<20 + 3} {
+		suite.T().Run(fmt.Sprintf("size%d", size), func(t *testing.T) {
+			oid := suite.GenObjectName()
+			data := suite.RandomBytes(size)
+			require.NoError(t, suite.ioctx.WriteFromReader(oid, bytes.NewReader(data), 1<<20))
+
+			buf := &bytes.Buffer{}
+			n, err := suite.ioctx.ReadToWriter(oid, buf, 4096)
+			assert.NoError(t, err)
+			assert.EqualValues(t, size, n)
+			assert.True(t, bytes.Equal(data, buf.Bytes()))
+		})
+	}
+
+	suite.T().Run("emptyObject", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		require.NoError(t, suite.ioctx.Create(oid, CreateExclusive))
+		buf := &bytes.Buffer{}
+		n, err := suite.ioctx.ReadToWriter(oid, buf, 4096)
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	suite.T().Run("writeError", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		require.NoError(t, suite.ioctx.WriteFull(oid, suite.RandomBytes(64*1024)))
+		errFull := errors.New("writer full")
+		n, err := suite.ioctx.ReadToWriter(oid, &failingWriter{10000, errFull}, 4096)
+		assert.Equal(t, errFull, err)
+		assert.EqualValues(t, 10000, n)
+	})
+}
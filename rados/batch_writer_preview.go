@@ -0,0 +1,97 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"fmt"
+)
+
+// BatchWriteError is returned by BatchWriter.Flush for every write that
+// failed.
+type BatchWriteError struct {
+	OID string
+	Err error
+}
+
+// Error returns a string describing the failed write.
+//  PREVIEW
+func (e *BatchWriteError) Error() string {
+	return fmt.Sprintf("write of %q failed: %v", e.OID, e.Err)
+}
+
+// Unwrap returns the error of the failed write.
+//  PREVIEW
+func (e *BatchWriteError) Unwrap() error {
+	return e.Err
+}
+
+// BatchWriter writes many objects, keeping a bounded number of writes in
+// flight at once so that the round trip of one write overlaps with the
+// others. A BatchWriter is not safe for concurrent use by multiple
+// goroutines.
+type BatchWriter struct {
+	ioctx    *IOContext
+	inflight []batchWrite
+	limit    int
+	errs     []error
+}
+
+type batchWrite struct {
+	oid string
+	c   *Completion
+}
+
+// NewBatchWriter returns a BatchWriter that keeps up to concurrency writes
+// to objects of the IO context in flight. A concurrency less than one is
+// treated as one.
+//  PREVIEW
+func (ioctx *IOContext) NewBatchWriter(concurrency int) *BatchWriter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &BatchWriter{
+		ioctx:    ioctx,
+		inflight: make([]batchWrite, 0, concurrency),
+		limit:    concurrency,
+	}
+}
+
+// Write queues writing data to the start of the object with key oid. If the
+// maximum number of writes is already in flight, Write first waits for the
+// oldest of them to finish. The data is copied and so the caller may reuse
+// the slice right away. Failures are reported by Flush.
+//  PREVIEW
+func (bw *BatchWriter) Write(oid string, data []byte) {
+	if len(bw.inflight) == bw.limit {
+		bw.finish(bw.inflight[0])
+		bw.inflight = append(bw.inflight[:0], bw.inflight[1:]...)
+	}
+	c, err := bw.ioctx.WriteAsync(oid, data, 0)
+	if err != nil {
+		bw.errs = append(bw.errs, &BatchWriteError{OID: oid, Err: err})
+		return
+	}
+	bw.inflight = append(bw.inflight, batchWrite{oid: oid, c: c})
+}
+
+func (bw *BatchWriter) finish(w batchWrite) {
+	if err := w.c.Wait(); err != nil {
+		bw.errs = append(bw.errs, &BatchWriteError{OID: w.oid, Err: err})
+	}
+}
+
+// Flush waits for all writes in flight to finish and returns a
+// BatchWriteError for every write that failed since the previous call to
+// Flush. If every write succeeded nil is returned. The BatchWriter may be
+// used again after Flush returns.
+//  PREVIEW
+func (bw *BatchWriter) Flush() []error {
+	for _, w := range bw.inflight {
+		bw.finish(w)
+	}
+	bw.inflight = bw.inflight[:0]
+	errs := bw.errs
+	bw.errs = nil
+	return errs
+}
@@ -0,0 +1,129 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestBatchWriter() {
+	suite.SetupConnection()
+
+	suite.T().Run("write", func(t *testing.T) {
+		bw := suite.ioctx.NewBatchWriter(8)
+		oids := []string{}
+		for i := 0; i < 100; i++ {
+			oid := suite.GenObjectName()
+			bw.Write(oid, []byte(fmt.Sprintf("object %d", i)))
+			oids = append(oids, oid)
+		}
+		assert.Nil(t, bw.Flush())
+
+		buf := make([]byte, 32)
+		for i, oid := range oids {
+			n, err := suite.ioctx.Read(oid, buf, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("object %d", i), string(buf[:n]))
+		}
+	})
+
+	suite.T().Run("errors", func(t *testing.T) {
+		bw := suite.ioctx.NewBatchWriter(0)
+		// object names longer than the OSDs accept fail
+		bad1 := strings.Repeat("a", 4096)
+		bad2 := strings.Repeat("b", 4096)
+		bw.Write(suite.GenObjectName(), []byte("ok"))
+		bw.Write(bad1, []byte("fails"))
+		bw.Write(suite.GenObjectName(), []byte("ok"))
+		bw.Write(bad2, []byte("fails"))
+		errs := bw.Flush()
+		if assert.Len(t, errs, 2) {
+			oids := []string{}
+			for _, err := range errs {
+				var bwe *BatchWriteError
+				if assert.True(t, errors.As(err, &bwe)) {
+					assert.Error(t, bwe.Err)
+					oids = append(oids, bwe.OID)
+				}
+			}
+			assert.Equal(t, []string{bad1, bad2}, oids)
+		}
+
+		// errors are reset by Flush
+		bw.Write(suite.GenObjectName(), []byte("ok"))
+		assert.Nil(t, bw.Flush())
+	})
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		bw := (&IOContext{}).NewBatchWriter(4)
+		bw.Write("foo", []byte("bar"))
+		errs := bw.Flush()
+		if assert.Len(t, errs, 1) {
+			assert.True(t, errors.Is(errs[0], ErrInvalidIOContext))
+		}
+	})
+}
+
+// benchmarkSmallWrites writes 10000 small objects per iteration using the
+// given write function.
+func benchmarkSmallWrites(b *testing.B, write func(*IOContext, []string, []byte) error) {
+	const count = 10000
+	conn, err := NewConn()
+	require.NoError(b, err)
+	require.NoError(b, conn.ReadDefaultConfigFile())
+	require.NoError(b, conn.Connect())
+	defer conn.Shutdown()
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(b, conn.MakePool(pool))
+	defer conn.DeletePool(pool)
+
+	ioctx, err := conn.OpenIOContext(pool)
+	require.NoError(b, err)
+	defer ioctx.Destroy()
+
+	oids := make([]string, count)
+	for i := range oids {
+		oids[i] = fmt.Sprintf("small%05d", i)
+	}
+	data := []byte("small object data")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := write(ioctx, oids, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSmallWritesSerial(b *testing.B) {
+	benchmarkSmallWrites(b, func(ioctx *IOContext, oids []string, data []byte) error {
+		for _, oid := range oids {
+			if err := ioctx.Write(oid, data, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkSmallWritesBatch(b *testing.B) {
+	benchmarkSmallWrites(b, func(ioctx *IOContext, oids []string, data []byte) error {
+		bw := ioctx.NewBatchWriter(64)
+		for _, oid := range oids {
+			bw.Write(oid, data)
+		}
+		if errs := bw.Flush(); len(errs) > 0 {
+			return errs[0]
+		}
+		return nil
+	})
+}
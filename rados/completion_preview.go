@@ -0,0 +1,241 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Completion tracks the state of an asynchronous rados operation. Any data
+// needed by the operation is copied into memory owned by the Completion and
+// is kept alive until the operation finishes. Wait or Release must be called
+// on every Completion in order to release the resources it holds.
+type Completion struct {
+	// lock serializes Wait and Release and protects err.
+	lock sync.Mutex
+	// handle guards completion against being released while it is in use.
+	// It is only held for writing by release, so that IsComplete does not
+	// block while Wait is waiting for the operation.
+	handle     sync.RWMutex
+	completion C.rados_completion_t
+	// buf is C memory that librados reads from or writes into while the
+	// operation is in flight. It is freed once the operation completes.
+	buf unsafe.Pointer
+	// onComplete, if set, is called with the return value of the operation
+	// when it completes and before buf is freed.
+	onComplete func(ret C.int) error
+	// conn is the connection the operation was started on, if known.
+	conn *Conn
+	// done is set to 1 once the resources of the Completion were released.
+	done int32
+	err  error
+}
+
+// completionRegistry keeps track of the Completions that were not released
+// yet for every connection, so that ShutdownGraceful can wait for them.
+type completionRegistry struct {
+	lock        sync.Mutex
+	completions map[*Conn]map[*Completion]struct{}
+}
+
+var pendingCompletions = &completionRegistry{
+	completions: map[*Conn]map[*Completion]struct{}{},
+}
+
+func (r *completionRegistry) add(c *Completion) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	m := r.completions[c.conn]
+	if m == nil {
+		m = map[*Completion]struct{}{}
+		r.completions[c.conn] = m
+	}
+	m[c] = struct{}{}
+}
+
+func (r *completionRegistry) remove(c *Completion) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	m := r.completions[c.conn]
+	delete(m, c)
+	if len(m) == 0 {
+		delete(r.completions, c.conn)
+	}
+}
+
+// complete returns true if every Completion of the connection has finished.
+func (r *completionRegistry) complete(conn *Conn) bool {
+	r.lock.Lock()
+	pending := make([]*Completion, 0, len(r.completions[conn]))
+	for c := range r.completions[conn] {
+		pending = append(pending, c)
+	}
+	r.lock.Unlock()
+	for _, c := range pending {
+		if !c.IsComplete() {
+			return false
+		}
+	}
+	return true
+}
+
+// newCompletion returns a new Completion for an operation on the given IO
+// context that owns the C memory buf, which may be nil.
+//
+// Implements:
+//  int rados_aio_create_completion(void *cb_arg,
+//                                  rados_callback_t cb_complete,
+//                                  rados_callback_t cb_safe,
+//                                  rados_completion_t *pc);
+func newCompletion(ioctx *IOContext, buf unsafe.Pointer) (*Completion, error) {
+	c := &Completion{buf: buf, conn: ioctx.conn}
+	ret := C.rados_aio_create_completion(nil, nil, nil, &c.completion)
+	if ret < 0 {
+		C.free(buf)
+		return nil, getError(ret)
+	}
+	if c.conn != nil {
+		pendingCompletions.add(c)
+	}
+	return c, nil
+}
+
+// release frees the resources held by the Completion.
+// NOTE: c.lock must be held and the operation must no longer be in flight.
+//
+// Implements:
+//  void rados_aio_release(rados_completion_t c);
+func (c *Completion) release() {
+	c.handle.Lock()
+	defer c.handle.Unlock()
+	C.rados_aio_release(c.completion)
+	c.completion = nil
+	C.free(c.buf)
+	c.buf = nil
+	atomic.StoreInt32(&c.done, 1)
+	if c.conn != nil {
+		pendingCompletions.remove(c)
+	}
+}
+
+// abort releases a Completion whose operation could not be started.
+func (c *Completion) abort() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.release()
+}
+
+// waitForComplete blocks until the operation has finished.
+// NOTE: c.lock must be held and the Completion must not be released.
+//
+// Implements:
+//  int rados_aio_wait_for_complete(rados_completion_t c);
+func (c *Completion) waitForComplete() {
+	c.handle.RLock()
+	defer c.handle.RUnlock()
+	C.rados_aio_wait_for_complete(c.completion)
+}
+
+// IsComplete returns true if the asynchronous operation has finished. It
+// does not block, even while Wait is waiting on the Completion in another
+// goroutine.
+//  PREVIEW
+//
+// Implements:
+//  int rados_aio_is_complete(rados_completion_t c);
+func (c *Completion) IsComplete() bool {
+	if atomic.LoadInt32(&c.done) != 0 {
+		return true
+	}
+	c.handle.RLock()
+	defer c.handle.RUnlock()
+	if c.completion == nil {
+		return true
+	}
+	return C.rados_aio_is_complete(c.completion) != 0
+}
+
+// Wait blocks until the asynchronous operation has finished and returns the
+// result of the operation. Once Wait returns the resources held by the
+// Completion have been released. Wait may be called more than once and from
+// multiple goroutines; every call returns the same result.
+//  PREVIEW
+//
+// Implements:
+//  int rados_aio_wait_for_complete(rados_completion_t c);
+//  int rados_aio_get_return_value(rados_completion_t c);
+func (c *Completion) Wait() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if atomic.LoadInt32(&c.done) != 0 {
+		return c.err
+	}
+
+	c.waitForComplete()
+	ret := C.rados_aio_get_return_value(c.completion)
+	if c.onComplete != nil {
+		c.err = c.onComplete(ret)
+	} else {
+		c.err = getErrorIfNegative(ret)
+	}
+	c.release()
+	return c.err
+}
+
+// Release frees the resources held by the Completion without reporting the
+// result of the operation, for callers that are not interested in it. As
+// librados may still access the memory of an operation in flight, Release
+// blocks until the operation has finished. Calling Release after Wait, or
+// more than once, does nothing. Once released, Wait returns nil.
+//  PREVIEW
+func (c *Completion) Release() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if atomic.LoadInt32(&c.done) != 0 {
+		return
+	}
+	c.waitForComplete()
+	c.release()
+}
+
+// CompletionGroup collects Completions so that the results of many
+// asynchronous operations can be waited on at once. The zero value is an
+// empty group ready to use.
+type CompletionGroup struct {
+	lock        sync.Mutex
+	completions []*Completion
+}
+
+// Add a Completion to the group.
+//  PREVIEW
+func (g *CompletionGroup) Add(c *Completion) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.completions = append(g.completions, c)
+}
+
+// Wait blocks until every Completion added to the group has finished and
+// returns the result of each operation, in the order the Completions were
+// added. After Wait returns the group is empty and may be reused.
+//  PREVIEW
+func (g *CompletionGroup) Wait() []error {
+	g.lock.Lock()
+	completions := g.completions
+	g.completions = nil
+	g.lock.Unlock()
+
+	errs := make([]error, len(completions))
+	for i, c := range completions {
+		errs[i] = c.Wait()
+	}
+	return errs
+}
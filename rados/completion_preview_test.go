@@ -0,0 +1,111 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestCompletionGroup() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	g := &CompletionGroup{}
+	ta.Len(g.Wait(), 0)
+
+	const count = 100
+	oids := make([]string, count)
+	for i := range oids {
+		oids[i] = suite.GenObjectName()
+		c, err := suite.ioctx.AppendAsync(oids[i], []byte(fmt.Sprintf("data-%d", i)))
+		require.NoError(suite.T(), err)
+		g.Add(c)
+	}
+	errs := g.Wait()
+	ta.Len(errs, count)
+	for _, err := range errs {
+		ta.NoError(err)
+	}
+	for i, oid := range oids {
+		buf := make([]byte, 16)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		ta.NoError(err)
+		ta.Equal(fmt.Sprintf("data-%d", i), string(buf[:n]))
+	}
+
+	// the group is empty after waiting and can be reused
+	ta.Len(g.Wait(), 0)
+	c, err := suite.ioctx.AppendAsync(oids[0], []byte("more"))
+	require.NoError(suite.T(), err)
+	g.Add(c)
+	errs = g.Wait()
+	if ta.Len(errs, 1) {
+		ta.NoError(errs[0])
+	}
+}
+
+func (suite *RadosTestSuite) TestCompletionIsCompleteDoesNotBlock() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	c, err := suite.ioctx.WriteAsync(suite.GenObjectName(), make([]byte, 1<<20), 0)
+	require.NoError(suite.T(), err)
+
+	// hold the lock a Wait call holds while it is blocked on the operation
+	c.lock.Lock()
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		deadline := time.Now().Add(10 * time.Second)
+		for !c.IsComplete() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	select {
+	case <-polled:
+	case <-time.After(15 * time.Second):
+		suite.T().Error("IsComplete blocked on a concurrent Wait")
+	}
+	c.lock.Unlock()
+
+	ta.True(c.IsComplete())
+	ta.NoError(c.Wait())
+	ta.True(c.IsComplete())
+}
+
+func (suite *RadosTestSuite) TestCompletionRelease() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	c, err := suite.ioctx.WriteAsync(oid, []byte("released"), 0)
+	require.NoError(suite.T(), err)
+	// release the completion without waiting for the result first
+	c.Release()
+	ta.True(c.IsComplete())
+	ta.NoError(c.Wait())
+	c.Release()
+
+	buf := make([]byte, 16)
+	n, err := suite.ioctx.Read(oid, buf, 0)
+	ta.NoError(err)
+	ta.Equal("released", string(buf[:n]))
+
+	// releasing after a failed operation does not report the error
+	sc, _, err := suite.ioctx.readAsync(suite.GenObjectName(), 16, 0)
+	require.NoError(suite.T(), err)
+	sc.Release()
+	ta.NoError(sc.Wait())
+
+	// releasing after Wait does nothing
+	c, err = suite.ioctx.WriteAsync(oid, []byte("again"), 0)
+	require.NoError(suite.T(), err)
+	ta.NoError(c.Wait())
+	c.Release()
+	ta.NoError(c.Wait())
+}
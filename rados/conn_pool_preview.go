@@ -0,0 +1,116 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrConnPoolClosed is returned when a connection is requested from a
+	// ConnPool that has been shut down.
+	ErrConnPoolClosed = errors.New("connection pool is closed")
+
+	errInvalidPoolSize = errors.New("pool size must be positive")
+)
+
+// ConnPool manages a fixed number of connections to the cluster. Servers
+// issuing requests from many goroutines can acquire a connection for the
+// duration of a request instead of sharing one Conn, and the IOContexts
+// opened from them, between all goroutines.
+//  PREVIEW
+type ConnPool struct {
+	mutex    sync.Mutex
+	free     chan *Conn
+	acquired map[*Conn]bool
+	closed   bool
+}
+
+// NewConnPool returns a ConnPool holding size connections. Each connection
+// is created by calling newConn, which must return a connected Conn. If any
+// call to newConn fails the connections created so far are shut down and
+// the error is returned.
+//  PREVIEW
+func NewConnPool(size int, newConn func() (*Conn, error)) (*ConnPool, error) {
+	if size <= 0 {
+		return nil, errInvalidPoolSize
+	}
+	p := &ConnPool{
+		free:     make(chan *Conn, size),
+		acquired: make(map[*Conn]bool, size),
+	}
+	for i := 0; i < size; i++ {
+		c, err := newConn()
+		if err == nil {
+			err = c.ensureConnected()
+		}
+		if err != nil {
+			if c != nil {
+				c.Shutdown()
+			}
+			p.Shutdown()
+			return nil, err
+		}
+		p.free <- c
+	}
+	return p, nil
+}
+
+// Acquire returns a connection from the pool for the exclusive use of the
+// caller, waiting for one to be released if all of them are in use. The
+// connection must be handed back with Release. ErrConnPoolClosed is
+// returned if the pool is shut down.
+//  PREVIEW
+func (p *ConnPool) Acquire() (*Conn, error) {
+	c, ok := <-p.free
+	if !ok {
+		return nil, ErrConnPoolClosed
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		c.Shutdown()
+		return nil, ErrConnPoolClosed
+	}
+	p.acquired[c] = true
+	return c, nil
+}
+
+// Release returns a connection obtained from Acquire to the pool so that
+// it can be reused. Any IOContexts opened from the connection should be
+// destroyed first. Connections that are not currently acquired from the
+// pool are ignored.
+//  PREVIEW
+func (p *ConnPool) Release(c *Conn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.acquired[c] {
+		return
+	}
+	delete(p.acquired, c)
+	if p.closed {
+		c.Shutdown()
+		return
+	}
+	p.free <- c
+}
+
+// Shutdown shuts down all idle connections of the pool. Connections that
+// are still acquired are shut down once they are released. Pending and
+// future calls to Acquire return ErrConnPoolClosed.
+//  PREVIEW
+func (p *ConnPool) Shutdown() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.free)
+	for c := range p.free {
+		c.Shutdown()
+	}
+}
@@ -0,0 +1,211 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestConn returns a connection using the default configuration.
+func newTestConn() (*Conn, error) {
+	conn, err := NewConn()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.ReadDefaultConfigFile(); err != nil {
+		return nil, err
+	}
+	if err := conn.Connect(); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (suite *RadosTestSuite) TestConnPoolInvalid() {
+	ta := assert.New(suite.T())
+
+	_, err := NewConnPool(0, newTestConn)
+	ta.Equal(errInvalidPoolSize, err)
+
+	errFail := errors.New("connect failed")
+	calls := 0
+	_, err = NewConnPool(3, func() (*Conn, error) {
+		calls++
+		if calls == 2 {
+			return nil, errFail
+		}
+		return newTestConn()
+	})
+	ta.Equal(errFail, err)
+	ta.Equal(2, calls)
+
+	_, err = NewConnPool(1, NewConn)
+	ta.Equal(ErrNotConnected, err)
+}
+
+func (suite *RadosTestSuite) TestConnPoolAcquire() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	const size = 4
+	p, err := NewConnPool(size, newTestConn)
+	require.NoError(suite.T(), err)
+	defer p.Shutdown()
+
+	// concurrent acquirers get their own connections and the IOContexts
+	// opened from them do not share namespace settings
+	conns := make([]*Conn, size)
+	var wg sync.WaitGroup
+	for i := 0; i < size; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := p.Acquire()
+			if !ta.NoError(err) {
+				return
+			}
+			conns[i] = c
+			ioctx, err := c.OpenIOContext(suite.pool)
+			if !ta.NoError(err) {
+				return
+			}
+			defer ioctx.Destroy()
+			ioctx.SetNamespace(fmt.Sprintf("connpool%d", i))
+			ta.NoError(ioctx.WriteFull("obj", []byte(fmt.Sprintf("value%d", i))))
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[*Conn]bool{}
+	for i, c := range conns {
+		if ta.NotNil(c) {
+			ta.False(seen[c], "connection handed out twice")
+			seen[c] = true
+		}
+		suite.ioctx.SetNamespace(fmt.Sprintf("connpool%d", i))
+		data := make([]byte, 16)
+		n, err := suite.ioctx.Read("obj", data, 0)
+		ta.NoError(err)
+		ta.Equal(fmt.Sprintf("value%d", i), string(data[:n]))
+		ta.NoError(suite.ioctx.Delete("obj"))
+	}
+	suite.ioctx.SetNamespace("")
+
+	// all connections are in use, so the next acquirer waits
+	acquired := make(chan *Conn)
+	go func() {
+		c, err := p.Acquire()
+		ta.NoError(err)
+		acquired <- c
+	}()
+	select {
+	case <-acquired:
+		suite.T().Fatal("acquired a connection from an exhausted pool")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// releasing a connection hands it to the waiting acquirer
+	p.Release(conns[0])
+	select {
+	case c := <-acquired:
+		ta.Equal(conns[0], c)
+		conns[0] = c
+	case <-time.After(5 * time.Second):
+		suite.T().Fatal("released connection was not reused")
+	}
+
+	for _, c := range conns {
+		p.Release(c)
+	}
+	// releasing a connection twice is ignored
+	p.Release(conns[0])
+}
+
+func (suite *RadosTestSuite) TestConnPoolShutdown() {
+	ta := assert.New(suite.T())
+
+	p, err := NewConnPool(2, newTestConn)
+	require.NoError(suite.T(), err)
+
+	c, err := p.Acquire()
+	require.NoError(suite.T(), err)
+
+	p.Shutdown()
+	_, err = p.Acquire()
+	ta.Equal(ErrConnPoolClosed, err)
+
+	// the acquired connection stays usable until it is released
+	_, err = c.GetFSID()
+	ta.NoError(err)
+	p.Release(c)
+	_, err = c.GetFSID()
+	ta.Equal(ErrNotConnected, err)
+
+	// shutting down again is harmless
+	p.Shutdown()
+}
+
+// benchmarkWrites writes a small object from parallel goroutines, each
+// write using an IOContext opened from the connection returned by get.
+func benchmarkWrites(b *testing.B, get func() (*Conn, func(), error)) {
+	conn, err := newTestConn()
+	require.NoError(b, err)
+	defer conn.Shutdown()
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(b, conn.MakePool(pool))
+	defer conn.DeletePool(pool)
+
+	data := []byte("connection pool benchmark")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		oid := uuid.Must(uuid.NewV4()).String()
+		for pb.Next() {
+			c, done, err := get()
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			ioctx, err := c.OpenIOContext(pool)
+			if err == nil {
+				err = ioctx.WriteFull(oid, data)
+				ioctx.Destroy()
+			}
+			done()
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkWritesSharedConn(b *testing.B) {
+	conn, err := newTestConn()
+	require.NoError(b, err)
+	defer conn.Shutdown()
+
+	benchmarkWrites(b, func() (*Conn, func(), error) {
+		return conn, func() {}, nil
+	})
+}
+
+func BenchmarkWritesConnPool(b *testing.B) {
+	p, err := NewConnPool(8, newTestConn)
+	require.NoError(b, err)
+	defer p.Shutdown()
+
+	benchmarkWrites(b, func() (*Conn, func(), error) {
+		c, err := p.Acquire()
+		return c, func() { p.Release(c) }, err
+	})
+}
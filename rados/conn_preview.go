@@ -0,0 +1,134 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// shutdownPollInterval is how often ShutdownGraceful checks whether the
+// pending operations have completed.
+const shutdownPollInterval = 10 * time.Millisecond
+
+// opTimeoutOptions are the configuration options that together control how
+// long librados waits for monitor and OSD operations before giving up.
+var opTimeoutOptions = []string{
+	"rados_mon_op_timeout",
+	"rados_osd_op_timeout",
+}
+
+// SetOpTimeout sets the time limit for the monitor and OSD operations of the
+// connection, including those issued through any of its IO contexts.
+// Operations that do not complete within the limit fail with ETIMEDOUT
+// rather than block indefinitely. The limit is applied by setting the
+// rados_mon_op_timeout and rados_osd_op_timeout options, which takes effect
+// right away on a connection that is already connected. The timeout is
+// rounded up to whole seconds and a zero duration disables it.
+//  PREVIEW
+//
+// Implements:
+//  int rados_conf_set(rados_t cluster, const char *option,
+//                     const char *value);
+func (c *Conn) SetOpTimeout(d time.Duration) error {
+	secs := int64(d / time.Second)
+	if d%time.Second > 0 {
+		secs++
+	}
+	if secs < 0 {
+		secs = 0
+	}
+	value := strconv.FormatInt(secs, 10)
+	for _, option := range opTimeoutOptions {
+		if err := c.SetConfigOption(option, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenIOContextByID creates and returns a new IOContext for the pool with
+// the given ID. This avoids looking up the pool name when only the ID of the
+// pool is known.
+//  PREVIEW
+//
+// Implements:
+//  int rados_ioctx_create2(rados_t cluster, int64_t pool_id,
+//                          rados_ioctx_t *ioctx);
+func (c *Conn) OpenIOContextByID(poolID int64) (*IOContext, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	ioctx := &IOContext{conn: c}
+	ret := C.rados_ioctx_create2(c.cluster, C.int64_t(poolID), &ioctx.ioctx)
+	if ret == 0 {
+		return ioctx, nil
+	}
+	return nil, getError(ret)
+}
+
+// ListPoolsForApplication returns the names of the pools the given
+// application, for example "rbd", "cephfs" or "rgw", is enabled on.
+//  PREVIEW
+func (c *Conn) ListPoolsForApplication(app string) ([]string, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	buf, err := c.osdDump()
+	if err != nil {
+		return nil, err
+	}
+
+	var dump struct {
+		Pools []struct {
+			Name         string                     `json:"pool_name"`
+			Applications map[string]json.RawMessage `json:"application_metadata"`
+		} `json:"pools"`
+	}
+	if err := json.Unmarshal(buf, &dump); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, p := range dump.Pools {
+		if _, found := p.Applications[app]; found {
+			names = append(names, p.Name)
+		}
+	}
+	return names, nil
+}
+
+// ShutdownGraceful waits for the asynchronous operations pending on the IO
+// contexts opened on the connection to complete and then disconnects from
+// the cluster. If the operations do not complete within the timeout
+// ErrTimedOut is returned and the connection is left open. Nothing keeps
+// waiting for the operations in the background once ShutdownGraceful has
+// returned, so it is safe to call Shutdown or to destroy the IO contexts
+// afterwards, as it is for any operation in flight.
+//  PREVIEW
+func (c *Conn) ShutdownGraceful(timeout time.Duration) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+	if c.cluster == nil {
+		// already shut down
+		return ErrNotConnected
+	}
+
+	deadline := time.Now().Add(timeout)
+	for !pendingCompletions.complete(c) {
+		if !time.Now().Before(deadline) {
+			return ErrTimedOut
+		}
+		time.Sleep(shutdownPollInterval)
+	}
+	c.Shutdown()
+	return nil
+}
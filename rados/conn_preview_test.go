@@ -0,0 +1,251 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestOpenIOContextByID() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.OpenIOContextByID(suite.ioctx.GetPoolID())
+	ta.Equal(ErrNotConnected, err)
+
+	poolID := suite.ioctx.GetPoolID()
+	ioctx, err := suite.conn.OpenIOContextByID(poolID)
+	require.NoError(suite.T(), err)
+	defer ioctx.Destroy()
+
+	ta.Equal(poolID, ioctx.GetPoolID())
+	name, err := ioctx.GetPoolName()
+	ta.NoError(err)
+	ta.Equal(suite.pool, name)
+
+	// data written through one context is visible through the other
+	oid := suite.GenObjectName()
+	err = ioctx.WriteFull(oid, []byte("by id"))
+	ta.NoError(err)
+	data := make([]byte, 16)
+	n, err := suite.ioctx.Read(oid, data, 0)
+	ta.NoError(err)
+	ta.Equal("by id", string(data[:n]))
+
+	_, err = suite.conn.OpenIOContextByID(-1)
+	ta.Error(err)
+}
+
+func (suite *RadosTestSuite) TestListPoolsForApplication() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.ListPoolsForApplication("rbd")
+	ta.Equal(ErrNotConnected, err)
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(suite.T(), suite.conn.MakePool(pool))
+	defer suite.conn.DeletePool(pool)
+
+	pools, err := suite.conn.ListPoolsForApplication("rbd")
+	ta.NoError(err)
+	ta.NotContains(pools, pool)
+
+	// this is what rbd pool init does to tag the pool
+	cmd, err := json.Marshal(map[string]interface{}{
+		"prefix":               "osd pool application enable",
+		"pool":                 pool,
+		"app":                  "rbd",
+		"yes_i_really_mean_it": true,
+	})
+	require.NoError(suite.T(), err)
+	_, _, err = suite.conn.MonCommand(cmd)
+	require.NoError(suite.T(), err)
+
+	pools, err = suite.conn.ListPoolsForApplication("rbd")
+	ta.NoError(err)
+	ta.Contains(pools, pool)
+
+	pools, err = suite.conn.ListPoolsForApplication("no-such-app")
+	ta.NoError(err)
+	ta.NotContains(pools, pool)
+}
+
+func (suite *RadosTestSuite) TestShutdownGraceful() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	ta.Equal(ErrNotConnected, conn.ShutdownGraceful(time.Second))
+
+	require.NoError(suite.T(), conn.ReadDefaultConfigFile())
+	require.NoError(suite.T(), conn.Connect())
+	ioctx, err := conn.OpenIOContext(suite.pool)
+	require.NoError(suite.T(), err)
+	destroyed, err := conn.OpenIOContext(suite.pool)
+	require.NoError(suite.T(), err)
+	// destroyed IO contexts are not flushed
+	destroyed.Destroy()
+
+	oids := []string{}
+	completions := []*Completion{}
+	for i := 0; i < 100; i++ {
+		oid := suite.GenObjectName()
+		c, err := ioctx.WriteAsync(oid, []byte(fmt.Sprintf("pending %d", i)), 0)
+		require.NoError(suite.T(), err)
+		oids = append(oids, oid)
+		completions = append(completions, c)
+	}
+	ta.NoError(conn.ShutdownGraceful(time.Minute))
+
+	for _, c := range completions {
+		ta.NoError(c.Wait())
+	}
+	buf := make([]byte, 32)
+	for i, oid := range oids {
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		ta.NoError(err)
+		ta.Equal(fmt.Sprintf("pending %d", i), string(buf[:n]))
+	}
+
+	// the connection is shut down
+	ta.Equal(ErrNotConnected, conn.ShutdownGraceful(time.Second))
+}
+
+func (suite *RadosTestSuite) TestShutdownGracefulTimeout() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), conn.ReadDefaultConfigFile())
+	require.NoError(suite.T(), conn.Connect())
+	ioctx, err := conn.OpenIOContext(suite.pool)
+	require.NoError(suite.T(), err)
+
+	// a completion that no operation was started with never completes
+	c, err := newCompletion(ioctx, nil)
+	require.NoError(suite.T(), err)
+	ta.Equal(ErrTimedOut, conn.ShutdownGraceful(100*time.Millisecond))
+
+	// the connection is still usable after the timeout and other
+	// connections are not affected by its pending operations
+	_, err = ioctx.GetPoolName()
+	ta.NoError(err)
+	ta.True(pendingCompletions.complete(suite.conn))
+
+	c.abort()
+	ioctx.Destroy()
+	ta.NoError(conn.ShutdownGraceful(time.Second))
+}
+
+func (suite *RadosTestSuite) TestSetOpTimeout() {
+	ta := assert.New(suite.T())
+
+	// a connection of its own, so the timeout does not affect other tests
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), conn.ReadDefaultConfigFile())
+	require.NoError(suite.T(), conn.Connect())
+	defer conn.Shutdown()
+
+	err = conn.SetOpTimeout(1500 * time.Millisecond)
+	ta.NoError(err)
+	for _, option := range opTimeoutOptions {
+		val, err := conn.GetConfigOption(option)
+		ta.NoError(err)
+		ta.Equal("2", val)
+	}
+
+	suite.T().Run("blockedPool", func(t *testing.T) {
+		ioctx, cleanup := openBlockedPool(t, conn)
+		defer cleanup()
+
+		// the connection was connected before the timeout was set
+		ch := make(chan error, 1)
+		go func() {
+			ch <- ioctx.WriteFull("blocked", []byte("blocked"))
+		}()
+		select {
+		case err := <-ch:
+			assert.Equal(t, radosError(-int(syscall.ETIMEDOUT)), err)
+		case <-time.After(30 * time.Second):
+			t.Fatal("operation did not time out")
+		}
+	})
+
+	err = conn.SetOpTimeout(0)
+	ta.NoError(err)
+	for _, option := range opTimeoutOptions {
+		val, err := conn.GetConfigOption(option)
+		ta.NoError(err)
+		ta.Equal("0", val)
+	}
+}
+
+// openBlockedPool creates a pool whose placement groups can never become
+// active, because its crush rule selects from an empty crush root, and
+// returns an IO context of the pool. Operations on the pool block until they
+// time out. The returned function removes the pool along with its rule.
+func openBlockedPool(t *testing.T, conn *Conn) (*IOContext, func()) {
+	name := uuid.Must(uuid.NewV4()).String()
+	monCommand := func(cmd map[string]interface{}) error {
+		buf, err := json.Marshal(cmd)
+		require.NoError(t, err)
+		_, _, err = conn.MonCommand(buf)
+		return err
+	}
+	cleanup := func() {
+		assert.NoError(t, conn.DeletePool(name))
+		assert.NoError(t, monCommand(map[string]interface{}{
+			"prefix": "osd crush rule rm",
+			"name":   name,
+		}))
+		assert.NoError(t, monCommand(map[string]interface{}{
+			"prefix": "osd crush remove",
+			"name":   name,
+		}))
+	}
+
+	require.NoError(t, monCommand(map[string]interface{}{
+		"prefix": "osd crush add-bucket",
+		"name":   name,
+		"type":   "root",
+	}))
+	require.NoError(t, monCommand(map[string]interface{}{
+		"prefix": "osd crush rule create-replicated",
+		"name":   name,
+		"root":   name,
+		"type":   "osd",
+	}))
+	require.NoError(t, monCommand(map[string]interface{}{
+		"prefix":    "osd pool create",
+		"pool":      name,
+		"pg_num":    1,
+		"pool_type": "replicated",
+		"rule":      name,
+	}))
+	ioctx, err := conn.OpenIOContext(name)
+	if err != nil {
+		cleanup()
+		require.NoError(t, err)
+	}
+	return ioctx, func() {
+		ioctx.Destroy()
+		cleanup()
+	}
+}
@@ -0,0 +1,80 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// counterSize is the size of the data of a counter object, a little-endian
+// encoded 64-bit signed integer.
+const counterSize = 8
+
+var errCounterSize = errors.New("object does not hold a 64-bit counter")
+
+// Increment atomically adds delta, which may be negative, to the counter
+// stored in the object with key oid and returns the new value. A missing
+// object is created with a value of delta. The counter is stored as a
+// little-endian encoded 64-bit signed integer that wraps around on overflow;
+// an error is returned if the object holds data of a different size.
+//
+// The update is applied only if the counter was not changed since it was
+// read, so concurrent increments from any number of clients or goroutines are
+// never lost. Like ReadModifyWrite, Increment waits for a short, growing time
+// and tries again if the counter was changed, and gives up with
+// ErrReadModifyWriteConflict after a limited number of attempts.
+//  PREVIEW
+func (ioctx *IOContext) Increment(oid string, delta int64) (int64, error) {
+	if err := ioctx.validate(); err != nil {
+		return 0, err
+	}
+	var value int64
+	err := retryConflicts(func() (bool, error) {
+		// one more byte than needed to detect objects holding other data
+		old := make([]byte, counterSize+1)
+		n, err := ioctx.Read(oid, old, 0)
+		if err != nil && err != ErrNotFound {
+			return false, err
+		}
+		exists := err == nil
+		value = 0
+		if exists {
+			if n != counterSize {
+				return false, errCounterSize
+			}
+			value = int64(binary.LittleEndian.Uint64(old))
+		}
+		value += delta
+		data := make([]byte, counterSize)
+		binary.LittleEndian.PutUint64(data, uint64(value))
+
+		op := CreateWriteOp()
+		var cmp *WriteOpCmpExtStep
+		if exists {
+			cmp = op.CmpExt(old[:counterSize], 0)
+		} else {
+			op.Create(CreateExclusive)
+		}
+		op.WriteFull(data)
+		err = op.operateCompat(ioctx, oid)
+		op.Release()
+		if err == nil {
+			return false, nil
+		}
+		if cmp != nil && cmp.Err() != nil {
+			// the counter changed since it was read
+			return true, nil
+		}
+		if err == ErrObjectExists || err == ErrNotFound {
+			// the object was created or removed since it was read
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
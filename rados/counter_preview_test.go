@@ -0,0 +1,93 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"math"
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestIncrement() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	_, err := (&IOContext{}).Increment("foo", 1)
+	ta.Equal(ErrInvalidIOContext, err)
+
+	oid := suite.GenObjectName()
+	v, err := suite.ioctx.Increment(oid, 5)
+	ta.NoError(err)
+	ta.EqualValues(5, v)
+	v, err = suite.ioctx.Increment(oid, -7)
+	ta.NoError(err)
+	ta.EqualValues(-2, v)
+	v, err = suite.ioctx.Increment(oid, 0)
+	ta.NoError(err)
+	ta.EqualValues(-2, v)
+
+	oid2 := suite.GenObjectName()
+	v, err = suite.ioctx.Increment(oid2, math.MaxInt64)
+	ta.NoError(err)
+	ta.EqualValues(math.MaxInt64, v)
+	v, err = suite.ioctx.Increment(oid2, 1)
+	ta.NoError(err)
+	ta.EqualValues(math.MinInt64, v)
+
+	// objects holding other data are not touched
+	oid3 := suite.GenObjectName()
+	require.NoError(suite.T(), suite.ioctx.WriteFull(oid3, []byte("not a counter")))
+	_, err = suite.ioctx.Increment(oid3, 1)
+	ta.Equal(errCounterSize, err)
+}
+
+func (suite *RadosTestSuite) TestIncrementConcurrent() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	const (
+		goroutines = 16
+		increments = 25
+	)
+	oid := suite.GenObjectName()
+	// half of the goroutines share an IO context, the others use their own
+	ioctxs := make([]*IOContext, goroutines/2)
+	for i := range ioctxs {
+		ioctx, err := suite.conn.OpenIOContext(suite.pool)
+		require.NoError(suite.T(), err)
+		defer ioctx.Destroy()
+		ioctxs[i] = ioctx
+	}
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, goroutines*increments)
+	for i := 0; i < goroutines; i++ {
+		ioctx := suite.ioctx
+		if i < len(ioctxs) {
+			ioctx = ioctxs[i]
+		}
+		wg.Add(1)
+		go func(ioctx *IOContext, delta int64) {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				if _, err := ioctx.Increment(oid, delta); err != nil {
+					errs <- err
+				}
+			}
+		}(ioctx, int64(i+1))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		ta.NoError(err)
+	}
+
+	// every goroutine added its (1-based) index increments times
+	expected := int64(increments * goroutines * (goroutines + 1) / 2)
+	v, err := suite.ioctx.Increment(oid, 0)
+	ta.NoError(err)
+	ta.Equal(expected, v)
+}
@@ -0,0 +1,69 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+// DFGlobalStats contains the cluster wide usage statistics reported by the
+// "df" monitor command.
+type DFGlobalStats struct {
+	TotalBytes        uint64  `json:"total_bytes"`
+	TotalAvailBytes   uint64  `json:"total_avail_bytes"`
+	TotalUsedBytes    uint64  `json:"total_used_bytes"`
+	TotalUsedRawBytes uint64  `json:"total_used_raw_bytes"`
+	TotalUsedRawRatio float64 `json:"total_used_raw_ratio"`
+	NumOSDs           uint64  `json:"num_osds"`
+}
+
+// DFPoolUsage contains the usage statistics of a single pool reported by
+// the "df" monitor command.
+type DFPoolUsage struct {
+	Stored      uint64  `json:"stored"`
+	Objects     uint64  `json:"objects"`
+	KbUsed      uint64  `json:"kb_used"`
+	BytesUsed   uint64  `json:"bytes_used"`
+	PercentUsed float64 `json:"percent_used"`
+	MaxAvail    uint64  `json:"max_avail"`
+}
+
+// DFPoolStats identifies a pool and contains its usage statistics.
+type DFPoolStats struct {
+	Name  string      `json:"name"`
+	ID    int64       `json:"id"`
+	Stats DFPoolUsage `json:"stats"`
+}
+
+// DFStats contains the output of the "df" monitor command.
+type DFStats struct {
+	Stats DFGlobalStats `json:"stats"`
+	Pools []DFPoolStats `json:"pools"`
+}
+
+// GetDF returns the global and per-pool usage statistics of the cluster, as
+// reported by the "df" monitor command.
+//  PREVIEW
+func (c *Conn) GetDF() (*DFStats, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "df",
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &DFStats{}
+	if err := json.Unmarshal(buf, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,41 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestGetDF() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.GetDF()
+	ta.Equal(ErrNotConnected, err)
+
+	df, err := suite.conn.GetDF()
+	require.NoError(suite.T(), err)
+
+	ta.NotZero(df.Stats.TotalBytes)
+	ta.NotZero(df.Stats.NumOSDs)
+	ta.True(df.Stats.TotalAvailBytes <= df.Stats.TotalBytes)
+	ta.True(df.Stats.TotalUsedRawBytes <= df.Stats.TotalBytes)
+	ta.True(df.Stats.TotalUsedRawRatio >= 0 && df.Stats.TotalUsedRawRatio <= 1)
+
+	poolID, err := suite.conn.GetPoolByName(suite.pool)
+	require.NoError(suite.T(), err)
+	found := false
+	for _, p := range df.Pools {
+		if p.Name == suite.pool {
+			found = true
+			ta.Equal(poolID, p.ID)
+			ta.True(p.Stats.MaxAvail <= df.Stats.TotalBytes)
+			ta.True(p.Stats.PercentUsed >= 0 && p.Stats.PercentUsed <= 1)
+		}
+	}
+	ta.True(found, "pool %s not found in df output", suite.pool)
+}
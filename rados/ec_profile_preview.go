@@ -0,0 +1,102 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// ECProfile is an erasure-code profile, describing how the objects of an
+// erasure coded pool are split into data and coding chunks.
+type ECProfile struct {
+	// Name of the profile.
+	Name string
+	// K is the number of data chunks.
+	K int
+	// M is the number of coding chunks.
+	M int
+	// Plugin is the erasure-code plugin, for example "jerasure".
+	Plugin string
+	// Technique is the plugin specific coding technique, for example
+	// "reed_sol_van". It is empty for plugins without techniques.
+	Technique string
+	// Options contains all the settings of the profile, including the
+	// ones decoded into the fields above.
+	Options map[string]string
+}
+
+func parseECProfile(name string, buf []byte) (*ECProfile, error) {
+	options := map[string]string{}
+	if err := json.Unmarshal(buf, &options); err != nil {
+		return nil, err
+	}
+	p := &ECProfile{
+		Name:      name,
+		Plugin:    options["plugin"],
+		Technique: options["technique"],
+		Options:   options,
+	}
+	values := []struct {
+		key   string
+		value *int
+	}{
+		{"k", &p.K},
+		{"m", &p.M},
+	}
+	for _, v := range values {
+		s, ok := options[v.key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		*v.value = n
+	}
+	return p, nil
+}
+
+// GetECProfile returns the erasure-code profile of the named erasure coded
+// pool. An error is returned if the pool is a replicated pool.
+//  PREVIEW
+func (c *Conn) GetECProfile(poolName string) (*ECProfile, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "osd pool get",
+		"pool":   poolName,
+		"var":    "erasure_code_profile",
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	pool := struct {
+		Profile string `json:"erasure_code_profile"`
+	}{}
+	if err := json.Unmarshal(buf, &pool); err != nil {
+		return nil, err
+	}
+
+	cmd, err = json.Marshal(map[string]string{
+		"prefix": "osd erasure-code-profile get",
+		"name":   pool.Profile,
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err = c.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseECProfile(pool.Profile, buf)
+}
@@ -0,0 +1,82 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseECProfile(t *testing.T) {
+	buf := []byte(`{"crush-device-class":"","crush-failure-domain":"osd",
+		"crush-root":"default","jerasure-per-chunk-alignment":"false",
+		"k":"4","m":"2","plugin":"jerasure","technique":"reed_sol_van",
+		"w":"8"}`)
+	p, err := parseECProfile("ec42", buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ec42", p.Name)
+	assert.Equal(t, 4, p.K)
+	assert.Equal(t, 2, p.M)
+	assert.Equal(t, "jerasure", p.Plugin)
+	assert.Equal(t, "reed_sol_van", p.Technique)
+	assert.Equal(t, "osd", p.Options["crush-failure-domain"])
+
+	_, err = parseECProfile("bad", []byte(`{"k":"four","m":"2"}`))
+	assert.Error(t, err)
+	_, err = parseECProfile("bad", []byte(`[]`))
+	assert.Error(t, err)
+}
+
+func (suite *RadosTestSuite) TestGetECProfile() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.GetECProfile("foo")
+	ta.Equal(ErrNotConnected, err)
+
+	monCommand := func(args map[string]interface{}) {
+		cmd, err := json.Marshal(args)
+		require.NoError(suite.T(), err)
+		_, _, err = suite.conn.MonCommand(cmd)
+		require.NoError(suite.T(), err)
+	}
+
+	profile := "goceph-" + uuid.Must(uuid.NewV4()).String()
+	monCommand(map[string]interface{}{
+		"prefix":  "osd erasure-code-profile set",
+		"name":    profile,
+		"profile": []string{"k=2", "m=1", "crush-failure-domain=osd"},
+	})
+	defer monCommand(map[string]interface{}{
+		"prefix": "osd erasure-code-profile rm",
+		"name":   profile,
+	})
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	monCommand(map[string]interface{}{
+		"prefix":               "osd pool create",
+		"pool":                 pool,
+		"pg_num":               8,
+		"pool_type":            "erasure",
+		"erasure_code_profile": profile,
+	})
+	defer func() { ta.NoError(suite.conn.DeletePool(pool)) }()
+
+	p, err := suite.conn.GetECProfile(pool)
+	require.NoError(suite.T(), err)
+	ta.Equal(profile, p.Name)
+	ta.Equal(2, p.K)
+	ta.Equal(1, p.M)
+	ta.NotEmpty(p.Plugin)
+
+	// replicated pools have no erasure-code profile
+	_, err = suite.conn.GetECProfile(suite.pool)
+	ta.Error(err)
+}
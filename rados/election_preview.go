@@ -0,0 +1,128 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	electionLockName = "go-ceph election"
+
+	// electionNotifyTimeout limits how long Resign waits for the other
+	// candidates to acknowledge that the leadership is available.
+	electionNotifyTimeout = 5 * time.Second
+)
+
+var (
+	// ErrNoLeader is returned by Election.Leader when no candidate holds
+	// the leadership.
+	ErrNoLeader = errors.New("election has no leader")
+	// ErrNotLeader is returned by Election.Resign when the candidate does
+	// not hold the leadership.
+	ErrNotLeader = errors.New("candidate is not the leader")
+)
+
+// Election elects a single leader among candidates, which may run in
+// different processes, contending for the same rados object. The leader
+// holds an exclusive lock on the object, with a lease that is renewed in the
+// background like that of a Mutex. Candidates waiting for the leadership
+// watch the object so that they are notified as soon as the leader resigns.
+// If the leader goes away without resigning another candidate is elected
+// once the lease expires.
+type Election struct {
+	ioctx *IOContext
+	oid   string
+	mutex *Mutex
+}
+
+// NewElection returns an Election for the candidate with the given ID,
+// contending for the leadership represented by the object with key oid. The
+// ID should be unique among the candidates. The object is created if it
+// does not exist when the candidate campaigns.
+//  PREVIEW
+func (ioctx *IOContext) NewElection(oid, candidateID string) *Election {
+	m := ioctx.NewMutex(oid, electionLockName)
+	m.cookie = candidateID
+	return &Election{
+		ioctx: ioctx,
+		oid:   oid,
+		mutex: m,
+	}
+}
+
+// SetLease sets the duration of the lease held by the leader. A candidate
+// that stops renewing its lease, for example because the process exited,
+// loses the leadership once the lease expires. The new lease takes effect
+// the next time the candidate campaigns. ErrInvalidMutexLease is returned if
+// the lease is not positive.
+//  PREVIEW
+func (e *Election) SetLease(d time.Duration) error {
+	return e.mutex.SetLease(d)
+}
+
+// Campaign waits until the candidate is elected leader. If the candidate is
+// already the leader Campaign returns immediately. If the context is done
+// before the candidate is elected the error of the context is returned.
+// Resign does not wait for a pending Campaign of the same candidate to end.
+//  PREVIEW
+func (e *Election) Campaign(ctx context.Context) error {
+	if err := e.ioctx.validate(); err != nil {
+		return err
+	}
+	if err := e.ioctx.Create(e.oid, CreateIdempotent); err != nil {
+		return err
+	}
+	sub, err := e.ioctx.Subscribe(e.oid)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	err = e.mutex.acquire(ctx, sub.Events())
+	if err == ErrMutexLocked {
+		return nil
+	}
+	return err
+}
+
+// Resign gives up the leadership and notifies the waiting candidates so that
+// one of them is elected. ErrNotLeader is returned if the candidate is not
+// the leader.
+//  PREVIEW
+func (e *Election) Resign() error {
+	err := e.mutex.Unlock()
+	if err == ErrMutexNotLocked {
+		return ErrNotLeader
+	}
+	if err != nil {
+		return err
+	}
+	// candidates that miss the notification notice the released lock on
+	// their next attempt
+	_ = e.ioctx.Notify(e.oid, nil, electionNotifyTimeout)
+	return nil
+}
+
+// Leader returns the ID of the candidate currently holding the leadership.
+// ErrNoLeader is returned if there is no leader.
+//  PREVIEW
+func (e *Election) Leader() (string, error) {
+	if err := e.ioctx.validate(); err != nil {
+		return "", err
+	}
+	info, err := e.ioctx.ListLockers(e.oid, electionLockName)
+	if err == ErrNotFound {
+		return "", ErrNoLeader
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.NumLockers == 0 || len(info.Cookies) == 0 {
+		return "", ErrNoLeader
+	}
+	return info.Cookies[0], nil
+}
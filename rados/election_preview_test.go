@@ -0,0 +1,158 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestElection() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	elections := make([]*Election, 3)
+	for i := range elections {
+		elections[i] = suite.ioctx.NewElection(oid, fmt.Sprintf("candidate%d", i))
+		require.NoError(suite.T(), elections[i].SetLease(2*time.Second))
+	}
+
+	_, err := elections[0].Leader()
+	ta.Equal(ErrNoLeader, err)
+	ta.Equal(ErrNotLeader, elections[0].Resign())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	elected := make(chan int, len(elections))
+	for i := range elections {
+		go func(i int) {
+			if ta.NoError(elections[i].Campaign(ctx)) {
+				elected <- i
+			}
+		}(i)
+	}
+
+	waitElected := func() int {
+		select {
+		case i := <-elected:
+			return i
+		case <-time.After(20 * time.Second):
+			suite.T().Fatal("no candidate was elected")
+		}
+		return -1
+	}
+	// only one candidate is elected while the leader keeps its lease
+	assertSingleLeader := func(leader int) {
+		select {
+		case i := <-elected:
+			suite.T().Fatalf("candidate%d elected while candidate%d leads", i, leader)
+		case <-time.After(3 * time.Second):
+		}
+		id, err := elections[leader].Leader()
+		ta.NoError(err)
+		ta.Equal(fmt.Sprintf("candidate%d", leader), id)
+	}
+
+	first := waitElected()
+	assertSingleLeader(first)
+	// campaigning again as the leader returns immediately
+	ta.NoError(elections[first].Campaign(ctx))
+
+	// resigning promotes another candidate
+	ta.NoError(elections[first].Resign())
+	second := waitElected()
+	ta.NotEqual(first, second)
+	assertSingleLeader(second)
+
+	// a leader that stops renewing its lease is replaced once it expires
+	m := elections[second].mutex
+	close(m.stop)
+	<-m.done
+	third := waitElected()
+	ta.NotEqual(first, third)
+	ta.NotEqual(second, third)
+	id, err := elections[third].Leader()
+	ta.NoError(err)
+	ta.Equal(fmt.Sprintf("candidate%d", third), id)
+
+	ta.NoError(elections[third].Resign())
+	_, err = elections[third].Leader()
+	ta.Equal(ErrNoLeader, err)
+}
+
+func (suite *RadosTestSuite) TestElectionCanceled() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	leader := suite.ioctx.NewElection(oid, "leader")
+	require.NoError(suite.T(), leader.Campaign(context.Background()))
+	defer func() { ta.NoError(leader.Resign()) }()
+
+	other := suite.ioctx.NewElection(oid, "other")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ta.Equal(context.DeadlineExceeded, other.Campaign(ctx))
+	ta.Equal(ErrNotLeader, other.Resign())
+
+	// the canceled campaign leaves no watch behind
+	watchers, err := suite.ioctx.ListWatchers(oid)
+	ta.NoError(err)
+	ta.Len(watchers, 0)
+
+	ta.Equal(ErrInvalidIOContext,
+		(&IOContext{}).NewElection(oid, "x").Campaign(context.Background()))
+}
+
+func (suite *RadosTestSuite) TestElectionConcurrentResign() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	leader := suite.ioctx.NewElection(oid, "leader")
+	require.NoError(suite.T(), leader.Campaign(context.Background()))
+
+	other := suite.ioctx.NewElection(oid, "other")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	campaign := make(chan error, 1)
+	go func() { campaign <- other.Campaign(ctx) }()
+
+	// a Resign racing with the pending Campaign returns right away
+	resigned := make(chan error, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		resigned <- other.Resign()
+	}()
+	select {
+	case err := <-resigned:
+		ta.Equal(ErrNotLeader, err)
+	case <-time.After(5 * time.Second):
+		suite.T().Fatal("Resign blocked by a pending Campaign")
+	}
+
+	// the campaign goes on and wins once the leader resigns
+	ta.NoError(leader.Resign())
+	select {
+	case err := <-campaign:
+		ta.NoError(err)
+	case <-time.After(20 * time.Second):
+		suite.T().Fatal("candidate was not elected")
+	}
+	id, err := other.Leader()
+	ta.NoError(err)
+	ta.Equal("other", id)
+	ta.NoError(other.Resign())
+}
+
+func (suite *RadosTestSuite) TestElectionInvalidLease() {
+	e := (&IOContext{}).NewElection("foo", "bar")
+	assert.Equal(suite.T(), ErrInvalidMutexLease, e.SetLease(0))
+	assert.Equal(suite.T(), ErrInvalidMutexLease, e.SetLease(-time.Second))
+}
@@ -0,0 +1,91 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+// HealthState is the overall health of the cluster, or the severity of a
+// single health check.
+type HealthState string
+
+const (
+	// HealthOK indicates the cluster is healthy.
+	HealthOK = HealthState("HEALTH_OK")
+	// HealthWarn indicates a condition that needs attention.
+	HealthWarn = HealthState("HEALTH_WARN")
+	// HealthErr indicates a serious condition that needs immediate
+	// attention.
+	HealthErr = HealthState("HEALTH_ERR")
+)
+
+// HealthCheck describes a single health check that is currently raised.
+type HealthCheck struct {
+	Severity HealthState
+	Summary  string
+	Count    int
+	Muted    bool
+}
+
+// HealthStatus contains the overall health of the cluster and the health
+// checks that are currently raised, keyed by the name of the check, for
+// example "OSD_DOWN".
+type HealthStatus struct {
+	Status HealthState
+	Checks map[string]HealthCheck
+}
+
+type healthResponse struct {
+	Status HealthState `json:"status"`
+	Checks map[string]struct {
+		Severity HealthState `json:"severity"`
+		Summary  struct {
+			Message string `json:"message"`
+			Count   int    `json:"count"`
+		} `json:"summary"`
+		Muted bool `json:"muted"`
+	} `json:"checks"`
+}
+
+func parseHealthStatus(buf []byte) (*HealthStatus, error) {
+	r := healthResponse{}
+	if err := json.Unmarshal(buf, &r); err != nil {
+		return nil, err
+	}
+	hs := &HealthStatus{
+		Status: r.Status,
+		Checks: make(map[string]HealthCheck, len(r.Checks)),
+	}
+	for name, c := range r.Checks {
+		hs.Checks[name] = HealthCheck{
+			Severity: c.Severity,
+			Summary:  c.Summary.Message,
+			Count:    c.Summary.Count,
+			Muted:    c.Muted,
+		}
+	}
+	return hs, nil
+}
+
+// GetClusterHealth returns the health of the cluster as reported by the
+// "health" monitor command.
+//  PREVIEW
+func (c *Conn) GetClusterHealth() (*HealthStatus, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "health",
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseHealthStatus(buf)
+}
@@ -0,0 +1,91 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestGetClusterHealth() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.GetClusterHealth()
+	ta.Equal(ErrNotConnected, err)
+
+	hs, err := suite.conn.GetClusterHealth()
+	require.NoError(suite.T(), err)
+	ta.Contains([]HealthState{HealthOK, HealthWarn, HealthErr}, hs.Status)
+	if hs.Status == HealthOK {
+		ta.Len(hs.Checks, 0)
+	} else {
+		ta.NotEmpty(hs.Checks)
+	}
+	for name, c := range hs.Checks {
+		ta.NotEmpty(name)
+		ta.NotEmpty(c.Summary)
+		ta.Contains([]HealthState{HealthWarn, HealthErr}, c.Severity)
+	}
+}
+
+func TestParseHealthStatus(t *testing.T) {
+	t.Run("healthy", func(t *testing.T) {
+		hs, err := parseHealthStatus(
+			[]byte(`{"status":"HEALTH_OK","checks":{},"mutes":[]}`))
+		assert.NoError(t, err)
+		assert.Equal(t, HealthOK, hs.Status)
+		assert.Len(t, hs.Checks, 0)
+	})
+
+	t.Run("noChecks", func(t *testing.T) {
+		hs, err := parseHealthStatus([]byte(`{"status":"HEALTH_OK"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, HealthOK, hs.Status)
+		assert.Len(t, hs.Checks, 0)
+	})
+
+	t.Run("withChecks", func(t *testing.T) {
+		buf := []byte(`{
+  "status": "HEALTH_WARN",
+  "checks": {
+    "POOL_APP_NOT_ENABLED": {
+      "severity": "HEALTH_WARN",
+      "summary": {
+        "message": "1 pool(s) do not have an application enabled",
+        "count": 1
+      },
+      "muted": false
+    },
+    "OSD_DOWN": {
+      "severity": "HEALTH_ERR",
+      "summary": {"message": "1 osds down", "count": 1},
+      "muted": true
+    }
+  },
+  "mutes": []
+}`)
+		hs, err := parseHealthStatus(buf)
+		require.NoError(t, err)
+		assert.Equal(t, HealthWarn, hs.Status)
+		require.Len(t, hs.Checks, 2)
+		c := hs.Checks["POOL_APP_NOT_ENABLED"]
+		assert.Equal(t, HealthWarn, c.Severity)
+		assert.Equal(t, "1 pool(s) do not have an application enabled", c.Summary)
+		assert.Equal(t, 1, c.Count)
+		assert.False(t, c.Muted)
+		c = hs.Checks["OSD_DOWN"]
+		assert.Equal(t, HealthErr, c.Severity)
+		assert.True(t, c.Muted)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseHealthStatus([]byte(`{"status":`))
+		assert.Error(t, err)
+	})
+}
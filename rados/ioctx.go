@@ -94,6 +94,8 @@ type LockInfo struct {
 // IOContext represents a context for performing I/O within a pool.
 type IOContext struct {
 	ioctx C.rados_ioctx_t
+	// conn is the connection the IO context was opened on, if known.
+	conn *Conn
 }
 
 // validate returns an error if the ioctx is not ready to be used
@@ -0,0 +1,386 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <errno.h>
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"errors"
+	"math/rand"
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// ObjectVisitFunc is the type of the function called for each object
+// visited by ForEachObject. It is passed the name, namespace and locator key
+// of the object. Returning a non-nil error stops the scan.
+type ObjectVisitFunc func(oid, nspace, locator string) error
+
+// ForEachObject calls fn for each object in the pool associated with the I/O
+// context. Call SetNamespace with AllNamespaces before calling this function
+// to visit objects in all namespaces. If fn returns an error the scan stops
+// and that error is returned.
+//  PREVIEW
+//
+// Implements:
+//  int rados_nobjects_list_open(rados_ioctx_t io, rados_list_ctx_t *ctx);
+//  int rados_nobjects_list_next(rados_list_ctx_t ctx, const char **entry,
+//                               const char **key, const char **nspace);
+//  void rados_nobjects_list_close(rados_list_ctx_t ctx);
+func (ioctx *IOContext) ForEachObject(fn ObjectVisitFunc) error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	var ctx C.rados_list_ctx_t
+	ret := C.rados_nobjects_list_open(ioctx.ioctx, &ctx)
+	if ret < 0 {
+		return getError(ret)
+	}
+	defer C.rados_nobjects_list_close(ctx)
+
+	for {
+		var cEntry, cKey, cNamespace *C.char
+		ret := C.rados_nobjects_list_next(ctx, &cEntry, &cKey, &cNamespace)
+		if ret == -C.ENOENT {
+			return nil
+		} else if ret < 0 {
+			return getError(ret)
+		}
+		err := fn(C.GoString(cEntry), C.GoString(cNamespace), C.GoString(cKey))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CopyObject copies the object srcOid, including its data, xattrs and omap,
+// to the object dstOid of the dst I/O context, which may be associated with a
+// different pool. An existing destination object is replaced.
+//
+// The librados C API provides no server side copy, so the source object is
+// read by the client and the destination is written with a single write
+// operation, so that it is never seen partially copied. The source object is
+// not read atomically and should not be modified while it is being copied.
+//
+// The C API has no way to read or write the omap header of an object, so the
+// omap header is not copied. The destination object ends up without an omap
+// header, as replacing its omap also clears any header it had.
+//  PREVIEW
+func (ioctx *IOContext) CopyObject(srcOid string, dst *IOContext, dstOid string) error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	if err := dst.validate(); err != nil {
+		return err
+	}
+
+	stat, err := ioctx.Stat(srcOid)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, stat.Size)
+	for off := 0; off < len(data); {
+		n, err := ioctx.Read(srcOid, data[off:], uint64(off))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			// the object shrank while it was being read
+			data = data[:off]
+			break
+		}
+		off += n
+	}
+	xattrs, err := ioctx.ListXattrs(srcOid)
+	if err != nil {
+		return err
+	}
+	omap, err := ioctx.GetAllOmapValues(srcOid, "", "", 1000)
+	if err != nil {
+		return err
+	}
+	oldXattrs, err := dst.ListXattrs(dstOid)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	op := CreateWriteOp()
+	defer op.Release()
+	op.Create(CreateIdempotent)
+	if len(data) > 0 {
+		op.WriteFull(data)
+	} else {
+		op.truncate(0)
+	}
+	for name := range oldXattrs {
+		if _, ok := xattrs[name]; !ok {
+			op.RmXattr(name)
+		}
+	}
+	for name, value := range xattrs {
+		op.SetXattr(name, value)
+	}
+	op.CleanOmap()
+	if len(omap) > 0 {
+		op.SetOmap(omap)
+	}
+	return op.Operate(dst, dstOid, OperationNoFlag)
+}
+
+// omapStatsPageSize is the number of omap entries OmapStats fetches with
+// each read operation.
+const omapStatsPageSize = 1000
+
+// OmapStats returns the number of omap keys of the object with key oid and
+// the total size, in bytes, of those keys and their values. The omap is
+// read in its entirety, one page at a time, so the cost of the call grows
+// with the size of the omap.
+//  PREVIEW
+func (ioctx *IOContext) OmapStats(oid string) (uint64, uint64, error) {
+	if err := ioctx.validate(); err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		numKeys    uint64
+		totalBytes uint64
+		startAfter string
+	)
+	for {
+		more, err := ioctx.omapStatsPage(oid, startAfter, func(kv *OmapKeyValue) {
+			numKeys++
+			totalBytes += uint64(len(kv.Key) + len(kv.Value))
+			startAfter = kv.Key
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		if !more {
+			return numKeys, totalBytes, nil
+		}
+	}
+}
+
+func (ioctx *IOContext) omapStatsPage(
+	oid, startAfter string, fn func(*OmapKeyValue)) (bool, error) {
+
+	op := CreateReadOp()
+	defer op.Release()
+	gos := op.GetOmapValues(startAfter, "", omapStatsPageSize)
+	if err := op.Operate(ioctx, oid, OperationNoFlag); err != nil {
+		return false, err
+	}
+	for {
+		kv, err := gos.Next()
+		if err != nil {
+			return false, err
+		}
+		if kv == nil {
+			break
+		}
+		fn(kv)
+	}
+	return gos.More(), nil
+}
+
+const errOverflow = radosError(-C.EOVERFLOW)
+
+const (
+	// readModifyWriteRetries is the number of times ReadModifyWrite tries
+	// to replace the data of an object that keeps changing under it.
+	readModifyWriteRetries = 32
+	// readModifyWriteMinBackoff and readModifyWriteMaxBackoff bound the
+	// time ReadModifyWrite waits before trying again after a conflict.
+	readModifyWriteMinBackoff = time.Millisecond
+	readModifyWriteMaxBackoff = 100 * time.Millisecond
+)
+
+// ErrReadModifyWriteConflict is returned by ReadModifyWrite when the
+// object was changed by others every time its data was about to be
+// replaced.
+var ErrReadModifyWriteConflict = errors.New(
+	"object kept changing during read-modify-write")
+
+// ModifyFunc is called by ReadModifyWrite with the current data of an
+// object, or nil if the object does not exist, and returns the new data of
+// the object.
+type ModifyFunc func(old []byte) ([]byte, error)
+
+// ReadModifyWrite reads the object with key oid, passes its data to modify
+// and replaces the data with the result, provided the object was not changed
+// in the meantime. If it was, ReadModifyWrite waits for a short, growing
+// time and repeats the read and the call to modify, giving up with
+// ErrReadModifyWriteConflict after a limited number of attempts. If the
+// object does not exist modify is called with nil and the object is
+// created. An error returned by modify aborts ReadModifyWrite and is
+// returned as is.
+//  PREVIEW
+func (ioctx *IOContext) ReadModifyWrite(oid string, modify ModifyFunc) error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	return retryConflicts(func() (bool, error) {
+		old, version, err := ioctx.readVersioned(oid)
+		if err != nil && err != ErrNotFound {
+			return false, err
+		}
+		exists := err == nil
+
+		data, err := modify(old)
+		if err != nil {
+			return false, err
+		}
+
+		op := CreateWriteOp()
+		if exists {
+			op.AssertVersion(version)
+		} else {
+			op.Create(CreateExclusive)
+		}
+		if len(data) > 0 {
+			op.WriteFull(data)
+		} else {
+			op.truncate(0)
+		}
+		// the error of the op itself tells whether the object changed
+		err = op.operateCompat(ioctx, oid)
+		op.Release()
+		switch err {
+		case errRange, errOverflow, errCanceled, ErrObjectExists, ErrNotFound:
+			// the object changed since it was read
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// retryConflicts calls attempt until it returns without reporting a
+// conflicting change of the object it updates, waiting for a short, growing
+// and jittered time before every new attempt. ErrReadModifyWriteConflict is
+// returned if all of readModifyWriteRetries attempts ran into a conflict.
+func retryConflicts(attempt func() (conflict bool, err error)) error {
+	backoff := readModifyWriteMinBackoff
+	for i := 0; i < readModifyWriteRetries; i++ {
+		if i > 0 {
+			time.Sleep(backoff/2 + time.Duration(rand.Int63n(int64(backoff))))
+			if backoff *= 2; backoff > readModifyWriteMaxBackoff {
+				backoff = readModifyWriteMaxBackoff
+			}
+		}
+		conflict, err := attempt()
+		if !conflict {
+			return err
+		}
+	}
+	return ErrReadModifyWriteConflict
+}
+
+// readVersionedStep reads the size and the data of an object as part of a
+// read op. The version the data was read from is available once the op was
+// performed.
+type readVersionedStep struct {
+	withoutUpdate
+
+	// C returned data:
+	buf       unsafe.Pointer
+	bytesRead *C.size_t
+	size      *C.uint64_t
+	prval     *C.int
+}
+
+func newReadVersionedStep(length int) *readVersionedStep {
+	s := &readVersionedStep{
+		buf:       C.malloc(C.size_t(length)),
+		bytesRead: (*C.size_t)(C.malloc(C.sizeof_size_t)),
+		size:      (*C.uint64_t)(C.malloc(C.sizeof_uint64_t)),
+		prval:     (*C.int)(C.malloc(C.sizeof_int * 2)),
+	}
+	*s.bytesRead = 0
+	*s.size = 0
+	runtime.SetFinalizer(s, opStepFinalizer)
+	return s
+}
+
+func (s *readVersionedStep) free() {
+	C.free(s.buf)
+	s.buf = nil
+	C.free(unsafe.Pointer(s.bytesRead))
+	s.bytesRead = nil
+	C.free(unsafe.Pointer(s.size))
+	s.size = nil
+	C.free(unsafe.Pointer(s.prval))
+	s.prval = nil
+}
+
+// readVersioned reads all of the data of the object with key oid and
+// returns it along with the version of the object it was read from. The
+// data and the version are taken from a single read op, so that they always
+// belong together no matter what else the IO context is used for.
+//
+// Implements:
+//  void rados_read_op_stat(rados_read_op_t read_op,
+//                          uint64_t *psize,
+//                          time_t *pmtime,
+//                          int *prval);
+//  void rados_read_op_read(rados_read_op_t read_op,
+//                          uint64_t offset,
+//                          size_t len,
+//                          char *buf,
+//                          size_t *bytes_read,
+//                          int *prval);
+//  uint64_t rados_aio_get_version(rados_completion_t c);
+func (ioctx *IOContext) readVersioned(oid string) ([]byte, uint64, error) {
+	length := 4096
+	for {
+		op := CreateReadOp()
+		s := newReadVersionedStep(length)
+		op.steps = append(op.steps, s)
+		prvals := (*[2]C.int)(unsafe.Pointer(s.prval))
+		C.rados_read_op_stat(op.op, s.size, nil, &prvals[0])
+		C.rados_read_op_read(
+			op.op,
+			0,
+			C.size_t(length),
+			(*C.char)(s.buf),
+			s.bytesRead,
+			&prvals[1])
+
+		c, err := op.operateAsync(ioctx, oid)
+		if err != nil {
+			op.Release()
+			return nil, 0, err
+		}
+		var version uint64
+		onComplete := c.onComplete
+		c.onComplete = func(ret C.int) error {
+			version = uint64(C.rados_aio_get_version(c.completion))
+			return onComplete(ret)
+		}
+		switch err := c.Wait().(type) {
+		case nil:
+		case OperationError:
+			op.Release()
+			return nil, 0, err.OpError
+		default:
+			op.Release()
+			return nil, 0, err
+		}
+
+		if size := int(*s.size); size > length {
+			// the object is larger than the buffer, read it again
+			op.Release()
+			length = size
+			continue
+		}
+		data := C.GoBytes(s.buf, C.int(*s.bytesRead))
+		op.Release()
+		return data, version, nil
+	}
+}
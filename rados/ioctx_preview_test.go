@@ -0,0 +1,357 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestForEachObject() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+	ra := require.New(suite.T())
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	ra.NoError(suite.conn.MakePool(pool))
+	defer suite.conn.DeletePool(pool)
+	ioctx, err := suite.conn.OpenIOContext(pool)
+	ra.NoError(err)
+	defer ioctx.Destroy()
+
+	expected := map[string]string{}
+	for _, ns := range []string{"", "ns1"} {
+		ioctx.SetNamespace(ns)
+		for i := 0; i < 5; i++ {
+			oid := suite.GenObjectName()
+			ra.NoError(ioctx.WriteFull(oid, []byte("data")))
+			expected[ns+"/"+oid] = ns
+		}
+	}
+
+	suite.T().Run("allObjects", func(t *testing.T) {
+		ioctx.SetNamespace(AllNamespaces)
+		visited := map[string]string{}
+		err := ioctx.ForEachObject(func(oid, nspace, locator string) error {
+			key := nspace + "/" + oid
+			ta.NotContains(visited, key)
+			ta.Equal("", locator)
+			visited[key] = nspace
+			return nil
+		})
+		ta.NoError(err)
+		ta.Equal(expected, visited)
+	})
+
+	suite.T().Run("oneNamespace", func(t *testing.T) {
+		ioctx.SetNamespace("ns1")
+		count := 0
+		err := ioctx.ForEachObject(func(oid, nspace, locator string) error {
+			ta.Equal("ns1", nspace)
+			count++
+			return nil
+		})
+		ta.NoError(err)
+		ta.Equal(5, count)
+	})
+
+	suite.T().Run("stopEarly", func(t *testing.T) {
+		ioctx.SetNamespace(AllNamespaces)
+		errStop := errors.New("stop")
+		count := 0
+		err := ioctx.ForEachObject(func(oid, nspace, locator string) error {
+			count++
+			if count == 3 {
+				return errStop
+			}
+			return nil
+		})
+		ta.Equal(errStop, err)
+		ta.Equal(3, count)
+	})
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		err := (&IOContext{}).ForEachObject(func(string, string, string) error {
+			return nil
+		})
+		ta.Equal(ErrInvalidIOContext, err)
+	})
+}
+
+func (suite *RadosTestSuite) TestCopyObject() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+	ra := require.New(suite.T())
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	ra.NoError(suite.conn.MakePool(pool))
+	defer suite.conn.DeletePool(pool)
+	dst, err := suite.conn.OpenIOContext(pool)
+	ra.NoError(err)
+	defer dst.Destroy()
+
+	src := suite.GenObjectName()
+	data := suite.RandomBytes(3 << 20)
+	ra.NoError(suite.ioctx.WriteFull(src, data))
+	ra.NoError(suite.ioctx.SetXattr(src, "owner", []byte("alice")))
+	ra.NoError(suite.ioctx.SetXattr(src, "kind", []byte("report")))
+	omap := map[string][]byte{
+		"k1": []byte("v1"),
+		"k2": []byte("v2"),
+	}
+	ra.NoError(suite.ioctx.SetOmap(src, omap))
+
+	suite.T().Run("otherPool", func(t *testing.T) {
+		dstOid := suite.GenObjectName()
+		err := suite.ioctx.CopyObject(src, dst, dstOid)
+		ta.NoError(err)
+
+		buf := make([]byte, len(data)+1)
+		total := 0
+		for {
+			n, err := dst.Read(dstOid, buf[total:], uint64(total))
+			ta.NoError(err)
+			if n == 0 || err != nil {
+				break
+			}
+			total += n
+		}
+		ta.Equal(data, buf[:total])
+
+		xattrs, err := dst.ListXattrs(dstOid)
+		ta.NoError(err)
+		ta.Equal(map[string][]byte{
+			"owner": []byte("alice"),
+			"kind":  []byte("report"),
+		}, xattrs)
+
+		got, err := dst.GetAllOmapValues(dstOid, "", "", 100)
+		ta.NoError(err)
+		ta.Equal(omap, got)
+	})
+
+	suite.T().Run("replaceExisting", func(t *testing.T) {
+		empty := suite.GenObjectName()
+		ra.NoError(suite.ioctx.Create(empty, CreateExclusive))
+
+		dstOid := suite.GenObjectName()
+		ra.NoError(dst.WriteFull(dstOid, []byte("old data")))
+		ra.NoError(dst.SetXattr(dstOid, "stale", []byte("x")))
+		ra.NoError(dst.SetOmap(dstOid, map[string][]byte{"old": []byte("x")}))
+
+		err := suite.ioctx.CopyObject(empty, dst, dstOid)
+		ta.NoError(err)
+
+		stat, err := dst.Stat(dstOid)
+		ta.NoError(err)
+		ta.Equal(uint64(0), stat.Size)
+		xattrs, err := dst.ListXattrs(dstOid)
+		ta.NoError(err)
+		ta.Len(xattrs, 0)
+		got, err := dst.GetAllOmapValues(dstOid, "", "", 100)
+		ta.NoError(err)
+		ta.Len(got, 0)
+	})
+
+	suite.T().Run("missingSource", func(t *testing.T) {
+		err := suite.ioctx.CopyObject("nosuchobject", dst, "nosuchobject")
+		ta.Equal(ErrNotFound, err)
+	})
+}
+
+func (suite *RadosTestSuite) TestOmapStats() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	_, _, err := (&IOContext{}).OmapStats("foo")
+	ta.Equal(ErrInvalidIOContext, err)
+
+	_, _, err = suite.ioctx.OmapStats(suite.GenObjectName())
+	ta.Equal(ErrNotFound, err)
+
+	oid := suite.GenObjectName()
+	err = suite.ioctx.Create(oid, CreateExclusive)
+	ta.NoError(err)
+	numKeys, totalBytes, err := suite.ioctx.OmapStats(oid)
+	ta.NoError(err)
+	ta.Zero(numKeys)
+	ta.Zero(totalBytes)
+
+	// more keys than fit in a single page
+	const count = 2*omapStatsPageSize + 17
+	var expectedBytes uint64
+	pairs := map[string][]byte{}
+	for i := 0; i < count; i++ {
+		key := fmt.Sprintf("key%05d", i)
+		value := []byte(fmt.Sprintf("value%d", i))
+		pairs[key] = value
+		expectedBytes += uint64(len(key) + len(value))
+	}
+	err = suite.ioctx.SetOmap(oid, pairs)
+	ta.NoError(err)
+
+	numKeys, totalBytes, err = suite.ioctx.OmapStats(oid)
+	ta.NoError(err)
+	ta.EqualValues(count, numKeys)
+	ta.Equal(expectedBytes, totalBytes)
+}
+
+func (suite *RadosTestSuite) TestReadModifyWrite() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	err := (&IOContext{}).ReadModifyWrite("foo", nil)
+	ta.Equal(ErrInvalidIOContext, err)
+
+	increment := func(old []byte) ([]byte, error) {
+		n := 0
+		if old != nil {
+			var err error
+			if n, err = strconv.Atoi(string(old)); err != nil {
+				return nil, err
+			}
+		}
+		return []byte(strconv.Itoa(n + 1)), nil
+	}
+
+	suite.T().Run("create", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		err := suite.ioctx.ReadModifyWrite(oid, func(old []byte) ([]byte, error) {
+			assert.Nil(t, old)
+			return []byte("new"), nil
+		})
+		assert.NoError(t, err)
+		buf := make([]byte, 8)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", string(buf[:n]))
+	})
+
+	suite.T().Run("modifyError", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		require.NoError(t, suite.ioctx.WriteFull(oid, []byte("keep")))
+		errFailed := errors.New("failed")
+		err := suite.ioctx.ReadModifyWrite(oid, func(old []byte) ([]byte, error) {
+			return []byte("discard"), errFailed
+		})
+		assert.Equal(t, errFailed, err)
+		buf := make([]byte, 8)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "keep", string(buf[:n]))
+	})
+
+	suite.T().Run("largeObject", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		data := suite.RandomBytes(100000)
+		require.NoError(t, suite.ioctx.WriteFull(oid, data))
+		err := suite.ioctx.ReadModifyWrite(oid, func(old []byte) ([]byte, error) {
+			assert.Equal(t, data, old)
+			return old[:10], nil
+		})
+		assert.NoError(t, err)
+		stat, err := suite.ioctx.Stat(oid)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 10, stat.Size)
+	})
+
+	suite.T().Run("concurrentCounter", func(t *testing.T) {
+		const (
+			workers   = 8
+			perWorker = 25
+		)
+		oid := suite.GenObjectName()
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*perWorker)
+		for w := 0; w < workers; w++ {
+			// each goroutine uses its own IO context
+			ioctx, err := suite.conn.OpenIOContext(suite.pool)
+			require.NoError(t, err)
+			defer ioctx.Destroy()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					errs <- ioctx.ReadModifyWrite(oid, increment)
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		buf := make([]byte, 16)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(workers*perWorker), string(buf[:n]))
+	})
+
+	suite.T().Run("sharedIOContext", func(t *testing.T) {
+		const workers = 4
+		oid := suite.GenObjectName()
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- suite.ioctx.ReadModifyWrite(oid, increment)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		buf := make([]byte, 16)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(workers), string(buf[:n]))
+	})
+
+	suite.T().Run("conflict", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		require.NoError(t, suite.ioctx.WriteFull(oid, []byte("0")))
+		calls := 0
+		err := suite.ioctx.ReadModifyWrite(oid, func(old []byte) ([]byte, error) {
+			calls++
+			// change the object behind the back of every attempt
+			if err := suite.ioctx.Append(oid, []byte("x")); err != nil {
+				return nil, err
+			}
+			return []byte("lost"), nil
+		})
+		assert.Equal(t, ErrReadModifyWriteConflict, err)
+		assert.Equal(t, readModifyWriteRetries, calls)
+	})
+}
+
+func TestRetryConflicts(t *testing.T) {
+	calls := 0
+	err := retryConflicts(func() (bool, error) {
+		calls++
+		return calls < 3, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	errFailed := errors.New("failed")
+	calls = 0
+	err = retryConflicts(func() (bool, error) {
+		calls++
+		return false, errFailed
+	})
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, 1, calls)
+}
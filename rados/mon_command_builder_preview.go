@@ -0,0 +1,84 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+// MonCommandBuilder assembles the JSON for a monitor command from a command
+// prefix and a set of named arguments, so the command does not need to be
+// formatted by hand.
+//  PREVIEW
+type MonCommandBuilder struct {
+	prefix      string
+	args        map[string]interface{}
+	inputBuffer []byte
+}
+
+// NewMonCommandBuilder returns a builder for the monitor command with the
+// given prefix, for example "osd pool get".
+//  PREVIEW
+func NewMonCommandBuilder(prefix string) *MonCommandBuilder {
+	return &MonCommandBuilder{
+		prefix: prefix,
+		args:   map[string]interface{}{},
+	}
+}
+
+// Arg sets the named argument of the command to value. The value must be
+// encodable as JSON.
+//  PREVIEW
+func (b *MonCommandBuilder) Arg(name string, value interface{}) *MonCommandBuilder {
+	b.args[name] = value
+	return b
+}
+
+// Args sets all of the named arguments in args on the command.
+//  PREVIEW
+func (b *MonCommandBuilder) Args(args map[string]interface{}) *MonCommandBuilder {
+	for k, v := range args {
+		b.args[k] = v
+	}
+	return b
+}
+
+// InputBuffer attaches a buffer of input data that is sent along with the
+// command.
+//  PREVIEW
+func (b *MonCommandBuilder) InputBuffer(buf []byte) *MonCommandBuilder {
+	b.inputBuffer = buf
+	return b
+}
+
+// Build returns the command in the form librados accepts: a list holding a
+// single JSON object with the prefix and all of the arguments. A prefix set
+// as an argument is always replaced by the builder's prefix.
+//  PREVIEW
+func (b *MonCommandBuilder) Build() ([][]byte, error) {
+	if b.prefix == "" {
+		return nil, ErrEmptyArgument
+	}
+	m := make(map[string]interface{}, len(b.args)+1)
+	for k, v := range b.args {
+		m[k] = v
+	}
+	m["prefix"] = b.prefix
+	cmd, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{cmd}, nil
+}
+
+// Exec builds the command and sends it, with the input buffer if one is
+// attached, to one of the monitors of the connected cluster.
+//  PREVIEW
+func (b *MonCommandBuilder) Exec(c *Conn) ([]byte, string, error) {
+	cmds, err := b.Build()
+	if err != nil {
+		return nil, "", err
+	}
+	return c.MonCommandWithInputBuffer(cmds[0], b.inputBuffer)
+}
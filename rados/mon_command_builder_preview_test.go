@@ -0,0 +1,100 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonCommandBuilderBuild(t *testing.T) {
+	t.Run("osdPoolGet", func(t *testing.T) {
+		cmds, err := NewMonCommandBuilder("osd pool get").
+			Arg("pool", "mypool").
+			Arg("var", "size").
+			Arg("format", "json").
+			Build()
+		assert.NoError(t, err)
+		if assert.Len(t, cmds, 1) {
+			assert.Equal(t,
+				`{"format":"json","pool":"mypool","prefix":"osd pool get","var":"size"}`,
+				string(cmds[0]))
+		}
+	})
+
+	t.Run("args", func(t *testing.T) {
+		cmds, err := NewMonCommandBuilder("osd pool set").
+			Args(map[string]interface{}{
+				"pool":   "mypool",
+				"var":    "size",
+				"val":    "2",
+				"prefix": "ignored",
+			}).
+			Arg("yes_i_really_mean_it", true).
+			Build()
+		assert.NoError(t, err)
+		if assert.Len(t, cmds, 1) {
+			assert.Equal(t,
+				`{"pool":"mypool","prefix":"osd pool set","val":"2","var":"size","yes_i_really_mean_it":true}`,
+				string(cmds[0]))
+		}
+	})
+
+	t.Run("emptyPrefix", func(t *testing.T) {
+		_, err := NewMonCommandBuilder("").Build()
+		assert.Equal(t, ErrEmptyArgument, err)
+	})
+
+	t.Run("unencodableArg", func(t *testing.T) {
+		_, err := NewMonCommandBuilder("df").Arg("bad", func() {}).Build()
+		assert.Error(t, err)
+	})
+}
+
+func (suite *RadosTestSuite) TestMonCommandBuilderExec() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	suite.T().Run("osdPoolGet", func(t *testing.T) {
+		buf, _, err := NewMonCommandBuilder("osd pool get").
+			Arg("pool", suite.pool).
+			Arg("var", "size").
+			Arg("format", "json").
+			Exec(suite.conn)
+		ta.NoError(err)
+
+		var result struct {
+			Pool string `json:"pool"`
+			Size int    `json:"size"`
+		}
+		ta.NoError(json.Unmarshal(buf, &result))
+		ta.Equal(suite.pool, result.Pool)
+		ta.True(result.Size > 0)
+	})
+
+	suite.T().Run("inputBuffer", func(t *testing.T) {
+		entity := fmt.Sprintf("client.testMonCmdBuilder%d", time.Now().UnixNano())
+		_, info, err := NewMonCommandBuilder("auth add").
+			Arg("entity", entity).
+			Arg("format", "json").
+			InputBuffer([]byte(fmt.Sprintf(clientKeyFormat, entity))).
+			Exec(suite.conn)
+		ta.NoError(err)
+		ta.Equal(fmt.Sprintf("added key for %s", entity), info)
+
+		_, _, err = NewMonCommandBuilder("auth del").
+			Arg("entity", entity).
+			Exec(suite.conn)
+		ta.NoError(err)
+	})
+
+	suite.T().Run("unknownCommand", func(t *testing.T) {
+		_, _, err := NewMonCommandBuilder("no such command").Exec(suite.conn)
+		ta.Error(err)
+	})
+}
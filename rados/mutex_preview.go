@@ -0,0 +1,238 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMutexLease is the lease duration of a Mutex unless changed
+	// with SetLease.
+	defaultMutexLease = 30 * time.Second
+
+	// minMutexRetryInterval and maxMutexRetryInterval limit how long Lock
+	// waits between attempts to take a lock held by another client.
+	minMutexRetryInterval = 10 * time.Millisecond
+	maxMutexRetryInterval = time.Second
+
+	mutexDescription = "go-ceph mutex"
+)
+
+var (
+	// ErrMutexLocked is returned by Mutex.Lock when the Mutex is already
+	// held by the caller.
+	ErrMutexLocked = errors.New("mutex is already locked")
+	// ErrMutexNotLocked is returned by Mutex.Unlock when the Mutex is not
+	// held by the caller.
+	ErrMutexNotLocked = errors.New("mutex is not locked")
+	// ErrMutexLockPending is returned by Mutex.Lock when another call to
+	// Lock on the same Mutex is still waiting for the lock.
+	ErrMutexLockPending = errors.New("mutex is already being locked")
+	// ErrInvalidMutexLease is returned by Mutex.SetLease when the lease is
+	// not positive.
+	ErrInvalidMutexLease = errors.New("mutex lease must be positive")
+)
+
+// Mutex is a distributed mutual exclusion lock based on an exclusive
+// advisory lock on a rados object. While the Mutex is held the lock is
+// taken with a limited lease that is renewed in the background, so that
+// the lock is released by the cluster if the holder goes away without
+// unlocking it.
+type Mutex struct {
+	ioctx  *IOContext
+	oid    string
+	name   string
+	cookie string
+	lease  time.Duration
+
+	lock      sync.Mutex
+	acquiring bool
+	stop      chan struct{}
+	done      chan struct{}
+	err       error
+}
+
+// NewMutex returns a new Mutex using the lock with the given name on the
+// object with key oid. The object is created if it does not exist when the
+// Mutex is locked.
+//  PREVIEW
+func (ioctx *IOContext) NewMutex(oid, name string) *Mutex {
+	return &Mutex{
+		ioctx:  ioctx,
+		oid:    oid,
+		name:   name,
+		cookie: newMutexCookie(),
+		lease:  defaultMutexLease,
+	}
+}
+
+func newMutexCookie() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format(time.RFC3339Nano)
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetLease sets the duration of the lease the lock is taken with. The lease
+// is renewed when half of it has passed. The new lease takes effect the next
+// time the Mutex is locked. ErrInvalidMutexLease is returned if the lease
+// is not positive.
+//  PREVIEW
+func (m *Mutex) SetLease(d time.Duration) error {
+	if d <= 0 {
+		return ErrInvalidMutexLease
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lease = d
+	return nil
+}
+
+// Lock takes the lock, waiting for it to become available if it is held by
+// another client. If the context is done before the lock is taken the error
+// of the context is returned. ErrMutexLocked is returned if the Mutex is
+// already held and ErrMutexLockPending if another call to Lock is still
+// waiting for it.
+//  PREVIEW
+func (m *Mutex) Lock(ctx context.Context) error {
+	return m.acquire(ctx, nil)
+}
+
+// acquire takes the lock like Lock. While the lock is held by another
+// client a new attempt is made after a short interval or as soon as a value
+// is received from wakeup, which may be nil. m.lock is not held while
+// waiting so that the other methods of the Mutex do not block meanwhile.
+func (m *Mutex) acquire(ctx context.Context, wakeup <-chan NotifyEvent) error {
+	if err := m.ioctx.validate(); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	if m.stop != nil {
+		m.lock.Unlock()
+		return ErrMutexLocked
+	}
+	if m.acquiring {
+		m.lock.Unlock()
+		return ErrMutexLockPending
+	}
+	m.acquiring = true
+	lease := m.lease
+	m.lock.Unlock()
+
+	err := m.tryLock(ctx, wakeup, lease)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.acquiring = false
+	if err != nil {
+		return err
+	}
+	m.err = nil
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.renew(lease, m.stop, m.done)
+	return nil
+}
+
+// tryLock repeatedly attempts to take the lock with the given lease until
+// it succeeds, fails with an error other than EBUSY or the context is done.
+func (m *Mutex) tryLock(
+	ctx context.Context, wakeup <-chan NotifyEvent, lease time.Duration) error {
+
+	interval := lease / 10
+	if interval < minMutexRetryInterval {
+		interval = minMutexRetryInterval
+	}
+	if interval > maxMutexRetryInterval {
+		interval = maxMutexRetryInterval
+	}
+	for {
+		res, err := m.ioctx.LockExclusive(
+			m.oid, m.name, m.cookie, mutexDescription, lease, nil)
+		if err != nil {
+			return err
+		}
+		if res == 0 {
+			return nil
+		}
+		if res != -C.EBUSY {
+			return getError(C.int(res))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		case <-wakeup:
+		}
+	}
+}
+
+// renew extends the lease of the held lock until stop is closed.
+func (m *Mutex) renew(lease time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	flags := byte(C.LIBRADOS_LOCK_FLAG_RENEW)
+	ticker := time.NewTicker(lease / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		res, err := m.ioctx.LockExclusive(
+			m.oid, m.name, m.cookie, mutexDescription, lease, &flags)
+		if err == nil && res != 0 {
+			err = getError(C.int(res))
+		}
+		if err != nil {
+			m.lock.Lock()
+			m.err = err
+			m.lock.Unlock()
+			return
+		}
+	}
+}
+
+// Err returns the error that stopped the renewal of the lease, if any. After
+// such an error the lock may be lost once the current lease expires.
+//  PREVIEW
+func (m *Mutex) Err() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.err
+}
+
+// Unlock stops the renewal of the lease and releases the lock.
+//  PREVIEW
+func (m *Mutex) Unlock() error {
+	m.lock.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.lock.Unlock()
+	if stop == nil {
+		return ErrMutexNotLocked
+	}
+	close(stop)
+	<-done
+
+	res, err := m.ioctx.Unlock(m.oid, m.name, m.cookie)
+	if err != nil {
+		return err
+	}
+	if res != 0 {
+		return getError(C.int(res))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestMutex() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	m := suite.ioctx.NewMutex(oid, "myMutex")
+	require.NoError(suite.T(), m.SetLease(2*time.Second))
+
+	err := m.Lock(context.Background())
+	require.NoError(suite.T(), err)
+	ta.Equal(ErrMutexLocked, m.Lock(context.Background()))
+
+	// the lease is renewed beyond its original duration
+	time.Sleep(5 * time.Second)
+	info, err := suite.ioctx.ListLockers(oid, "myMutex")
+	ta.NoError(err)
+	ta.Equal(1, info.NumLockers)
+	ta.True(info.Exclusive)
+	ta.NoError(m.Err())
+
+	// a second mutex on the same lock has to wait
+	m2 := suite.ioctx.NewMutex(oid, "myMutex")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = m2.Lock(ctx)
+	ta.Equal(context.DeadlineExceeded, err)
+	ta.Equal(ErrMutexNotLocked, m2.Unlock())
+
+	err = m.Unlock()
+	ta.NoError(err)
+	ta.Equal(ErrMutexNotLocked, m.Unlock())
+
+	info, err = suite.ioctx.ListLockers(oid, "myMutex")
+	ta.NoError(err)
+	ta.Equal(0, info.NumLockers)
+
+	// the released lock can be taken by others
+	err = m2.Lock(context.Background())
+	ta.NoError(err)
+	ta.NoError(m2.Unlock())
+
+	// renewal stopped with Unlock: a lock taken without renewal expires
+	// and does not come back
+	ta.NoError(m.SetLease(time.Second))
+	ta.NoError(m.Lock(context.Background()))
+	ta.NoError(m.Unlock())
+	time.Sleep(2 * time.Second)
+	info, err = suite.ioctx.ListLockers(oid, "myMutex")
+	ta.NoError(err)
+	ta.Equal(0, info.NumLockers)
+}
+
+func (suite *RadosTestSuite) TestMutexUnlockStopsRenewal() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	m := suite.ioctx.NewMutex(oid, "myMutex")
+	require.NoError(suite.T(), m.SetLease(400*time.Millisecond))
+	require.NoError(suite.T(), m.Lock(context.Background()))
+
+	m.lock.Lock()
+	done := m.done
+	m.lock.Unlock()
+	ta.NoError(m.Unlock())
+
+	// the renew goroutine has exited by the time Unlock returns
+	select {
+	case <-done:
+	default:
+		suite.T().Fatal("renew goroutine still running after Unlock")
+	}
+
+	// several renewal intervals later the lock has not been taken again
+	time.Sleep(time.Second)
+	info, err := suite.ioctx.ListLockers(oid, "myMutex")
+	ta.NoError(err)
+	ta.Equal(0, info.NumLockers)
+	ta.NoError(m.Err())
+}
+
+func (suite *RadosTestSuite) TestMutexPendingLock() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	holder := suite.ioctx.NewMutex(oid, "myMutex")
+	require.NoError(suite.T(), holder.Lock(context.Background()))
+	defer func() { ta.NoError(holder.Unlock()) }()
+
+	m := suite.ioctx.NewMutex(oid, "myMutex")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := make(chan error, 1)
+	go func() { result <- m.Lock(ctx) }()
+
+	// wait for the Lock call above to be in its retry loop
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.lock.Lock()
+		pending := m.acquiring
+		m.lock.Unlock()
+		if pending || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the other methods do not block while Lock is waiting
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		ta.Equal(ErrMutexNotLocked, m.Unlock())
+		ta.NoError(m.SetLease(time.Minute))
+		ta.NoError(m.Err())
+		ta.Equal(ErrMutexLockPending, m.Lock(context.Background()))
+	}()
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		suite.T().Fatal("Mutex methods blocked by a pending Lock")
+	}
+
+	cancel()
+	ta.Equal(context.Canceled, <-result)
+}
+
+func (suite *RadosTestSuite) TestMutexInvalidLease() {
+	m := (&IOContext{}).NewMutex("foo", "bar")
+	assert.Equal(suite.T(), ErrInvalidMutexLease, m.SetLease(0))
+	assert.Equal(suite.T(), ErrInvalidMutexLease, m.SetLease(-time.Second))
+	assert.NoError(suite.T(), m.SetLease(time.Nanosecond))
+}
+
+func (suite *RadosTestSuite) TestMutexInvalidIOContext() {
+	ioctx := &IOContext{}
+	m := ioctx.NewMutex("foo", "bar")
+	err := m.Lock(context.Background())
+	assert.Equal(suite.T(), ErrInvalidIOContext, err)
+}
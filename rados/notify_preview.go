@@ -0,0 +1,53 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+// Notify sends a notification with the given data to all watchers of the
+// object with key oid and waits until all of them acknowledged it or the
+// timeout expired. A zero timeout uses the default timeout of the cluster.
+//  PREVIEW
+//
+// Implements:
+//  int rados_notify2(rados_ioctx_t io, const char *o, const char *buf,
+//                    int buf_len, uint64_t timeout_ms, char **reply_buffer,
+//                    size_t *reply_buffer_len);
+func (ioctx *IOContext) Notify(oid string, data []byte, timeout time.Duration) error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	coid := C.CString(oid)
+	defer C.free(unsafe.Pointer(coid))
+
+	var cData *C.char
+	if len(data) > 0 {
+		cData = (*C.char)(unsafe.Pointer(&data[0]))
+	}
+	var (
+		reply    *C.char
+		replyLen C.size_t
+	)
+	ret := C.rados_notify2(
+		ioctx.ioctx,
+		coid,
+		cData,
+		C.int(len(data)),
+		C.uint64_t(timeout/time.Millisecond),
+		&reply,
+		&replyLen)
+	if reply != nil {
+		C.rados_buffer_free(reply)
+	}
+	return getError(ret)
+}
@@ -0,0 +1,49 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// parseObjectClassList splits the value of the osd_class_load_list option
+// into sorted class names.
+func parseObjectClassList(value string) []string {
+	names := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t' || r == '\n'
+	})
+	sort.Strings(names)
+	return names
+}
+
+// ListConfiguredObjectClasses returns the names of the object classes the
+// OSDs are configured to load, as set by the osd_class_load_list option in
+// the configuration database of the monitors. A single "*" is returned if
+// the OSDs may load any class found in their class directory.
+//
+// These are not necessarily the classes that are loaded. The OSDs load
+// classes on their first use and neither librados nor the OSDs provide a way
+// to list the loaded classes. Whether a class is actually available on the
+// OSDs serving an object can be checked by calling one of its methods.
+//  PREVIEW
+func (c *Conn) ListConfiguredObjectClasses() ([]string, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "config get",
+		"who":    "osd",
+		"key":    "osd_class_load_list",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseObjectClassList(string(buf)), nil
+}
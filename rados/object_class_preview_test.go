@@ -0,0 +1,39 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseObjectClassList(t *testing.T) {
+	assert.Equal(t,
+		[]string{"hello", "lock", "rbd"},
+		parseObjectClassList("rbd hello  lock\n"))
+	assert.Equal(t,
+		[]string{"hello", "lock"},
+		parseObjectClassList("lock,hello"))
+	assert.Equal(t, []string{"*"}, parseObjectClassList("*\n"))
+	assert.Len(t, parseObjectClassList(""), 0)
+}
+
+func (suite *RadosTestSuite) TestListConfiguredObjectClasses() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	ta.NoError(err)
+	_, err = conn.ListConfiguredObjectClasses()
+	ta.Equal(ErrNotConnected, err)
+
+	names, err := suite.conn.ListConfiguredObjectClasses()
+	ta.NoError(err)
+	if len(names) == 1 && names[0] == "*" {
+		suite.T().Skip("the OSDs may load any class")
+	}
+	ta.Contains(names, "hello")
+	ta.Contains(names, "lock")
+}
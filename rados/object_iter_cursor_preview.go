@@ -0,0 +1,203 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errInvalidIterCursor = errors.New("invalid iterator cursor")
+
+const iterCursorVersion = 1
+
+// IterCursor marks the position of an Iter at the last entry it returned.
+// Unlike an IterToken, which only identifies a placement group, a cursor
+// refers to an exact position in the listing order of the pool, so listing
+// can be resumed where it was left off even if objects were added or
+// removed in the meantime. A cursor holds memory allocated by librados and
+// must be freed with Free once it is no longer needed.
+//
+// The librados C API does not provide a way to serialize its cursors, so
+// MarshalBinary encodes the placement group being listed and the name of the
+// object at the cursor instead. A cursor decoded by UnmarshalBinary, for
+// example in a different process, resumes listing after that object. If the
+// object was removed in the meantime the other objects of its placement
+// group may be listed again, but none are skipped.
+type IterCursor struct {
+	ioctx  *IOContext
+	cursor C.rados_object_list_cursor
+	// position is the placement group being listed at the cursor.
+	position IterToken
+	// entry and namespace name the object at the cursor, which was already
+	// returned by the iterator the cursor was taken from.
+	entry     string
+	namespace string
+	// decoded is set for cursors created by UnmarshalBinary.
+	decoded bool
+}
+
+// GetCursor returns a cursor marking the current position of the iterator.
+// The IO context must be the one the iterator was created from.
+//  PREVIEW
+//
+// Implements:
+//  int rados_nobjects_list_get_cursor(rados_list_ctx_t ctx,
+//                                     rados_object_list_cursor *cursor);
+func (iter *Iter) GetCursor(ioctx *IOContext) (*IterCursor, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	cursor := &IterCursor{ioctx: ioctx, position: iter.Token()}
+	ret := C.rados_nobjects_list_get_cursor(iter.ctx, &cursor.cursor)
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	// until Next is called again the cursor points at the entry Next
+	// returned last, if any
+	if iter.err == nil {
+		cursor.entry = iter.entry
+		cursor.namespace = iter.namespace
+	}
+	return cursor, nil
+}
+
+// Free releases the memory held by the cursor.
+//  PREVIEW
+//
+// Implements:
+//  void rados_object_list_cursor_free(rados_ioctx_t io,
+//                                     rados_object_list_cursor c);
+func (cursor *IterCursor) Free() {
+	if cursor.cursor == nil {
+		return
+	}
+	C.rados_object_list_cursor_free(cursor.ioctx.ioctx, cursor.cursor)
+	cursor.cursor = nil
+}
+
+// IterateFromCursor returns an Iter that resumes listing the objects of
+// the IO context after the position marked by the cursor.
+//  PREVIEW
+//
+// Implements:
+//  int rados_nobjects_list_open(rados_ioctx_t io, rados_list_ctx_t *ctx);
+//  uint32_t rados_nobjects_list_seek_cursor(rados_list_ctx_t ctx,
+//                                           rados_object_list_cursor cursor);
+func (ioctx *IOContext) IterateFromCursor(cursor *IterCursor) (*Iter, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	if cursor == nil || (cursor.cursor == nil && !cursor.decoded) {
+		return nil, errInvalidIterCursor
+	}
+	iter, err := ioctx.Iter()
+	if err != nil {
+		return nil, err
+	}
+	if cursor.decoded {
+		return resumeAt(iter, cursor)
+	}
+	C.rados_nobjects_list_seek_cursor(iter.ctx, cursor.cursor)
+	if cursor.entry == "" {
+		return iter, nil
+	}
+
+	// skip the entry at the cursor, which was returned before, unless it
+	// went away in the meantime
+	if !iter.Next() {
+		if err := iter.Err(); err != nil {
+			iter.Close()
+			return nil, err
+		}
+		return iter, nil
+	}
+	if iter.entry != cursor.entry || iter.namespace != cursor.namespace {
+		C.rados_nobjects_list_seek_cursor(iter.ctx, cursor.cursor)
+	}
+	return iter, nil
+}
+
+// resumeAt positions iter, created from a new list context, after the
+// object named by a decoded cursor. The objects of the placement group of
+// the cursor are skipped up to that object. If the object is not found the
+// iterator is put back to the start of the placement group.
+func resumeAt(iter *Iter, cursor *IterCursor) (*Iter, error) {
+	iter.Seek(cursor.position)
+	if cursor.entry == "" {
+		return iter, nil
+	}
+	for iter.Next() {
+		if iter.Token() != cursor.position {
+			break
+		}
+		if iter.entry == cursor.entry && iter.namespace == cursor.namespace {
+			return iter, nil
+		}
+	}
+	if err := iter.Err(); err != nil {
+		iter.Close()
+		return nil, err
+	}
+	iter.err = nil
+	iter.Seek(cursor.position)
+	return iter, nil
+}
+
+// MarshalBinary encodes the cursor into a byte slice that can be decoded by
+// UnmarshalBinary.
+//  PREVIEW
+func (cursor *IterCursor) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 1+4, 1+4+4+len(cursor.namespace)+4+len(cursor.entry))
+	b[0] = iterCursorVersion
+	binary.LittleEndian.PutUint32(b[1:], uint32(cursor.position))
+	for _, s := range []string{cursor.namespace, cursor.entry} {
+		var n [4]byte
+		binary.LittleEndian.PutUint32(n[:], uint32(len(s)))
+		b = append(b, n[:]...)
+		b = append(b, s...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a cursor encoded by MarshalBinary. A decoded
+// cursor holds no memory allocated by librados, but calling Free on it is
+// harmless.
+//  PREVIEW
+func (cursor *IterCursor) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+4 || data[0] != iterCursorVersion {
+		return errInvalidIterCursor
+	}
+	position := IterToken(binary.LittleEndian.Uint32(data[1:]))
+	data = data[1+4:]
+	var fields [2]string
+	for i := range fields {
+		if len(data) < 4 {
+			return errInvalidIterCursor
+		}
+		n := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) < uint64(n) {
+			return errInvalidIterCursor
+		}
+		fields[i] = string(data[:n])
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return errInvalidIterCursor
+	}
+	cursor.Free()
+	*cursor = IterCursor{
+		position:  position,
+		namespace: fields[0],
+		entry:     fields[1],
+		decoded:   true,
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestIterateFromCursor() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(suite.T(), suite.conn.MakePool(pool))
+	defer suite.conn.DeletePool(pool)
+	ioctx, err := suite.conn.OpenIOContext(pool)
+	require.NoError(suite.T(), err)
+	defer ioctx.Destroy()
+
+	expected := []string{}
+	for i := 0; i < 100; i++ {
+		oid := fmt.Sprintf("obj%03d", i)
+		require.NoError(suite.T(), ioctx.Create(oid, CreateExclusive))
+		expected = append(expected, oid)
+	}
+
+	iter, err := ioctx.Iter()
+	require.NoError(suite.T(), err)
+	listed := []string{}
+	for len(listed) < 50 && iter.Next() {
+		listed = append(listed, iter.Value())
+	}
+	ta.NoError(iter.Err())
+	cursor, err := iter.GetCursor(ioctx)
+	require.NoError(suite.T(), err)
+	defer cursor.Free()
+	iter.Close()
+	require.Len(suite.T(), listed, 50)
+
+	// objects written after the cursor was taken do not disturb the
+	// position of the cursor
+	added := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		oid := fmt.Sprintf("new%03d", i)
+		require.NoError(suite.T(), ioctx.Create(oid, CreateExclusive))
+		added[oid] = true
+	}
+
+	iter, err = ioctx.IterateFromCursor(cursor)
+	require.NoError(suite.T(), err)
+	for iter.Next() {
+		if !added[iter.Value()] {
+			listed = append(listed, iter.Value())
+		}
+	}
+	ta.NoError(iter.Err())
+	iter.Close()
+
+	// every original object was listed exactly once
+	sort.Strings(listed)
+	ta.Equal(expected, listed)
+
+	suite.T().Run("serialized", func(t *testing.T) {
+		all := append([]string{}, expected...)
+		for oid := range added {
+			all = append(all, oid)
+		}
+		sort.Strings(all)
+
+		iter, err := ioctx.Iter()
+		require.NoError(t, err)
+		listed := []string{}
+		for len(listed) < 60 && iter.Next() {
+			listed = append(listed, iter.Value())
+		}
+		cursor, err := iter.GetCursor(ioctx)
+		require.NoError(t, err)
+		iter.Close()
+		data, err := cursor.MarshalBinary()
+		assert.NoError(t, err)
+		cursor.Free()
+
+		// resume as a different process would, from the encoded cursor and
+		// with a new IO context
+		ioctx2, err := suite.conn.OpenIOContext(pool)
+		require.NoError(t, err)
+		defer ioctx2.Destroy()
+		var restored IterCursor
+		require.NoError(t, restored.UnmarshalBinary(data))
+		iter, err = ioctx2.IterateFromCursor(&restored)
+		require.NoError(t, err)
+		defer iter.Close()
+		for iter.Next() {
+			listed = append(listed, iter.Value())
+		}
+		assert.NoError(t, iter.Err())
+		sort.Strings(listed)
+		assert.Equal(t, all, listed)
+	})
+
+	suite.T().Run("start", func(t *testing.T) {
+		iter, err := ioctx.Iter()
+		require.NoError(t, err)
+		cursor, err := iter.GetCursor(ioctx)
+		require.NoError(t, err)
+		defer cursor.Free()
+		iter.Close()
+
+		iter, err = ioctx.IterateFromCursor(cursor)
+		require.NoError(t, err)
+		defer iter.Close()
+		count := 0
+		for iter.Next() {
+			count++
+		}
+		assert.NoError(t, iter.Err())
+		assert.Equal(t, 120, count)
+	})
+
+	suite.T().Run("end", func(t *testing.T) {
+		iter, err := ioctx.Iter()
+		require.NoError(t, err)
+		count := 0
+		for iter.Next() {
+			count++
+		}
+		cursor, err := iter.GetCursor(ioctx)
+		require.NoError(t, err)
+		defer cursor.Free()
+		iter.Close()
+		assert.Equal(t, 120, count)
+
+		iter, err = ioctx.IterateFromCursor(cursor)
+		require.NoError(t, err)
+		defer iter.Close()
+		assert.False(t, iter.Next())
+		assert.NoError(t, iter.Err())
+	})
+
+	suite.T().Run("invalid", func(t *testing.T) {
+		_, err := ioctx.IterateFromCursor(nil)
+		assert.Error(t, err)
+		_, err = ioctx.IterateFromCursor(&IterCursor{})
+		assert.Error(t, err)
+
+		iter, err := ioctx.Iter()
+		require.NoError(t, err)
+		defer iter.Close()
+		_, err = iter.GetCursor(&IOContext{})
+		assert.Equal(t, ErrInvalidIOContext, err)
+		_, err = (&IOContext{}).IterateFromCursor(&IterCursor{})
+		assert.Equal(t, ErrInvalidIOContext, err)
+	})
+}
+
+func TestIterCursorBinary(t *testing.T) {
+	cursor := &IterCursor{position: 7, namespace: "ns", entry: "obj"}
+	data, err := cursor.MarshalBinary()
+	assert.NoError(t, err)
+
+	var decoded IterCursor
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, IterCursor{
+		position:  7,
+		namespace: "ns",
+		entry:     "obj",
+		decoded:   true,
+	}, decoded)
+
+	for _, b := range [][]byte{
+		nil,
+		{0, 7, 0, 0, 0},
+		data[:len(data)-1],
+		append(append([]byte{}, data...), 0),
+	} {
+		assert.Equal(t, errInvalidIterCursor, decoded.UnmarshalBinary(b))
+	}
+}
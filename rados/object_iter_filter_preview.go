@@ -0,0 +1,213 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+const (
+	// PlainObjectListFilter is the name of the filter built into the OSD
+	// that matches objects having an xattr with a given value.
+	PlainObjectListFilter = "plain"
+
+	filterIterBatchSize = 1024
+)
+
+// EncodeObjectListFilter returns a filter buffer that can be passed to
+// IOContext.FilterIter. The buffer is encoded the way the OSD expects a PG
+// listing filter: the filter type followed by the filter arguments, each
+// encoded as a Ceph string (a little-endian 32-bit length followed by the
+// bytes of the string).
+//
+// The filter type is either PlainObjectListFilter or the name of a filter
+// registered by an object class in the form "<class>.<filter>". The plain
+// filter takes two arguments, the name of the xattr and the value it must
+// have. Note that the OSD stores user xattrs, such as those set by
+// IOContext.SetXattr, with an underscore prefix so the xattr name passed
+// to the plain filter must include it. The arguments to an object class
+// filter are defined by the object class.
+//  PREVIEW
+func EncodeObjectListFilter(filterType string, args ...string) []byte {
+	size := 4 + len(filterType)
+	for _, a := range args {
+		size += 4 + len(a)
+	}
+	buf := make([]byte, 0, size)
+	for _, s := range append([]string{filterType}, args...) {
+		var l [4]byte
+		binary.LittleEndian.PutUint32(l[:], uint32(len(s)))
+		buf = append(buf, l[:]...)
+		buf = append(buf, s...)
+	}
+	return buf
+}
+
+// FilterIter supports iterating over the objects in the ioctx that match
+// a server side filter.
+type FilterIter struct {
+	ioctx  *IOContext
+	filter []byte
+	cursor C.rados_object_list_cursor
+	next   C.rados_object_list_cursor
+	end    C.rados_object_list_cursor
+
+	items     []C.rados_object_list_item
+	entries   []filterIterEntry
+	err       error
+	entry     string
+	namespace string
+}
+
+type filterIterEntry struct {
+	oid       string
+	namespace string
+}
+
+// FilterIter returns an iterator that can be used to list the names of the
+// objects in the current pool that match the given filter. The filter is
+// evaluated by the OSDs so that only matching object names are sent to the
+// client. The filter buffer is typically created with
+// EncodeObjectListFilter.
+//  PREVIEW
+//
+// Implements:
+//  rados_object_list_cursor rados_object_list_begin(rados_ioctx_t io);
+//  rados_object_list_cursor rados_object_list_end(rados_ioctx_t io);
+func (ioctx *IOContext) FilterIter(filter []byte) (*FilterIter, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	if len(filter) == 0 {
+		return nil, ErrEmptyArgument
+	}
+	iter := &FilterIter{
+		ioctx:  ioctx,
+		filter: filter,
+		cursor: C.rados_object_list_begin(ioctx.ioctx),
+		next:   C.rados_object_list_begin(ioctx.ioctx),
+		end:    C.rados_object_list_end(ioctx.ioctx),
+		items:  make([]C.rados_object_list_item, filterIterBatchSize),
+	}
+	return iter, nil
+}
+
+// fetch retrieves the next batch of matching objects. Batches may be empty
+// when none of the objects in a range of placement groups match the filter.
+//
+// Implements:
+//  int rados_object_list(rados_ioctx_t io,
+//                        const rados_object_list_cursor start,
+//                        const rados_object_list_cursor finish,
+//                        const size_t result_size,
+//                        const char *filter_buf,
+//                        const size_t filter_buf_len,
+//                        rados_object_list_item *results,
+//                        rados_object_list_cursor *next);
+//  void rados_object_list_free(const size_t result_size,
+//                              rados_object_list_item *results);
+func (iter *FilterIter) fetch() error {
+	ret := C.rados_object_list(
+		iter.ioctx.ioctx,
+		iter.cursor,
+		iter.end,
+		C.size_t(len(iter.items)),
+		(*C.char)(unsafe.Pointer(&iter.filter[0])),
+		C.size_t(len(iter.filter)),
+		&iter.items[0],
+		&iter.next)
+	if ret < 0 {
+		return getError(ret)
+	}
+	for i := 0; i < int(ret); i++ {
+		item := &iter.items[i]
+		iter.entries = append(iter.entries, filterIterEntry{
+			oid:       C.GoStringN(item.oid, C.int(item.oid_length)),
+			namespace: C.GoStringN(item.nspace, C.int(item.nspace_length)),
+		})
+	}
+	C.rados_object_list_free(C.size_t(ret), &iter.items[0])
+	iter.cursor, iter.next = iter.next, iter.cursor
+	return nil
+}
+
+// Next retrieves the next matching object name in the pool/namespace
+// iterator. Upon a successful invocation (return value of true), the Value
+// method should be used to obtain the name of the retrieved object name.
+// When the iterator is exhausted, Next returns false. The Err method should
+// be used to verify whether the end of the iterator was reached, or the
+// iterator received an error.
+//  PREVIEW
+//
+// Implements:
+//  int rados_object_list_is_end(rados_ioctx_t io,
+//                               rados_object_list_cursor cur);
+func (iter *FilterIter) Next() bool {
+	if iter.err != nil {
+		return false
+	}
+	for len(iter.entries) == 0 {
+		if C.rados_object_list_is_end(iter.ioctx.ioctx, iter.cursor) != 0 {
+			return false
+		}
+		if err := iter.fetch(); err != nil {
+			iter.err = err
+			return false
+		}
+	}
+	iter.entry = iter.entries[0].oid
+	iter.namespace = iter.entries[0].namespace
+	iter.entries = iter.entries[1:]
+	return true
+}
+
+// Value returns the current value of the iterator (object name), after a
+// successful call to Next.
+//  PREVIEW
+func (iter *FilterIter) Value() string {
+	if iter.err != nil {
+		return ""
+	}
+	return iter.entry
+}
+
+// Namespace returns the namespace associated with the current value of the
+// iterator (object name), after a successful call to Next.
+//  PREVIEW
+func (iter *FilterIter) Namespace() string {
+	if iter.err != nil {
+		return ""
+	}
+	return iter.namespace
+}
+
+// Err checks whether the iterator has encountered an error.
+//  PREVIEW
+func (iter *FilterIter) Err() error {
+	return iter.err
+}
+
+// Close releases the resources held by the iterator. Be aware that
+// iterators are not closed automatically at the end of iteration.
+//  PREVIEW
+//
+// Implements:
+//  void rados_object_list_cursor_free(rados_ioctx_t io,
+//                                     rados_object_list_cursor c);
+func (iter *FilterIter) Close() {
+	for _, c := range []*C.rados_object_list_cursor{
+		&iter.cursor, &iter.next, &iter.end} {
+		if *c != nil {
+			C.rados_object_list_cursor_free(iter.ioctx.ioctx, *c)
+			*c = nil
+		}
+	}
+	iter.entries = nil
+}
@@ -0,0 +1,83 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestEncodeObjectListFilter() {
+	buf := EncodeObjectListFilter(PlainObjectListFilter, "_xa", "v")
+	assert.Equal(suite.T(), []byte(
+		"\x05\x00\x00\x00plain"+
+			"\x03\x00\x00\x00_xa"+
+			"\x01\x00\x00\x00v"), buf)
+
+	buf = EncodeObjectListFilter("hello.hello")
+	assert.Equal(suite.T(), []byte("\x0b\x00\x00\x00hello.hello"), buf)
+}
+
+func (suite *RadosTestSuite) TestFilterIter() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	// tests use a shared pool so use a namespace unique to this test
+	suite.ioctx.SetNamespace("filterIter")
+	defer suite.ioctx.SetNamespace("")
+
+	matching := []string{}
+	for i := 0; i < 20; i++ {
+		oid := suite.GenObjectName()
+		err := suite.ioctx.WriteFull(oid, []byte("input data"))
+		require.NoError(suite.T(), err)
+		value := []byte("other")
+		if i%2 == 0 {
+			value = []byte("match")
+			matching = append(matching, oid)
+		}
+		err = suite.ioctx.SetXattr(oid, "filterkey", value)
+		require.NoError(suite.T(), err)
+	}
+
+	filter := EncodeObjectListFilter(PlainObjectListFilter, "_filterkey", "match")
+	iter, err := suite.ioctx.FilterIter(filter)
+	require.NoError(suite.T(), err)
+	defer iter.Close()
+
+	found := []string{}
+	for iter.Next() {
+		ta.Equal("filterIter", iter.Namespace())
+		found = append(found, iter.Value())
+	}
+	ta.NoError(iter.Err())
+	ta.False(iter.Next())
+
+	sort.Strings(matching)
+	sort.Strings(found)
+	ta.Equal(matching, found)
+
+	suite.T().Run("emptyFilter", func(t *testing.T) {
+		_, err := suite.ioctx.FilterIter(nil)
+		assert.Equal(t, ErrEmptyArgument, err)
+	})
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		ioctx := &IOContext{}
+		_, err := ioctx.FilterIter(filter)
+		assert.Equal(t, ErrInvalidIOContext, err)
+	})
+
+	suite.T().Run("unknownFilter", func(t *testing.T) {
+		iter, err := suite.ioctx.FilterIter(
+			EncodeObjectListFilter("nosuchclass.nosuchfilter"))
+		require.NoError(t, err)
+		defer iter.Close()
+		assert.False(t, iter.Next())
+		assert.Error(t, iter.Err())
+	})
+}
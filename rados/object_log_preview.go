@@ -0,0 +1,151 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #include <errno.h>
+import "C"
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+)
+
+const (
+	errCanceled = radosError(-C.ECANCELED)
+	errNoData   = radosError(-C.ENODATA)
+
+	// objectLogSizeXattr records the size of the log, it guards appends
+	// against concurrent writers.
+	objectLogSizeXattr = "go-ceph.log.size"
+)
+
+// ObjectLogHeaderSize is the size of the length prefix that precedes every
+// entry of an ObjectLog. The entry following an entry of length n at offset
+// o starts at o + ObjectLogHeaderSize + n.
+const ObjectLogHeaderSize = 4
+
+// ObjectLog is an append-only log of entries stored in a single object. Each
+// entry is framed with a big-endian 32-bit length prefix. The object must
+// only be written through ObjectLog. An ObjectLog may be used by multiple
+// goroutines at once, and multiple clients may append to the same log.
+type ObjectLog struct {
+	ioctx *IOContext
+	oid   string
+}
+
+// NewLog returns an ObjectLog stored in the object with key oid. The object
+// is created by the first Append.
+//  PREVIEW
+func (ioctx *IOContext) NewLog(oid string) *ObjectLog {
+	return &ObjectLog{ioctx: ioctx, oid: oid}
+}
+
+// size returns the size of the log as recorded in the object, and whether
+// the object exists.
+func (l *ObjectLog) size() (string, bool, error) {
+	buf := make([]byte, 32)
+	n, err := l.ioctx.GetXattr(l.oid, objectLogSizeXattr, buf)
+	switch err {
+	case nil:
+		return string(buf[:n]), true, nil
+	case ErrNotFound:
+		return "", false, nil
+	case errNoData:
+		return "", true, nil
+	}
+	return "", false, err
+}
+
+// Append atomically appends the entry to the end of the log and returns the
+// offset of the entry within the object. An append racing with other
+// appends is retried after a short, growing wait like ReadModifyWrite, giving
+// up with ErrReadModifyWriteConflict if it keeps losing the race.
+//  PREVIEW
+//
+// Implements:
+//  void rados_write_op_append(rados_write_op_t write_op,
+//                             const char *buffer,
+//                             size_t len);
+func (l *ObjectLog) Append(entry []byte) (uint64, error) {
+	if err := l.ioctx.validate(); err != nil {
+		return 0, err
+	}
+	if uint64(len(entry)) > math.MaxUint32 {
+		return 0, errRange
+	}
+	frame := make([]byte, ObjectLogHeaderSize+len(entry))
+	binary.BigEndian.PutUint32(frame, uint32(len(entry)))
+	copy(frame[ObjectLogHeaderSize:], entry)
+
+	var offset uint64
+	err := retryConflicts(func() (bool, error) {
+		size, exists, err := l.size()
+		if err != nil {
+			return false, err
+		}
+		offset = 0
+		if size != "" {
+			if offset, err = strconv.ParseUint(size, 10, 64); err != nil {
+				return false, err
+			}
+		}
+
+		// the append only applies if no other append happened since the
+		// size was read
+		op := CreateWriteOp()
+		if exists {
+			op.CmpXattr(objectLogSizeXattr, CmpOpEQ, []byte(size))
+		} else {
+			op.Create(CreateExclusive)
+		}
+		op.append(frame)
+		op.SetXattr(objectLogSizeXattr,
+			[]byte(strconv.FormatUint(offset+uint64(len(frame)), 10)))
+		err = op.operateCompat(l.ioctx, l.oid)
+		op.Release()
+		switch err {
+		case errCanceled, ErrObjectExists, ErrNotFound:
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// ReadFrom returns the entry of the log that starts at the given offset.
+// At the end of the log io.EOF is returned.
+//  PREVIEW
+func (l *ObjectLog) ReadFrom(offset uint64) ([]byte, error) {
+	if err := l.ioctx.validate(); err != nil {
+		return nil, err
+	}
+	header := make([]byte, ObjectLogHeaderSize)
+	n, err := l.ioctx.Read(l.oid, header, offset)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, io.EOF
+	} else if n < ObjectLogHeaderSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	entry := make([]byte, binary.BigEndian.Uint32(header))
+	if len(entry) == 0 {
+		return entry, nil
+	}
+	n, err = l.ioctx.Read(l.oid, entry, offset+ObjectLogHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	if n < len(entry) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return entry, nil
+}
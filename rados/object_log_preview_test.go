@@ -0,0 +1,83 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestObjectLog() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	defer suite.ioctx.Delete(oid)
+	log := suite.ioctx.NewLog(oid)
+
+	_, err := log.ReadFrom(0)
+	ta.Equal(ErrNotFound, err)
+
+	const writers = 8
+	const perWriter = 10
+	type appended struct {
+		offset uint64
+		entry  string
+	}
+	results := make(chan appended, writers*perWriter)
+	wg := sync.WaitGroup{}
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				// entries of varying length, including empty ones
+				entry := fmt.Sprintf("%d-%d:%s", w, i, make([]byte, (w*i)%7))
+				if i == 3 {
+					entry = ""
+				}
+				offset, err := log.Append([]byte(entry))
+				if !assert.NoError(suite.T(), err) {
+					return
+				}
+				results <- appended{offset, entry}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(results)
+
+	all := []appended{}
+	for r := range results {
+		all = append(all, r)
+	}
+	require.Len(suite.T(), all, writers*perWriter)
+	sort.Slice(all, func(i, j int) bool { return all[i].offset < all[j].offset })
+
+	// reading the log from the start visits every appended entry in order
+	offset := uint64(0)
+	for _, expected := range all {
+		ta.Equal(expected.offset, offset)
+		entry, err := log.ReadFrom(offset)
+		require.NoError(suite.T(), err)
+		ta.Equal(expected.entry, string(entry))
+		offset += ObjectLogHeaderSize + uint64(len(entry))
+	}
+	_, err = log.ReadFrom(offset)
+	ta.Equal(io.EOF, err)
+
+	stat, err := suite.ioctx.Stat(oid)
+	ta.NoError(err)
+	ta.Equal(offset, stat.Size)
+
+	_, err = (&IOContext{}).NewLog(oid).Append([]byte("x"))
+	ta.Equal(ErrInvalidIOContext, err)
+	_, err = (&IOContext{}).NewLog(oid).ReadFrom(0)
+	ta.Equal(ErrInvalidIOContext, err)
+}
@@ -0,0 +1,201 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"errors"
+	"runtime"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/cutil"
+)
+
+var errInvalidConcurrency = errors.New("concurrency must be positive")
+
+// getOmapByKeysStep is a read op step fetching the values of a set of omap
+// keys. The values are copied out of librados when the op completes.
+type getOmapByKeysStep struct {
+	withRefs
+
+	// C arguments and returned data:
+	cKeys cutil.CPtrCSlice
+	iter  C.rados_omap_iter_t
+	prval *C.int
+
+	values map[string][]byte
+}
+
+func newGetOmapByKeysStep(keys []string) *getOmapByKeysStep {
+	s := &getOmapByKeysStep{
+		cKeys: cutil.NewCPtrCSlice(len(keys)),
+		prval: (*C.int)(C.malloc(C.sizeof_int)),
+	}
+	for i, key := range keys {
+		s.cKeys[i] = cutil.CPtr(C.CString(key))
+		s.add(unsafe.Pointer(s.cKeys[i]))
+	}
+	runtime.SetFinalizer(s, opStepFinalizer)
+	return s
+}
+
+func (s *getOmapByKeysStep) update() error {
+	if err := getError(*s.prval); err != nil {
+		return err
+	}
+	s.values = map[string][]byte{}
+	for {
+		var (
+			cKey *C.char
+			cVal *C.char
+			cLen C.size_t
+		)
+		ret := C.rados_omap_get_next(s.iter, &cKey, &cVal, &cLen)
+		if ret != 0 {
+			return getError(ret)
+		}
+		if cKey == nil {
+			return nil
+		}
+		s.values[C.GoString(cKey)] = C.GoBytes(unsafe.Pointer(cVal), C.int(cLen))
+	}
+}
+
+func (s *getOmapByKeysStep) free() {
+	if s.iter != nil {
+		C.rados_omap_get_end(s.iter)
+	}
+	s.iter = nil
+	C.free(unsafe.Pointer(s.prval))
+	s.prval = nil
+	s.cKeys.Free()
+	s.withRefs.free()
+}
+
+// getOmapValuesByKeys adds a step fetching the values of the given omap
+// keys to the read op.
+//
+// Implements:
+//  void rados_read_op_omap_get_vals_by_keys(rados_read_op_t read_op,
+//                                           char const* const* keys,
+//                                           size_t keys_len,
+//                                           rados_omap_iter_t *iter,
+//                                           int *prval);
+func (r *ReadOp) getOmapValuesByKeys(keys []string) *getOmapByKeysStep {
+	s := newGetOmapByKeysStep(keys)
+	r.steps = append(r.steps, s)
+	C.rados_read_op_omap_get_vals_by_keys(
+		r.op,
+		(**C.char)(s.cKeys.Ptr()),
+		C.size_t(len(keys)),
+		&s.iter,
+		s.prval)
+	return s
+}
+
+// operateAsync starts performing the read op and returns a Completion that
+// updates the steps of the op once it is waited on. The op must not be
+// released before the Completion was waited on.
+//
+// Implements:
+//  int rados_aio_read_op_operate(rados_read_op_t read_op,
+//                                rados_ioctx_t io,
+//                                rados_completion_t completion,
+//                                const char *oid,
+//                                int flags);
+func (r *ReadOp) operateAsync(ioctx *IOContext, oid string) (*Completion, error) {
+	c, err := newCompletion(ioctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.onComplete = func(ret C.int) error {
+		return r.update(readOp, ret)
+	}
+
+	cOid := C.CString(oid)
+	defer C.free(unsafe.Pointer(cOid))
+
+	ret := C.rados_aio_read_op_operate(
+		r.op, ioctx.ioctx, c.completion, cOid, C.int(OperationNoFlag))
+	if ret < 0 {
+		c.abort()
+		return nil, getError(ret)
+	}
+	return c, nil
+}
+
+// GetOmapValuesMany fetches the values of the given omap keys from each of
+// the objects named by oids. Up to concurrency reads are in flight at the
+// same time. The result maps every object to the keys found in its omap;
+// keys that are not set are left out and so are objects that do not exist.
+//  PREVIEW
+func (ioctx *IOContext) GetOmapValuesMany(
+	oids []string, keys []string, concurrency int) (map[string]map[string][]byte, error) {
+
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	if concurrency <= 0 {
+		return nil, errInvalidConcurrency
+	}
+
+	type pending struct {
+		oid  string
+		op   *ReadOp
+		step *getOmapByKeysStep
+		c    *Completion
+	}
+	result := make(map[string]map[string][]byte, len(oids))
+	var firstErr error
+	wait := func(p pending) {
+		defer p.op.Release()
+		switch err := p.c.Wait().(type) {
+		case nil:
+			result[p.oid] = p.step.values
+		case OperationError:
+			if err.OpError == ErrNotFound {
+				return
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		default:
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	inflight := make([]pending, 0, concurrency)
+	for _, oid := range oids {
+		if firstErr != nil {
+			break
+		}
+		if len(inflight) == cap(inflight) {
+			wait(inflight[0])
+			inflight = append(inflight[:0], inflight[1:]...)
+		}
+		op := CreateReadOp()
+		step := op.getOmapValuesByKeys(keys)
+		c, err := op.operateAsync(ioctx, oid)
+		if err != nil {
+			op.Release()
+			firstErr = err
+			break
+		}
+		inflight = append(inflight, pending{oid, op, step, c})
+	}
+	for _, p := range inflight {
+		wait(p)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
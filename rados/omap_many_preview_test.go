@@ -0,0 +1,119 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestGetOmapValuesMany() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	_, err := (&IOContext{}).GetOmapValuesMany([]string{"foo"}, []string{"a"}, 4)
+	ta.Equal(ErrInvalidIOContext, err)
+	_, err = suite.ioctx.GetOmapValuesMany([]string{"foo"}, []string{"a"}, 0)
+	ta.Equal(errInvalidConcurrency, err)
+
+	oids := make([]string, 10)
+	for i := range oids {
+		oids[i] = suite.GenObjectName()
+		err := suite.ioctx.SetOmap(oids[i], map[string][]byte{
+			"alpha": []byte(fmt.Sprintf("a%d", i)),
+			"beta":  []byte(fmt.Sprintf("b%d", i)),
+			"gamma": []byte("unrequested"),
+		})
+		require.NoError(suite.T(), err)
+	}
+	missing := suite.GenObjectName()
+
+	for _, concurrency := range []int{1, 3, 16} {
+		suite.T().Run(fmt.Sprintf("concurrency%d", concurrency), func(t *testing.T) {
+			values, err := suite.ioctx.GetOmapValuesMany(
+				append(oids, missing), []string{"alpha", "beta", "delta"}, concurrency)
+			require.NoError(t, err)
+			assert.Len(t, values, len(oids))
+			assert.NotContains(t, values, missing)
+			for i, oid := range oids {
+				assert.Equal(t, map[string][]byte{
+					"alpha": []byte(fmt.Sprintf("a%d", i)),
+					"beta":  []byte(fmt.Sprintf("b%d", i)),
+				}, values[oid])
+			}
+		})
+	}
+
+	suite.T().Run("noKeys", func(t *testing.T) {
+		values, err := suite.ioctx.GetOmapValuesMany(oids, nil, 4)
+		require.NoError(t, err)
+		assert.Len(t, values, len(oids))
+		for _, oid := range oids {
+			assert.Len(t, values[oid], 0)
+		}
+	})
+}
+
+// benchmarkGetOmapMany fetches two omap keys from each of 1000 objects per
+// iteration using the given fetch function.
+func benchmarkGetOmapMany(b *testing.B, fetch func(*IOContext, []string, []string) error) {
+	const count = 1000
+	conn, err := NewConn()
+	require.NoError(b, err)
+	require.NoError(b, conn.ReadDefaultConfigFile())
+	require.NoError(b, conn.Connect())
+	defer conn.Shutdown()
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(b, conn.MakePool(pool))
+	defer conn.DeletePool(pool)
+
+	ioctx, err := conn.OpenIOContext(pool)
+	require.NoError(b, err)
+	defer ioctx.Destroy()
+
+	oids := make([]string, count)
+	for i := range oids {
+		oids[i] = fmt.Sprintf("index%04d", i)
+		err := ioctx.SetOmap(oids[i], map[string][]byte{
+			"alpha": []byte("first"),
+			"beta":  []byte("second"),
+		})
+		require.NoError(b, err)
+	}
+	keys := []string{"alpha", "beta"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := fetch(ioctx, oids, keys); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetOmapSerial(b *testing.B) {
+	benchmarkGetOmapMany(b, func(ioctx *IOContext, oids, keys []string) error {
+		for _, oid := range oids {
+			op := CreateReadOp()
+			op.getOmapValuesByKeys(keys)
+			err := op.Operate(ioctx, oid, OperationNoFlag)
+			op.Release()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkGetOmapMany(b *testing.B) {
+	benchmarkGetOmapMany(b, func(ioctx *IOContext, oids, keys []string) error {
+		_, err := ioctx.GetOmapValuesMany(oids, keys, 32)
+		return err
+	})
+}
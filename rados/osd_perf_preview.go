@@ -0,0 +1,73 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+// OSDPerf contains the latency statistics of a single OSD reported by the
+// "osd perf" command.
+type OSDPerf struct {
+	ID                int64  `json:"id"`
+	CommitLatencyMsec uint64 `json:"commit_latency_ms"`
+	ApplyLatencyMsec  uint64 `json:"apply_latency_ms"`
+}
+
+type osdPerfInfo struct {
+	ID        int64 `json:"id"`
+	PerfStats struct {
+		CommitLatencyMsec uint64 `json:"commit_latency_ms"`
+		ApplyLatencyMsec  uint64 `json:"apply_latency_ms"`
+	} `json:"perf_stats"`
+}
+
+type osdPerfDump struct {
+	// newer versions nest the list one level deeper
+	OSDStats *struct {
+		Infos []osdPerfInfo `json:"osd_perf_infos"`
+	} `json:"osdstats"`
+	Infos []osdPerfInfo `json:"osd_perf_infos"`
+}
+
+func parseOSDPerf(buf []byte) ([]OSDPerf, error) {
+	dump := osdPerfDump{}
+	if err := json.Unmarshal(buf, &dump); err != nil {
+		return nil, err
+	}
+	infos := dump.Infos
+	if dump.OSDStats != nil {
+		infos = dump.OSDStats.Infos
+	}
+	perf := make([]OSDPerf, len(infos))
+	for i, info := range infos {
+		perf[i] = OSDPerf{
+			ID:                info.ID,
+			CommitLatencyMsec: info.PerfStats.CommitLatencyMsec,
+			ApplyLatencyMsec:  info.PerfStats.ApplyLatencyMsec,
+		}
+	}
+	return perf, nil
+}
+
+// GetOSDPerf returns the commit and apply latencies of the OSDs of the
+// cluster, as reported by the "osd perf" command.
+//  PREVIEW
+func (c *Conn) GetOSDPerf() ([]OSDPerf, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "osd perf",
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MgrCommand([][]byte{cmd})
+	if err != nil {
+		return nil, err
+	}
+	return parseOSDPerf(buf)
+}
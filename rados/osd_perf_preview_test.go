@@ -0,0 +1,61 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestGetOSDPerf() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.GetOSDPerf()
+	ta.Equal(ErrNotConnected, err)
+
+	perf, err := suite.conn.GetOSDPerf()
+	require.NoError(suite.T(), err)
+
+	dump, err := suite.conn.getOSDMap()
+	require.NoError(suite.T(), err)
+	ids := map[int64]bool{}
+	for _, p := range perf {
+		ids[p.ID] = true
+	}
+	for _, osd := range dump.OSDs {
+		if osd.Up == 1 {
+			ta.True(ids[osd.ID], "osd.%d missing from osd perf output", osd.ID)
+		}
+	}
+}
+
+func TestParseOSDPerf(t *testing.T) {
+	nested := []byte(`{"osdstats":{"osd_perf_infos":[
+		{"id":1,"perf_stats":{"commit_latency_ms":7,"apply_latency_ms":7,
+			"commit_latency_ns":7000000,"apply_latency_ns":7000000}},
+		{"id":0,"perf_stats":{"commit_latency_ms":0,"apply_latency_ms":2,
+			"commit_latency_ns":0,"apply_latency_ns":2000000}}]}}`)
+	perf, err := parseOSDPerf(nested)
+	assert.NoError(t, err)
+	assert.Equal(t, []OSDPerf{
+		{ID: 1, CommitLatencyMsec: 7, ApplyLatencyMsec: 7},
+		{ID: 0, CommitLatencyMsec: 0, ApplyLatencyMsec: 2},
+	}, perf)
+
+	flat := []byte(`{"osd_perf_infos":[
+		{"id":3,"perf_stats":{"commit_latency_ms":12,"apply_latency_ms":4}}]}`)
+	perf, err = parseOSDPerf(flat)
+	assert.NoError(t, err)
+	assert.Equal(t, []OSDPerf{
+		{ID: 3, CommitLatencyMsec: 12, ApplyLatencyMsec: 4},
+	}, perf)
+
+	_, err = parseOSDPerf([]byte("nope"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+// CrushNode is a bucket, such as a root or a host, or a device (OSD) of the
+// CRUSH hierarchy.
+type CrushNode struct {
+	// ID is negative for buckets and the OSD id for devices.
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	TypeID int    `json:"type_id"`
+	// CrushWeight is the CRUSH weight of a device.
+	CrushWeight float64 `json:"crush_weight"`
+	// DeviceClass is the device class, for example "ssd", of a device.
+	DeviceClass string `json:"device_class"`
+	// Status is "up" or "down" for a device.
+	Status string `json:"status"`
+	// ChildIDs are the ids of the direct children of a bucket.
+	ChildIDs []int64 `json:"children"`
+
+	// Children are the direct children of a bucket, in the order of
+	// ChildIDs.
+	Children []*CrushNode `json:"-"`
+}
+
+// IsDevice returns true if the node is a device (OSD) rather than a bucket.
+//  PREVIEW
+func (n *CrushNode) IsDevice() bool {
+	return n.ID >= 0
+}
+
+// Walk calls fn for the node and, depth first, for all nodes below it.
+// Returning false from fn skips the nodes below the node passed to fn.
+//  PREVIEW
+func (n *CrushNode) Walk(fn func(*CrushNode) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+// Devices returns the devices (OSDs) below the node, or the node itself if it
+// is a device.
+//  PREVIEW
+func (n *CrushNode) Devices() []*CrushNode {
+	devices := []*CrushNode{}
+	n.Walk(func(c *CrushNode) bool {
+		if c.IsDevice() {
+			devices = append(devices, c)
+		}
+		return true
+	})
+	return devices
+}
+
+// CrushTree is the CRUSH hierarchy of the cluster, as reported by the
+// "osd tree" command.
+type CrushTree struct {
+	// Roots are the nodes that are not the child of any other node.
+	Roots []*CrushNode
+	// Stray are the OSDs that exist but are not part of the hierarchy.
+	Stray []*CrushNode
+
+	nodes map[int64]*CrushNode
+}
+
+// Node returns the node with the given id, or nil if the tree does not
+// contain such a node.
+//  PREVIEW
+func (t *CrushTree) Node(id int64) *CrushNode {
+	return t.nodes[id]
+}
+
+// NodesOfType returns the nodes of the given type, for example "host", in
+// depth first order.
+//  PREVIEW
+func (t *CrushTree) NodesOfType(typ string) []*CrushNode {
+	nodes := []*CrushNode{}
+	for _, r := range t.Roots {
+		r.Walk(func(n *CrushNode) bool {
+			if n.Type == typ {
+				nodes = append(nodes, n)
+			}
+			return true
+		})
+	}
+	return nodes
+}
+
+type osdTreeDump struct {
+	Nodes []*CrushNode `json:"nodes"`
+	Stray []*CrushNode `json:"stray"`
+}
+
+func parseOSDTree(buf []byte) (*CrushTree, error) {
+	dump := osdTreeDump{}
+	if err := json.Unmarshal(buf, &dump); err != nil {
+		return nil, err
+	}
+	tree := &CrushTree{
+		Stray: dump.Stray,
+		nodes: make(map[int64]*CrushNode, len(dump.Nodes)),
+	}
+	for _, n := range dump.Nodes {
+		tree.nodes[n.ID] = n
+	}
+	isChild := map[int64]bool{}
+	for _, n := range dump.Nodes {
+		for _, id := range n.ChildIDs {
+			if c, ok := tree.nodes[id]; ok {
+				n.Children = append(n.Children, c)
+				isChild[id] = true
+			}
+		}
+	}
+	for _, n := range dump.Nodes {
+		if !isChild[n.ID] {
+			tree.Roots = append(tree.Roots, n)
+		}
+	}
+	return tree, nil
+}
+
+// GetOSDTree returns the CRUSH hierarchy of the cluster, as reported by the
+// "osd tree" command.
+//  PREVIEW
+func (c *Conn) GetOSDTree() (*CrushTree, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "osd tree",
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseOSDTree(buf)
+}
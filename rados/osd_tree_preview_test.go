@@ -0,0 +1,89 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOSDTree(t *testing.T) {
+	buf := []byte(`{"nodes":[
+		{"id":-1,"name":"default","type":"root","type_id":11,"children":[-3,-2]},
+		{"id":-3,"name":"host-b","type":"host","type_id":1,"children":[2]},
+		{"id":-2,"name":"host-a","type":"host","type_id":1,"children":[1,0]},
+		{"id":0,"device_class":"hdd","name":"osd.0","type":"osd","type_id":0,
+		 "crush_weight":0.0099,"depth":2,"exists":1,"status":"up"},
+		{"id":1,"device_class":"hdd","name":"osd.1","type":"osd","type_id":0,
+		 "crush_weight":0.0099,"depth":2,"exists":1,"status":"down"},
+		{"id":2,"device_class":"ssd","name":"osd.2","type":"osd","type_id":0,
+		 "crush_weight":0.0099,"depth":2,"exists":1,"status":"up"}],
+		"stray":[{"id":3,"name":"osd.3","type":"osd","type_id":0,
+		 "crush_weight":0,"depth":0,"exists":1,"status":"down"}]}`)
+
+	tree, err := parseOSDTree(buf)
+	require.NoError(t, err)
+	if assert.Len(t, tree.Roots, 1) {
+		assert.Equal(t, "default", tree.Roots[0].Name)
+		assert.Equal(t, "root", tree.Roots[0].Type)
+		assert.Len(t, tree.Roots[0].Children, 2)
+		assert.Len(t, tree.Roots[0].Devices(), 3)
+	}
+	hosts := tree.NodesOfType("host")
+	if assert.Len(t, hosts, 2) {
+		assert.Equal(t, "host-b", hosts[0].Name)
+		assert.Equal(t, "host-a", hosts[1].Name)
+		devices := hosts[1].Devices()
+		if assert.Len(t, devices, 2) {
+			assert.Equal(t, int64(1), devices[0].ID)
+			assert.Equal(t, "down", devices[0].Status)
+			assert.Equal(t, "hdd", devices[0].DeviceClass)
+		}
+	}
+	osd := tree.Node(2)
+	if assert.NotNil(t, osd) {
+		assert.True(t, osd.IsDevice())
+		assert.Equal(t, "ssd", osd.DeviceClass)
+		assert.InDelta(t, 0.0099, osd.CrushWeight, 0.00001)
+	}
+	assert.Nil(t, tree.Node(42))
+	if assert.Len(t, tree.Stray, 1) {
+		assert.Equal(t, "osd.3", tree.Stray[0].Name)
+	}
+
+	// skipping the nodes below a bucket
+	visited := 0
+	tree.Roots[0].Walk(func(n *CrushNode) bool {
+		visited++
+		return n.Type == "root"
+	})
+	assert.Equal(t, 3, visited)
+
+	_, err = parseOSDTree([]byte("nope"))
+	assert.Error(t, err)
+}
+
+func (suite *RadosTestSuite) TestGetOSDTree() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.GetOSDTree()
+	ta.Equal(ErrNotConnected, err)
+
+	tree, err := suite.conn.GetOSDTree()
+	require.NoError(suite.T(), err)
+	roots := tree.NodesOfType("root")
+	ta.NotEmpty(roots)
+	hosts := tree.NodesOfType("host")
+	if ta.NotEmpty(hosts) {
+		ta.NotEmpty(hosts[0].Devices())
+		for _, d := range hosts[0].Devices() {
+			ta.Equal("osd", d.Type)
+		}
+	}
+}
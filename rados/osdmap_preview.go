@@ -0,0 +1,127 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+// PoolFlags is a set of flags of a pool, as recorded in the OSD map.
+type PoolFlags uint64
+
+const (
+	// PoolFlagHashPSPool indicates the pool hashes the pool id into the
+	// placement seed.
+	PoolFlagHashPSPool = PoolFlags(1 << 0)
+	// PoolFlagFull indicates the pool is full.
+	PoolFlagFull = PoolFlags(1 << 1)
+	// PoolFlagECOverwrites indicates an erasure coded pool allows overwrites.
+	PoolFlagECOverwrites = PoolFlags(1 << 2)
+	// PoolFlagIncompleteClones indicates the pool may have incomplete clones.
+	PoolFlagIncompleteClones = PoolFlags(1 << 3)
+	// PoolFlagNoDelete indicates the pool can not be deleted.
+	PoolFlagNoDelete = PoolFlags(1 << 4)
+	// PoolFlagNoPGChange indicates the pg_num of the pool can not be
+	// changed.
+	PoolFlagNoPGChange = PoolFlags(1 << 5)
+	// PoolFlagNoSizeChange indicates the size of the pool can not be changed.
+	PoolFlagNoSizeChange = PoolFlags(1 << 6)
+	// PoolFlagWriteFadviseDontNeed indicates writes to the pool are marked
+	// as not needed in the OSD caches.
+	PoolFlagWriteFadviseDontNeed = PoolFlags(1 << 7)
+	// PoolFlagNoScrub indicates the pool is not scrubbed.
+	PoolFlagNoScrub = PoolFlags(1 << 8)
+	// PoolFlagNoDeepScrub indicates the pool is not deep scrubbed.
+	PoolFlagNoDeepScrub = PoolFlags(1 << 9)
+	// PoolFlagFullQuota indicates the pool reached its quota.
+	PoolFlagFullQuota = PoolFlags(1 << 10)
+	// PoolFlagNearFull indicates the pool is nearly full.
+	PoolFlagNearFull = PoolFlags(1 << 11)
+	// PoolFlagBackfillFull indicates the pool is too full to backfill.
+	PoolFlagBackfillFull = PoolFlags(1 << 12)
+	// PoolFlagSelfManagedSnaps indicates the pool uses self managed
+	// snapshots.
+	PoolFlagSelfManagedSnaps = PoolFlags(1 << 13)
+	// PoolFlagPoolSnaps indicates the pool uses pool snapshots.
+	PoolFlagPoolSnaps = PoolFlags(1 << 14)
+	// PoolFlagCreating indicates the pool is still being created.
+	PoolFlagCreating = PoolFlags(1 << 15)
+)
+
+// Has returns true if all of the given flags are set.
+//  PREVIEW
+func (f PoolFlags) Has(flags PoolFlags) bool {
+	return f&flags == flags
+}
+
+type osdMapDump struct {
+	Epoch uint64 `json:"epoch"`
+	Pools []struct {
+		ID    int64     `json:"pool"`
+		Name  string    `json:"pool_name"`
+		Flags PoolFlags `json:"flags"`
+	} `json:"pools"`
+	OSDs []struct {
+		ID int64 `json:"osd"`
+		Up int   `json:"up"`
+	} `json:"osds"`
+}
+
+// osdDump returns the JSON output of the "osd dump" monitor command.
+func (c *Conn) osdDump() ([]byte, error) {
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "osd dump",
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MonCommand(cmd)
+	return buf, err
+}
+
+func (c *Conn) getOSDMap() (*osdMapDump, error) {
+	buf, err := c.osdDump()
+	if err != nil {
+		return nil, err
+	}
+	dump := &osdMapDump{}
+	if err := json.Unmarshal(buf, dump); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// GetOSDMapEpoch returns the epoch of the current OSD map of the cluster.
+//  PREVIEW
+func (c *Conn) GetOSDMapEpoch() (uint64, error) {
+	if err := c.ensureConnected(); err != nil {
+		return 0, err
+	}
+	dump, err := c.getOSDMap()
+	if err != nil {
+		return 0, err
+	}
+	return dump.Epoch, nil
+}
+
+// GetPoolFlags returns the flags of the pool the IO context is associated
+// with, as recorded in the current OSD map.
+//  PREVIEW
+func (ioctx *IOContext) GetPoolFlags() (PoolFlags, error) {
+	if err := ioctx.validate(); err != nil {
+		return 0, err
+	}
+	dump, err := ioctx.conn.getOSDMap()
+	if err != nil {
+		return 0, err
+	}
+	poolID := ioctx.GetPoolID()
+	for _, p := range dump.Pools {
+		if p.ID == poolID {
+			return p.Flags, nil
+		}
+	}
+	return 0, ErrNotFound
+}
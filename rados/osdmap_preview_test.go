@@ -0,0 +1,92 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) setPoolFlag(pool, flag, value string) {
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "osd pool set",
+		"pool":   pool,
+		"var":    flag,
+		"val":    value,
+	})
+	require.NoError(suite.T(), err)
+	_, _, err = suite.conn.MonCommand(cmd)
+	require.NoError(suite.T(), err)
+}
+
+func (suite *RadosTestSuite) TestGetOSDMapEpoch() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.GetOSDMapEpoch()
+	ta.Equal(ErrNotConnected, err)
+
+	before, err := suite.conn.GetOSDMapEpoch()
+	ta.NoError(err)
+	ta.NotZero(before)
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(suite.T(), suite.conn.MakePool(pool))
+	defer suite.conn.DeletePool(pool)
+
+	after, err := suite.conn.GetOSDMapEpoch()
+	ta.NoError(err)
+	ta.Greater(after, before)
+}
+
+func (suite *RadosTestSuite) TestGetPoolFlags() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	_, err := (&IOContext{}).GetPoolFlags()
+	ta.Equal(ErrInvalidIOContext, err)
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(suite.T(), suite.conn.MakePool(pool))
+	defer suite.conn.DeletePool(pool)
+	ioctx, err := suite.conn.OpenIOContext(pool)
+	require.NoError(suite.T(), err)
+	defer ioctx.Destroy()
+
+	flags, err := ioctx.GetPoolFlags()
+	ta.NoError(err)
+	ta.True(flags.Has(PoolFlagHashPSPool))
+	ta.False(flags.Has(PoolFlagNoDelete))
+	ta.False(flags.Has(PoolFlagNoSizeChange))
+
+	suite.setPoolFlag(pool, "nodelete", "true")
+	suite.setPoolFlag(pool, "nosizechange", "true")
+	flags, err = ioctx.GetPoolFlags()
+	ta.NoError(err)
+	ta.True(flags.Has(PoolFlagNoDelete | PoolFlagNoSizeChange))
+	ta.False(flags.Has(PoolFlagNoPGChange))
+
+	// clear the flags again so the pool can be deleted
+	suite.setPoolFlag(pool, "nodelete", "false")
+	suite.setPoolFlag(pool, "nosizechange", "false")
+	flags, err = ioctx.GetPoolFlags()
+	ta.NoError(err)
+	ta.False(flags.Has(PoolFlagNoDelete))
+	ta.False(flags.Has(PoolFlagNoSizeChange))
+}
+
+func TestPoolFlagsHas(t *testing.T) {
+	f := PoolFlagNoDelete | PoolFlagNoScrub
+	ta := assert.New(t)
+	ta.True(f.Has(PoolFlagNoDelete))
+	ta.True(f.Has(PoolFlagNoDelete | PoolFlagNoScrub))
+	ta.False(f.Has(PoolFlagNoDelete | PoolFlagFull))
+	ta.True(f.Has(0))
+}
@@ -0,0 +1,51 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrPoolDeletionDisabled is returned by DeletePoolConfirmed when the
+// monitors refuse to delete pools because the mon_allow_pool_delete option
+// is not enabled.
+var ErrPoolDeletionDisabled = errors.New(
+	"pool deletion is disabled, mon_allow_pool_delete must be set to true")
+
+// poolDeleteError converts the error of an "osd pool delete" command.
+func poolDeleteError(err error) error {
+	if err == ErrPermissionDenied {
+		return ErrPoolDeletionDisabled
+	}
+	return err
+}
+
+// DeletePoolConfirmed deletes the named pool and all the data inside it by
+// sending the "osd pool delete" command to the monitors, along with the
+// pool name repeated and the confirmation flag the command requires.
+// ErrNotFound is returned if the pool does not exist and
+// ErrPoolDeletionDisabled is returned if the monitors are configured to
+// refuse pool deletion.
+//  PREVIEW
+func (c *Conn) DeletePoolConfirmed(name string) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+	// the monitors report success when asked to delete a missing pool
+	if _, err := c.GetPoolByName(name); err != nil {
+		return err
+	}
+	cmd, err := json.Marshal(map[string]interface{}{
+		"prefix":                      "osd pool delete",
+		"pool":                        name,
+		"pool2":                       name,
+		"yes_i_really_really_mean_it": true,
+	})
+	if err != nil {
+		return err
+	}
+	_, _, err = c.MonCommand(cmd)
+	return poolDeleteError(err)
+}
@@ -0,0 +1,44 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolDeleteError(t *testing.T) {
+	assert.Equal(t, ErrPoolDeletionDisabled, poolDeleteError(ErrPermissionDenied))
+	assert.Nil(t, poolDeleteError(nil))
+	other := errors.New("other")
+	assert.Equal(t, other, poolDeleteError(other))
+}
+
+func (suite *RadosTestSuite) TestDeletePoolConfirmed() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	name := uuid.Must(uuid.NewV4()).String()
+	require.NoError(suite.T(), suite.conn.MakePool(name))
+	pools, err := suite.conn.ListPools()
+	ta.NoError(err)
+	ta.Contains(pools, name)
+
+	err = suite.conn.DeletePoolConfirmed(name)
+	ta.NoError(err)
+	pools, err = suite.conn.ListPools()
+	ta.NoError(err)
+	ta.NotContains(pools, name)
+
+	err = suite.conn.DeletePoolConfirmed(name)
+	ta.Equal(ErrNotFound, err)
+
+	conn, err := NewConn()
+	ta.NoError(err)
+	ta.Equal(ErrNotConnected, conn.DeletePoolConfirmed(name))
+}
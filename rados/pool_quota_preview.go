@@ -0,0 +1,80 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+type poolQuota struct {
+	MaxObjects     uint64  `json:"quota_max_objects"`
+	MaxBytes       uint64  `json:"quota_max_bytes"`
+	CurrentObjects *uint64 `json:"current_num_objects"`
+	CurrentBytes   *uint64 `json:"current_num_bytes"`
+}
+
+func (ioctx *IOContext) getPoolQuota() (*poolQuota, error) {
+	name, err := ioctx.GetPoolName()
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "osd pool get-quota",
+		"pool":   name,
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := ioctx.conn.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	q := &poolQuota{}
+	if err := json.Unmarshal(buf, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// GetPoolQuota returns the quota of the pool the IO context is associated
+// with. A value of zero means the respective quota is not set.
+//  PREVIEW
+func (ioctx *IOContext) GetPoolQuota() (maxBytes, maxObjects uint64, err error) {
+	if err := ioctx.validate(); err != nil {
+		return 0, 0, err
+	}
+	q, err := ioctx.getPoolQuota()
+	if err != nil {
+		return 0, 0, err
+	}
+	return q.MaxBytes, q.MaxObjects, nil
+}
+
+// PoolQuotaExceeded returns true if the pool the IO context is associated
+// with has reached one of its quotas. Writes to such a pool fail with
+// ENOSPC (or block, depending on the client configuration).
+//  PREVIEW
+func (ioctx *IOContext) PoolQuotaExceeded() (bool, error) {
+	if err := ioctx.validate(); err != nil {
+		return false, err
+	}
+	q, err := ioctx.getPoolQuota()
+	if err != nil {
+		return false, err
+	}
+	if q.MaxBytes > 0 && q.CurrentBytes != nil && *q.CurrentBytes >= q.MaxBytes {
+		return true, nil
+	}
+	if q.MaxObjects > 0 && q.CurrentObjects != nil && *q.CurrentObjects >= q.MaxObjects {
+		return true, nil
+	}
+	// older versions of ceph do not report the current usage, but the OSD
+	// map flags the pool once a quota is reached
+	flags, err := ioctx.GetPoolFlags()
+	if err != nil {
+		return false, err
+	}
+	return flags.Has(PoolFlagFullQuota), nil
+}
@@ -0,0 +1,67 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) setPoolQuota(pool, field, value string) {
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "osd pool set-quota",
+		"pool":   pool,
+		"field":  field,
+		"val":    value,
+	})
+	require.NoError(suite.T(), err)
+	_, _, err = suite.conn.MonCommand(cmd)
+	require.NoError(suite.T(), err)
+}
+
+func (suite *RadosTestSuite) TestGetPoolQuota() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	_, _, err := (&IOContext{}).GetPoolQuota()
+	ta.Equal(ErrInvalidIOContext, err)
+	_, err = (&IOContext{}).PoolQuotaExceeded()
+	ta.Equal(ErrInvalidIOContext, err)
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(suite.T(), suite.conn.MakePool(pool))
+	defer suite.conn.DeletePool(pool)
+	ioctx, err := suite.conn.OpenIOContext(pool)
+	require.NoError(suite.T(), err)
+	defer ioctx.Destroy()
+
+	maxBytes, maxObjects, err := ioctx.GetPoolQuota()
+	ta.NoError(err)
+	ta.EqualValues(0, maxBytes)
+	ta.EqualValues(0, maxObjects)
+	exceeded, err := ioctx.PoolQuotaExceeded()
+	ta.NoError(err)
+	ta.False(exceeded)
+
+	suite.setPoolQuota(pool, "max_bytes", "10485760")
+	suite.setPoolQuota(pool, "max_objects", "100")
+
+	maxBytes, maxObjects, err = ioctx.GetPoolQuota()
+	ta.NoError(err)
+	ta.EqualValues(10485760, maxBytes)
+	ta.EqualValues(100, maxObjects)
+	exceeded, err = ioctx.PoolQuotaExceeded()
+	ta.NoError(err)
+	ta.False(exceeded)
+
+	suite.setPoolQuota(pool, "max_bytes", "0")
+	suite.setPoolQuota(pool, "max_objects", "0")
+	maxBytes, maxObjects, err = ioctx.GetPoolQuota()
+	ta.NoError(err)
+	ta.EqualValues(0, maxBytes)
+	ta.EqualValues(0, maxObjects)
+}
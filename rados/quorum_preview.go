@@ -0,0 +1,70 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"encoding/json"
+)
+
+// QuorumMon describes a monitor of the monitor map.
+type QuorumMon struct {
+	Rank int    `json:"rank"`
+	Name string `json:"name"`
+	Addr string `json:"addr"`
+}
+
+// QuorumMonMap is the part of the monitor map reported by the
+// "quorum_status" monitor command.
+type QuorumMonMap struct {
+	Epoch uint64      `json:"epoch"`
+	Mons  []QuorumMon `json:"mons"`
+}
+
+// QuorumStatus contains the output of the "quorum_status" monitor command.
+type QuorumStatus struct {
+	ElectionEpoch uint64       `json:"election_epoch"`
+	Quorum        []int        `json:"quorum"`
+	QuorumNames   []string     `json:"quorum_names"`
+	LeaderName    string       `json:"quorum_leader_name"`
+	MonMap        QuorumMonMap `json:"monmap"`
+}
+
+// LeaderRank returns the rank of the monitor leading the quorum, or -1 if no
+// monitor of the monitor map has the name of the leader.
+//  PREVIEW
+func (s *QuorumStatus) LeaderRank() int {
+	for _, m := range s.MonMap.Mons {
+		if m.Name == s.LeaderName {
+			return m.Rank
+		}
+	}
+	return -1
+}
+
+// GetQuorumStatus returns the ranks of the monitors in quorum, the leader of
+// the quorum and the monitor map, as reported by the "quorum_status"
+// monitor command.
+//  PREVIEW
+func (c *Conn) GetQuorumStatus() (*QuorumStatus, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	cmd, err := json.Marshal(map[string]string{
+		"prefix": "quorum_status",
+		"format": "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf, _, err := c.MonCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &QuorumStatus{}
+	if err := json.Unmarshal(buf, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
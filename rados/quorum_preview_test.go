@@ -0,0 +1,30 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestGetQuorumStatus() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	conn, err := NewConn()
+	require.NoError(suite.T(), err)
+	_, err = conn.GetQuorumStatus()
+	ta.Equal(ErrNotConnected, err)
+
+	status, err := suite.conn.GetQuorumStatus()
+	require.NoError(suite.T(), err)
+
+	ta.NotZero(status.MonMap.Epoch)
+	ta.NotEmpty(status.MonMap.Mons)
+	ta.NotEmpty(status.Quorum)
+	ta.Len(status.QuorumNames, len(status.Quorum))
+	ta.NotEmpty(status.LeaderName)
+	ta.Contains(status.QuorumNames, status.LeaderName)
+	ta.Contains(status.Quorum, status.LeaderRank())
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -517,6 +519,38 @@ func (suite *RadosTestSuite) TestReadWrite() {
 	assert.Equal(suite.T(), bytesIn, bytesOut)
 }
 
+func (suite *RadosTestSuite) TestReadPartial() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	bytesIn := []byte("0123456789")
+	err := suite.ioctx.WriteFull(oid, bytesIn)
+	ta.NoError(err)
+
+	// a buffer larger than the remaining data is only partially filled
+	buf := []byte("xxxxxxxx")
+	n, err := suite.ioctx.Read(oid, buf, 6)
+	ta.NoError(err)
+	ta.Equal(4, n)
+	ta.Equal("6789xxxx", string(buf))
+
+	// a buffer smaller than the object is filled completely
+	buf = make([]byte, 3)
+	n, err = suite.ioctx.Read(oid, buf, 2)
+	ta.NoError(err)
+	ta.Equal(3, n)
+	ta.Equal("234", string(buf))
+
+	// reading at or past the end of the object returns no data
+	n, err = suite.ioctx.Read(oid, buf, 10)
+	ta.NoError(err)
+	ta.Equal(0, n)
+	n, err = suite.ioctx.Read(oid, buf, 100)
+	ta.NoError(err)
+	ta.Equal(0, n)
+}
+
 func (suite *RadosTestSuite) TestAppend() {
 	suite.SetupConnection()
 
@@ -1150,6 +1184,109 @@ func (suite *RadosTestSuite) TestLocking() {
 	assert.Equal(suite.T(), 0, len(info.Clients))
 }
 
+func (suite *RadosTestSuite) TestLockingContention() {
+	suite.SetupConnection()
+
+	oid := suite.GenObjectName()
+	err := suite.ioctx.Create(oid, CreateIdempotent)
+	require.NoError(suite.T(), err)
+
+	// a second, independent client of the cluster
+	conn2, err := NewConn()
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), conn2.ReadDefaultConfigFile())
+	require.NoError(suite.T(), conn2.Connect())
+	defer conn2.Shutdown()
+	ioctx2, err := conn2.OpenIOContext(suite.pool)
+	require.NoError(suite.T(), err)
+	defer ioctx2.Destroy()
+
+	// both clients contend for the same exclusive lock at the same time
+	results := make(chan int, 2)
+	var wg sync.WaitGroup
+	for i, ioctx := range []*IOContext{suite.ioctx, ioctx2} {
+		wg.Add(1)
+		go func(ioctx *IOContext, cookie string) {
+			defer wg.Done()
+			res, err := ioctx.LockExclusive(oid, "myLock", cookie, "contended lock", 0, nil)
+			assert.NoError(suite.T(), err)
+			results <- res
+		}(ioctx, fmt.Sprintf("cookie%d", i))
+	}
+	wg.Wait()
+	close(results)
+
+	won, busy := 0, 0
+	for res := range results {
+		switch res {
+		case 0:
+			won++
+		case -int(syscall.EBUSY):
+			busy++
+		}
+	}
+	assert.Equal(suite.T(), 1, won)
+	assert.Equal(suite.T(), 1, busy)
+
+	info, err := suite.ioctx.ListLockers(oid, "myLock")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, len(info.Clients))
+	assert.True(suite.T(), info.Exclusive)
+}
+
+func (suite *RadosTestSuite) TestBreakLockAndReacquire() {
+	suite.SetupConnection()
+
+	oid := suite.GenObjectName()
+
+	// the lock is taken by a second client that then "dies" without
+	// releasing it
+	conn2, err := NewConn()
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), conn2.ReadDefaultConfigFile())
+	require.NoError(suite.T(), conn2.Connect())
+	ioctx2, err := conn2.OpenIOContext(suite.pool)
+	require.NoError(suite.T(), err)
+	res, err := ioctx2.LockShared(oid, "myLock", "deadCookie", "deadTag", "abandoned lock", 0, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, res)
+	ioctx2.Destroy()
+	conn2.Shutdown()
+
+	info, err := suite.ioctx.ListLockers(oid, "myLock")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, info.NumLockers)
+	assert.False(suite.T(), info.Exclusive)
+	assert.Equal(suite.T(), "deadTag", info.Tag)
+	assert.Equal(suite.T(), []string{"deadCookie"}, info.Cookies)
+	assert.Len(suite.T(), info.Clients, 1)
+	assert.Len(suite.T(), info.Addrs, 1)
+	assert.NotEqual(suite.T(), "", info.Addrs[0])
+
+	// an exclusive lock can not be taken while the abandoned lock is held
+	res, err = suite.ioctx.LockExclusive(oid, "myLock", "myCookie", "a description", 0, nil)
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), 0, res)
+
+	res, err = suite.ioctx.BreakLock(oid, "myLock", info.Clients[0], info.Cookies[0])
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, res)
+
+	// breaking a lock that is no longer held fails
+	res, err = suite.ioctx.BreakLock(oid, "myLock", info.Clients[0], info.Cookies[0])
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), -int(syscall.ENOENT), res)
+
+	res, err = suite.ioctx.LockExclusive(oid, "myLock", "myCookie", "a description", 0, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, res)
+
+	info, err = suite.ioctx.ListLockers(oid, "myLock")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), info.Exclusive)
+	assert.Equal(suite.T(), []string{"myCookie"}, info.Cookies)
+}
+
 func (suite *RadosTestSuite) TestOmapOnNonexistentObjectError() {
 	suite.SetupConnection()
 	oid := suite.GenObjectName()
@@ -1306,3 +1443,59 @@ func (suite *RadosTestSuite) TestSetGetNamespace() {
 func TestRadosTestSuite(t *testing.T) {
 	tsuite.Run(t, new(RadosTestSuite))
 }
+
+// benchmarkRead reads an object repeatedly using buffers obtained from
+// getBuf and reports allocations so that reading into reused buffers can be
+// compared with allocating a new buffer for every read. Buffers are passed
+// by pointer so that handing one back to a sync.Pool does not allocate.
+func benchmarkRead(b *testing.B, getBuf func() *[]byte, putBuf func(*[]byte)) {
+	const objSize = 64 * 1024
+	conn, err := NewConn()
+	require.NoError(b, err)
+	require.NoError(b, conn.ReadDefaultConfigFile())
+	require.NoError(b, conn.Connect())
+	defer conn.Shutdown()
+
+	pool := uuid.Must(uuid.NewV4()).String()
+	require.NoError(b, conn.MakePool(pool))
+	defer conn.DeletePool(pool)
+
+	ioctx, err := conn.OpenIOContext(pool)
+	require.NoError(b, err)
+	defer ioctx.Destroy()
+
+	oid := "BenchmarkRead"
+	require.NoError(b, ioctx.WriteFull(oid, make([]byte, objSize)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := getBuf()
+		n, err := ioctx.Read(oid, *buf, 0)
+		if err != nil || n != objSize {
+			b.Fatalf("read failed: %d, %v", n, err)
+		}
+		putBuf(buf)
+	}
+}
+
+func BenchmarkReadNewBuffer(b *testing.B) {
+	benchmarkRead(b,
+		func() *[]byte {
+			buf := make([]byte, 64*1024)
+			return &buf
+		},
+		func(*[]byte) {})
+}
+
+func BenchmarkReadPooledBuffer(b *testing.B) {
+	pool := sync.Pool{
+		New: func() interface{} {
+			buf := make([]byte, 64*1024)
+			return &buf
+		},
+	}
+	benchmarkRead(b,
+		func() *[]byte { return pool.Get().(*[]byte) },
+		func(buf *[]byte) { pool.Put(buf) })
+}
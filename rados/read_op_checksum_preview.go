@@ -0,0 +1,143 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"encoding/binary"
+	"runtime"
+	"unsafe"
+)
+
+// ChecksumType is used to select the hash algorithm used by the
+// ReadOp.Checksum step.
+type ChecksumType C.rados_checksum_type_t
+
+const (
+	// ChecksumXXHash32 selects the 32-bit xxHash algorithm.
+	ChecksumXXHash32 = ChecksumType(C.LIBRADOS_CHECKSUM_TYPE_XXHASH32)
+	// ChecksumXXHash64 selects the 64-bit xxHash algorithm.
+	ChecksumXXHash64 = ChecksumType(C.LIBRADOS_CHECKSUM_TYPE_XXHASH64)
+	// ChecksumCRC32C selects the CRC-32C (Castagnoli) algorithm.
+	ChecksumCRC32C = ChecksumType(C.LIBRADOS_CHECKSUM_TYPE_CRC32C)
+)
+
+// size returns the size, in bytes, of a single checksum value.
+func (t ChecksumType) size() int {
+	if t == ChecksumXXHash64 {
+		return 8
+	}
+	return 4
+}
+
+// ReadOpChecksumStep holds the result of a checksum computed by the OSD as
+// part of a read operation. Checksums is valid only after Operate was called.
+type ReadOpChecksumStep struct {
+	withRefs
+
+	csumType ChecksumType
+	// C returned data:
+	cBuf  *C.char
+	cLen  C.size_t
+	prval *C.int
+
+	// Checksums contains one checksum for every chunk of the checked range.
+	Checksums []uint64
+}
+
+func newReadOpChecksumStep(t ChecksumType, chunks uint64) *ReadOpChecksumStep {
+	// the checksums are returned as a 32-bit count followed by the values
+	cLen := C.size_t(4 + chunks*uint64(t.size()))
+	s := &ReadOpChecksumStep{
+		csumType: t,
+		cBuf:     (*C.char)(C.malloc(cLen)),
+		cLen:     cLen,
+		prval:    (*C.int)(C.malloc(C.sizeof_int)),
+	}
+	runtime.SetFinalizer(s, opStepFinalizer)
+	return s
+}
+
+func (s *ReadOpChecksumStep) update() error {
+	if err := getError(*s.prval); err != nil {
+		return err
+	}
+	buf := C.GoBytes(unsafe.Pointer(s.cBuf), C.int(s.cLen))
+	count := int(binary.LittleEndian.Uint32(buf))
+	size := s.csumType.size()
+	if 4+count*size > len(buf) {
+		return errRange
+	}
+	s.Checksums = make([]uint64, count)
+	for i := range s.Checksums {
+		v := buf[4+i*size:]
+		if size == 8 {
+			s.Checksums[i] = binary.LittleEndian.Uint64(v)
+		} else {
+			s.Checksums[i] = uint64(binary.LittleEndian.Uint32(v))
+		}
+	}
+	return nil
+}
+
+func (s *ReadOpChecksumStep) free() {
+	C.free(unsafe.Pointer(s.cBuf))
+	s.cBuf = nil
+	C.free(unsafe.Pointer(s.prval))
+	s.prval = nil
+	s.withRefs.free()
+}
+
+// Checksum has the OSD compute checksums over length bytes of the object
+// starting at offset, without transferring the data itself. The range is
+// split into chunks of chunkSize bytes, each with its own checksum; a
+// chunkSize of zero computes a single checksum over the whole range. The
+// initValue is the little-endian encoded seed value for the algorithm and
+// must be 4 bytes long for 32-bit algorithms and 8 bytes long for 64-bit
+// algorithms.
+//  PREVIEW
+//
+// Implements:
+//  void rados_read_op_checksum(rados_read_op_t read_op,
+//                              rados_checksum_type_t type,
+//                              const char *init_value,
+//                              size_t init_value_len,
+//                              uint64_t offset, size_t len,
+//                              size_t chunk_size, char *pchecksum,
+//                              size_t checksum_len, int *prval);
+func (r *ReadOp) Checksum(
+	algo ChecksumType, initValue []byte,
+	offset, length, chunkSize uint64) *ReadOpChecksumStep {
+
+	chunks := uint64(1)
+	if chunkSize > 0 {
+		chunks = (length + chunkSize - 1) / chunkSize
+	}
+	s := newReadOpChecksumStep(algo, chunks)
+	r.steps = append(r.steps, s)
+
+	var cInit *C.char
+	if len(initValue) > 0 {
+		cInit = (*C.char)(C.CBytes(initValue))
+		s.add(unsafe.Pointer(cInit))
+	}
+
+	C.rados_read_op_checksum(
+		r.op,
+		C.rados_checksum_type_t(algo),
+		cInit,
+		C.size_t(len(initValue)),
+		C.uint64_t(offset),
+		C.size_t(length),
+		C.size_t(chunkSize),
+		s.cBuf,
+		s.cLen,
+		s.prval)
+	return s
+}
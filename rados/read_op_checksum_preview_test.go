@@ -0,0 +1,73 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"hash/crc32"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cephCRC32C computes a CRC-32C the same way ceph does, using the seed
+// directly and without inverting the result.
+func cephCRC32C(seed uint32, data []byte) uint32 {
+	table := crc32.MakeTable(crc32.Castagnoli)
+	return ^crc32.Update(^seed, table, data)
+}
+
+func (suite *RadosTestSuite) TestReadOpChecksum() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	data := suite.RandomBytes(4096)
+	err := suite.ioctx.WriteFull(oid, data)
+	ta.NoError(err)
+
+	seed := []byte{0xff, 0xff, 0xff, 0xff}
+
+	// whole range in one chunk
+	op := CreateReadOp()
+	defer op.Release()
+	s := op.Checksum(ChecksumCRC32C, seed, 0, 4096, 0)
+	err = op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	if ta.Len(s.Checksums, 1) {
+		ta.Equal(uint64(cephCRC32C(0xffffffff, data)), s.Checksums[0])
+	}
+
+	// per chunk checksums
+	op2 := CreateReadOp()
+	defer op2.Release()
+	s2 := op2.Checksum(ChecksumCRC32C, seed, 0, 4096, 1024)
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	if ta.Len(s2.Checksums, 4) {
+		for i := 0; i < 4; i++ {
+			chunk := data[i*1024 : (i+1)*1024]
+			ta.Equal(uint64(cephCRC32C(0xffffffff, chunk)), s2.Checksums[i])
+		}
+	}
+
+	// xxhash values are consistent between chunk sizes
+	op3 := CreateReadOp()
+	defer op3.Release()
+	s3a := op3.Checksum(ChecksumXXHash64, make([]byte, 8), 0, 2048, 0)
+	s3b := op3.Checksum(ChecksumXXHash64, make([]byte, 8), 0, 4096, 2048)
+	s3c := op3.Checksum(ChecksumXXHash32, make([]byte, 4), 0, 4096, 2048)
+	err = op3.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	if ta.Len(s3a.Checksums, 1) && ta.Len(s3b.Checksums, 2) {
+		ta.Equal(s3a.Checksums[0], s3b.Checksums[0])
+		ta.NotEqual(s3b.Checksums[0], s3b.Checksums[1])
+	}
+	ta.Len(s3c.Checksums, 2)
+
+	// an invalid seed is rejected
+	op4 := CreateReadOp()
+	defer op4.Release()
+	op4.Checksum(ChecksumXXHash64, seed, 0, 4096, 0)
+	err = op4.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.Error(err)
+}
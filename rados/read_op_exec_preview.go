@@ -0,0 +1,107 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// ReadOpExecStep holds the result of an object class method executed as
+// part of a read operation. Output is valid only after Operate was called.
+type ReadOpExecStep struct {
+	withRefs
+
+	// C returned data:
+	outBuf **C.char
+	outLen *C.size_t
+	prval  *C.int
+
+	// Output is the data returned by the object class method.
+	Output []byte
+}
+
+func newReadOpExecStep() *ReadOpExecStep {
+	s := &ReadOpExecStep{
+		outBuf: (**C.char)(C.malloc(C.size_t(unsafe.Sizeof((*C.char)(nil))))),
+		outLen: (*C.size_t)(C.malloc(C.sizeof_size_t)),
+		prval:  (*C.int)(C.malloc(C.sizeof_int)),
+	}
+	*s.outBuf = nil
+	*s.outLen = 0
+	runtime.SetFinalizer(s, opStepFinalizer)
+	return s
+}
+
+func (s *ReadOpExecStep) update() error {
+	if err := getError(*s.prval); err != nil {
+		return err
+	}
+	if *s.outBuf != nil {
+		s.Output = C.GoBytes(unsafe.Pointer(*s.outBuf), C.int(*s.outLen))
+	}
+	return nil
+}
+
+func (s *ReadOpExecStep) free() {
+	if s.outBuf != nil {
+		if *s.outBuf != nil {
+			C.rados_buffer_free(*s.outBuf)
+		}
+		C.free(unsafe.Pointer(s.outBuf))
+		s.outBuf = nil
+	}
+	C.free(unsafe.Pointer(s.outLen))
+	s.outLen = nil
+	C.free(unsafe.Pointer(s.prval))
+	s.prval = nil
+	s.withRefs.free()
+}
+
+// Exec executes the method of an object class on the object as part of the
+// read operation. The data in is passed to the method and the output of the
+// method is available from the returned ReadOpExecStep after the operation
+// was performed.
+//  PREVIEW
+//
+// Implements:
+//  void rados_read_op_exec(rados_read_op_t read_op,
+//                          const char *cls,
+//                          const char *method,
+//                          const char *in_buf,
+//                          size_t in_len,
+//                          char **out_buf,
+//                          size_t *out_len,
+//                          int *prval);
+func (r *ReadOp) Exec(cls, method string, in []byte) *ReadOpExecStep {
+	s := newReadOpExecStep()
+	r.steps = append(r.steps, s)
+
+	cCls := C.CString(cls)
+	s.add(unsafe.Pointer(cCls))
+	cMethod := C.CString(method)
+	s.add(unsafe.Pointer(cMethod))
+	var cIn *C.char
+	if len(in) > 0 {
+		cIn = (*C.char)(C.CBytes(in))
+		s.add(unsafe.Pointer(cIn))
+	}
+
+	C.rados_read_op_exec(
+		r.op,
+		cCls,
+		cMethod,
+		cIn,
+		C.size_t(len(in)),
+		s.outBuf,
+		s.outLen,
+		s.prval)
+	return s
+}
@@ -0,0 +1,33 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *RadosTestSuite) TestReadOpExec() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	res, err := suite.ioctx.LockExclusive(oid, "execLock", "myCookie", "", 0, nil)
+	ta.NoError(err)
+	ta.Equal(0, res)
+
+	// the output of cls lock "list_locks" is an encoded set of lock names
+	op := CreateReadOp()
+	defer op.Release()
+	s := op.Exec("lock", "list_locks", nil)
+	err = op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	ta.Equal([]byte("\x01\x00\x00\x00\x08\x00\x00\x00execLock"), s.Output)
+
+	op2 := CreateReadOp()
+	defer op2.Release()
+	s2 := op2.Exec("nosuchclass", "nosuchmethod", []byte("input"))
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.Error(err)
+	ta.Nil(s2.Output)
+}
@@ -0,0 +1,64 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *RadosTestSuite) TestReadOpReadSparse() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	first := bytes.Repeat([]byte("a"), 1024)
+	second := bytes.Repeat([]byte("b"), 512)
+	err := suite.ioctx.Write(oid, first, 0)
+	ta.NoError(err)
+	err = suite.ioctx.Write(oid, second, 8192)
+	ta.NoError(err)
+
+	op := CreateReadOp()
+	defer op.Release()
+	s := op.ReadSparse(0, 16384)
+	err = op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	ta.Equal([]SparseExtent{
+		{Offset: 0, Length: 1024},
+		{Offset: 8192, Length: 512},
+	}, s.Extents)
+	ta.Equal(append(append([]byte{}, first...), second...), s.Data)
+
+	// a range starting within the gap
+	op2 := CreateReadOp()
+	defer op2.Release()
+	s2 := op2.ReadSparse(4096, 8192)
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	ta.Equal([]SparseExtent{{Offset: 8192, Length: 512}}, s2.Extents)
+	ta.Equal(second, s2.Data)
+
+	// missing object
+	op3 := CreateReadOp()
+	defer op3.Release()
+	op3.ReadSparse(0, 1024)
+	err = op3.operateCompat(suite.ioctx, oid+"-missing")
+	ta.Equal(ErrNotFound, err)
+}
+
+func TestSparseExtents(t *testing.T) {
+	ext, data := sparseExtents(100, []byte{0, 1, 2, 0, 0, 3, 0})
+	assert.Equal(t, []SparseExtent{
+		{Offset: 101, Length: 2},
+		{Offset: 105, Length: 1},
+	}, ext)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+
+	ext, data = sparseExtents(0, make([]byte, 8))
+	assert.Len(t, ext, 0)
+	assert.Len(t, data, 0)
+}
@@ -0,0 +1,95 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+/*
+#include <errno.h>
+*/
+import "C"
+
+import (
+	"time"
+)
+
+const (
+	// ErrBlocklisted indicates the client was blocklisted by the cluster. On
+	// Linux this shares its value with ESHUTDOWN.
+	ErrBlocklisted = radosError(-C.ESHUTDOWN)
+	// ErrTimedOut indicates an operation did not complete in time.
+	ErrTimedOut = radosError(-C.ETIMEDOUT)
+)
+
+// RetryPolicy controls how operations that fail with a recoverable error are
+// retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made, including the first
+	// one. Values less than one are treated as one.
+	MaxAttempts int
+	// InitialBackoff is the time waited before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the time waited between attempts. The wait doubles
+	// after every retry until it reaches MaxBackoff. A zero value means no
+	// limit.
+	MaxBackoff time.Duration
+	// Recoverable are the errors that cause the operation to be retried.
+	// Any other error is returned right away.
+	Recoverable []error
+}
+
+// DefaultRetryPolicy returns a RetryPolicy that makes up to five attempts
+// with a backoff starting at 100 milliseconds, retrying operations that fail
+// because the client was blocklisted or timed out.
+//  PREVIEW
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Recoverable:    []error{ErrBlocklisted, ErrTimedOut},
+	}
+}
+
+func (p RetryPolicy) recoverable(err error) bool {
+	for _, e := range p.Recoverable {
+		if err == e {
+			return true
+		}
+	}
+	return false
+}
+
+// do calls op until it succeeds, fails with an error that is not recoverable
+// or the attempts are used up, returning the last error.
+func (p RetryPolicy) do(op func() error) error {
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.MaxAttempts || !p.recoverable(err) {
+			return err
+		}
+		retrySleep(backoff)
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// these are variables so that tests can simulate failures and avoid waiting
+var (
+	retrySleep = time.Sleep
+	retryWrite = (*IOContext).Write
+)
+
+// WriteWithRetry writes data to the object like Write, retrying the write
+// according to the policy when it fails with a recoverable error.
+//  PREVIEW
+func (ioctx *IOContext) WriteWithRetry(oid string, data []byte, offset uint64, policy RetryPolicy) error {
+	if err := ioctx.validate(); err != nil {
+		return err
+	}
+	return policy.do(func() error {
+		return retryWrite(ioctx, oid, data, offset)
+	})
+}
@@ -0,0 +1,107 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// simulateWriteFailures makes the next writes done by WriteWithRetry fail
+// with the given errors, in order, before the real write is done. The
+// returned function restores the original behavior and returns the number
+// of write attempts and the backoff times waited.
+func simulateWriteFailures(errs ...error) func() (int, []time.Duration) {
+	attempts := 0
+	waits := []time.Duration{}
+	retryWrite = func(ioctx *IOContext, oid string, data []byte, offset uint64) error {
+		attempts++
+		if attempts <= len(errs) {
+			return errs[attempts-1]
+		}
+		return ioctx.Write(oid, data, offset)
+	}
+	retrySleep = func(d time.Duration) { waits = append(waits, d) }
+	return func() (int, []time.Duration) {
+		retryWrite = (*IOContext).Write
+		retrySleep = time.Sleep
+		return attempts, waits
+	}
+}
+
+func (suite *RadosTestSuite) TestWriteWithRetry() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	policy := RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     25 * time.Millisecond,
+		Recoverable:    []error{ErrBlocklisted, ErrTimedOut},
+	}
+
+	suite.T().Run("noFailure", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		restore := simulateWriteFailures()
+		err := suite.ioctx.WriteWithRetry(oid, []byte("data"), 0, policy)
+		attempts, waits := restore()
+		ta.NoError(err)
+		ta.Equal(1, attempts)
+		ta.Len(waits, 0)
+	})
+
+	suite.T().Run("transientFailure", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		restore := simulateWriteFailures(ErrBlocklisted, ErrTimedOut, ErrBlocklisted)
+		err := suite.ioctx.WriteWithRetry(oid, []byte("eventually"), 0, policy)
+		attempts, waits := restore()
+		ta.NoError(err)
+		ta.Equal(4, attempts)
+		ta.Equal([]time.Duration{
+			10 * time.Millisecond,
+			20 * time.Millisecond,
+			25 * time.Millisecond,
+		}, waits)
+
+		buf := make([]byte, 16)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		ta.NoError(err)
+		ta.Equal("eventually", string(buf[:n]))
+	})
+
+	suite.T().Run("attemptsExhausted", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		restore := simulateWriteFailures(
+			ErrBlocklisted, ErrBlocklisted, ErrBlocklisted, ErrBlocklisted)
+		err := suite.ioctx.WriteWithRetry(oid, []byte("never"), 0, policy)
+		attempts, _ := restore()
+		ta.Equal(ErrBlocklisted, err)
+		ta.Equal(4, attempts)
+	})
+
+	suite.T().Run("notRecoverable", func(t *testing.T) {
+		oid := suite.GenObjectName()
+		restore := simulateWriteFailures(ErrPermissionDenied)
+		err := suite.ioctx.WriteWithRetry(oid, []byte("never"), 0, policy)
+		attempts, waits := restore()
+		ta.Equal(ErrPermissionDenied, err)
+		ta.Equal(1, attempts)
+		ta.Len(waits, 0)
+	})
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		err := (&IOContext{}).WriteWithRetry("oid", []byte("x"), 0, DefaultRetryPolicy())
+		ta.Equal(ErrInvalidIOContext, err)
+	})
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	p := DefaultRetryPolicy()
+	assert.Equal(t, 5, p.MaxAttempts)
+	assert.True(t, p.recoverable(ErrBlocklisted))
+	assert.True(t, p.recoverable(ErrTimedOut))
+	assert.False(t, p.recoverable(ErrNotFound))
+}
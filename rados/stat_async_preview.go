@@ -0,0 +1,89 @@
+//go:build !octopus && !nautilus && ceph_preview
+// +build !octopus,!nautilus,ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <time.h>
+// #include <rados/librados.h>
+//
+// typedef struct {
+//   uint64_t size;
+//   struct timespec mtime;
+// } go_rados_stat_t;
+//
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+// StatCompletion tracks the state of an asynchronous stat of an object. Once
+// the operation has finished the stat result can be fetched with Stat.
+type StatCompletion struct {
+	*Completion
+	stat ObjectStat
+}
+
+// Stat blocks until the asynchronous stat has finished and returns the size
+// and last modification time of the object. Like Wait, Stat may be called
+// more than once.
+//  PREVIEW
+func (sc *StatCompletion) Stat() (ObjectStat, error) {
+	if err := sc.Wait(); err != nil {
+		return ObjectStat{}, err
+	}
+	return sc.stat, nil
+}
+
+// StatAsync starts fetching the size and last modification time of the
+// object with key oid and returns a StatCompletion that can be used to
+// wait for the result. Unlike Stat the modification time is reported with
+// nanosecond precision. Many stats may be in flight at the same time.
+//  PREVIEW
+//
+// Implements:
+//  int rados_aio_stat2(rados_ioctx_t io, const char *o,
+//                      rados_completion_t completion,
+//                      uint64_t *psize, struct timespec *pmtime);
+func (ioctx *IOContext) StatAsync(oid string) (*StatCompletion, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+
+	c, err := newCompletion(ioctx, C.malloc(C.sizeof_go_rados_stat_t))
+	if err != nil {
+		return nil, err
+	}
+	sc := &StatCompletion{Completion: c}
+	cStat := (*C.go_rados_stat_t)(c.buf)
+	c.onComplete = func(ret C.int) error {
+		if ret < 0 {
+			return getError(ret)
+		}
+		// copy the result before the C buffer is freed
+		sc.stat = ObjectStat{
+			Size: uint64(cStat.size),
+			ModTime: time.Unix(
+				int64(cStat.mtime.tv_sec), int64(cStat.mtime.tv_nsec)),
+		}
+		return nil
+	}
+
+	cOid := C.CString(oid)
+	defer C.free(unsafe.Pointer(cOid))
+
+	ret := C.rados_aio_stat2(
+		ioctx.ioctx,
+		cOid,
+		c.completion,
+		&cStat.size,
+		&cStat.mtime)
+	if ret < 0 {
+		c.abort()
+		return nil, getError(ret)
+	}
+	return sc, nil
+}
@@ -0,0 +1,63 @@
+//go:build !octopus && !nautilus && ceph_preview
+// +build !octopus,!nautilus,ceph_preview
+
+package rados
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestStatAsync() {
+	suite.SetupConnection()
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		ioctx := &IOContext{}
+		_, err := ioctx.StatAsync("foo")
+		assert.Error(t, err)
+	})
+
+	suite.T().Run("missingObject", func(t *testing.T) {
+		sc, err := suite.ioctx.StatAsync(suite.GenObjectName())
+		require.NoError(t, err)
+		_, err = sc.Stat()
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	suite.T().Run("manyObjects", func(t *testing.T) {
+		const count = 200
+		before := time.Now().Add(-time.Minute)
+		oids := make([]string, count)
+		for i := range oids {
+			oids[i] = fmt.Sprintf("%s-%d", suite.GenObjectName(), i)
+			data := bytes.Repeat([]byte("x"), i+1)
+			require.NoError(t, suite.ioctx.WriteFull(oids[i], data))
+		}
+		defer func() {
+			for _, oid := range oids {
+				assert.NoError(t, suite.ioctx.Delete(oid))
+			}
+		}()
+
+		// start every stat before waiting on any of them
+		completions := make([]*StatCompletion, count)
+		for i, oid := range oids {
+			sc, err := suite.ioctx.StatAsync(oid)
+			require.NoError(t, err)
+			completions[i] = sc
+		}
+		for i := len(completions) - 1; i >= 0; i-- {
+			stat, err := completions[i].Stat()
+			if assert.NoError(t, err) {
+				assert.Equal(t, uint64(i+1), stat.Size)
+				assert.True(t, stat.ModTime.After(before))
+			}
+		}
+		assert.True(t, completions[0].IsComplete())
+	})
+}
@@ -0,0 +1,300 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+/*
+#cgo LDFLAGS: -lrados
+#include <stdlib.h>
+#include <rados/librados.h>
+
+extern void subscriptionNotifyCallback(uintptr_t, uint64_t, uint64_t,
+	uint64_t, void *, size_t);
+extern void subscriptionErrorCallback(uintptr_t, uint64_t, int);
+
+static inline void subscription_notify_cb(void *arg, uint64_t notify_id,
+	uint64_t cookie, uint64_t notifier_id, void *data, size_t data_len) {
+		subscriptionNotifyCallback((uintptr_t)arg, notify_id, cookie,
+			notifier_id, data, data_len);
+}
+
+static inline void subscription_error_cb(void *arg, uint64_t cookie,
+	int err) {
+		subscriptionErrorCallback((uintptr_t)arg, cookie, err);
+}
+
+// inline wrapper to cast uintptr_t to void*
+static inline int wrap_rados_watch2(rados_ioctx_t io, const char *o,
+	uint64_t *cookie, uintptr_t arg) {
+		return rados_watch2(io, o, cookie, subscription_notify_cb,
+			subscription_error_cb, (void*)arg);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/callbacks"
+)
+
+const (
+	// subscriptionCheckInterval is how often the watch of a subscription is
+	// checked and, if it failed, re-established.
+	subscriptionCheckInterval = 5 * time.Second
+
+	subscriptionQueueSize = 64
+)
+
+var subscriptionCallbacks = callbacks.New()
+
+// NotifyEvent is a notification received by a Subscription.
+type NotifyEvent struct {
+	// NotifyID identifies the notification.
+	NotifyID uint64
+	// NotifierID is the ID of the client that sent the notification.
+	NotifierID uint64
+	// Data is the payload the notifier sent with the notification.
+	Data []byte
+
+	cookie C.uint64_t
+}
+
+// Subscription is a long lived watch on a rados object. Notifications sent
+// to the object are acknowledged and delivered on the channel returned by
+// Events. If the watch fails, for example because the connection to the OSD
+// was lost or the watch timed out, it is re-established automatically.
+type Subscription struct {
+	ioctx *IOContext
+	oid   string
+	cbID  uintptr
+
+	lock     sync.Mutex
+	cookie   C.uint64_t
+	watching bool
+	lastErr  error
+
+	notifies chan NotifyEvent
+	failures chan error
+	events   chan NotifyEvent
+	stop     chan struct{}
+	done     chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Subscribe establishes a watch on the object with key oid and returns a
+// Subscription delivering the notifications sent to the object.
+//  PREVIEW
+func (ioctx *IOContext) Subscribe(oid string) (*Subscription, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	s := &Subscription{
+		ioctx:    ioctx,
+		oid:      oid,
+		notifies: make(chan NotifyEvent, subscriptionQueueSize),
+		failures: make(chan error, 1),
+		events:   make(chan NotifyEvent),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	s.cbID = subscriptionCallbacks.Add(s)
+	if err := s.watch(); err != nil {
+		subscriptionCallbacks.Remove(s.cbID)
+		return nil, err
+	}
+	go s.run()
+	return s, nil
+}
+
+// watch establishes a new watch on the object.
+//
+// Implements:
+//  int rados_watch2(rados_ioctx_t io, const char *o, uint64_t *cookie,
+//                   rados_watchcb2_t watchcb, rados_watcherrcb_t watcherrcb,
+//                   void *arg);
+func (s *Subscription) watch() error {
+	coid := C.CString(s.oid)
+	defer C.free(unsafe.Pointer(coid))
+
+	var cookie C.uint64_t
+	ret := C.wrap_rados_watch2(s.ioctx.ioctx, coid, &cookie, C.uintptr_t(s.cbID))
+	if err := getError(ret); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	s.cookie = cookie
+	s.watching = true
+	s.lock.Unlock()
+	return nil
+}
+
+// unwatch removes the current watch of the subscription, if any.
+//
+// Implements:
+//  int rados_unwatch2(rados_ioctx_t io, uint64_t cookie);
+func (s *Subscription) unwatch() error {
+	s.lock.Lock()
+	cookie, watching := s.cookie, s.watching
+	s.watching = false
+	s.lock.Unlock()
+	if !watching {
+		return nil
+	}
+	return getError(C.rados_unwatch2(s.ioctx.ioctx, cookie))
+}
+
+// check returns an error if the current watch is not healthy.
+//
+// Implements:
+//  int rados_watch_check(rados_ioctx_t io, uint64_t cookie);
+func (s *Subscription) check() error {
+	s.lock.Lock()
+	cookie, watching := s.cookie, s.watching
+	s.lock.Unlock()
+	if !watching {
+		return ErrNotConnected
+	}
+	return getErrorIfNegative(C.rados_watch_check(s.ioctx.ioctx, cookie))
+}
+
+// rewatch replaces a failed watch with a new one.
+func (s *Subscription) rewatch(cause error) {
+	s.setLastError(cause)
+	// the old watch is gone or broken, failing to remove it is expected
+	_ = s.unwatch()
+	if err := s.watch(); err != nil {
+		s.setLastError(err)
+	}
+}
+
+func (s *Subscription) setLastError(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lastErr = err
+}
+
+// handleWatchError reports a failure of the watch so that it is replaced.
+func (s *Subscription) handleWatchError(err error) {
+	select {
+	case s.failures <- err:
+	default:
+		// a failure is already pending
+	}
+}
+
+// ack acknowledges a notification so that the notifier does not have to
+// wait for the notify timeout.
+//
+// Implements:
+//  int rados_notify_ack(rados_ioctx_t io, const char *o, uint64_t notify_id,
+//                       uint64_t cookie, const char *buf, int buf_len);
+func (s *Subscription) ack(ev NotifyEvent) error {
+	coid := C.CString(s.oid)
+	defer C.free(unsafe.Pointer(coid))
+	return getError(C.rados_notify_ack(
+		s.ioctx.ioctx, coid, C.uint64_t(ev.NotifyID), ev.cookie, nil, 0))
+}
+
+func (s *Subscription) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(subscriptionCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case err := <-s.failures:
+			s.rewatch(err)
+		case <-ticker.C:
+			if err := s.check(); err != nil {
+				s.rewatch(err)
+			}
+		case ev := <-s.notifies:
+			if err := s.ack(ev); err != nil {
+				s.setLastError(err)
+			}
+			select {
+			case s.events <- ev:
+			case <-s.stop:
+				return
+			}
+		}
+	}
+}
+
+// Events returns the channel the notifications received by the subscription
+// are delivered on. The channel is closed when the subscription is closed.
+//  PREVIEW
+func (s *Subscription) Events() <-chan NotifyEvent {
+	return s.events
+}
+
+// LastError returns the most recent error encountered by the subscription,
+// such as the failure of a watch that was subsequently re-established.
+//  PREVIEW
+func (s *Subscription) LastError() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lastErr
+}
+
+// Close removes the watch and stops the delivery of notifications. Close may
+// be called more than once; later calls return the result of the first one.
+//  PREVIEW
+//
+// Implements:
+//  int rados_watch_flush(rados_t cluster);
+func (s *Subscription) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.closeErr = s.unwatch()
+		// wait for callbacks that may still be in flight
+		C.rados_watch_flush(C.rados_ioctx_get_cluster(s.ioctx.ioctx))
+		subscriptionCallbacks.Remove(s.cbID)
+		close(s.events)
+	})
+	return s.closeErr
+}
+
+//export subscriptionNotifyCallback
+func subscriptionNotifyCallback(
+	index uintptr, notifyID, cookie, notifierID C.uint64_t,
+	data unsafe.Pointer, dataLen C.size_t) {
+
+	v := subscriptionCallbacks.Lookup(index)
+	s, ok := v.(*Subscription)
+	if !ok {
+		return
+	}
+	ev := NotifyEvent{
+		NotifyID:   uint64(notifyID),
+		NotifierID: uint64(notifierID),
+		Data:       C.GoBytes(data, C.int(dataLen)),
+		cookie:     cookie,
+	}
+	select {
+	case s.notifies <- ev:
+	case <-s.stop:
+	}
+}
+
+//export subscriptionErrorCallback
+func subscriptionErrorCallback(index uintptr, cookie C.uint64_t, err C.int) {
+	v := subscriptionCallbacks.Lookup(index)
+	s, ok := v.(*Subscription)
+	if !ok {
+		return
+	}
+	s.lock.Lock()
+	current := s.watching && s.cookie == cookie
+	s.lock.Unlock()
+	if current {
+		s.handleWatchError(getError(err))
+	}
+}
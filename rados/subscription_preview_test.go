@@ -0,0 +1,98 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestSubscription() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	err := suite.ioctx.Create(oid, CreateIdempotent)
+	require.NoError(suite.T(), err)
+
+	sub, err := suite.ioctx.Subscribe(oid)
+	require.NoError(suite.T(), err)
+
+	watchers, err := suite.ioctx.ListWatchers(oid)
+	ta.NoError(err)
+	ta.Len(watchers, 1)
+
+	recv := func() *NotifyEvent {
+		select {
+		case ev := <-sub.Events():
+			return &ev
+		case <-time.After(10 * time.Second):
+			return nil
+		}
+	}
+
+	ch := make(chan error, 1)
+	go func() { ch <- suite.ioctx.Notify(oid, []byte("hello"), 5*time.Second) }()
+	ev := recv()
+	if ta.NotNil(ev) {
+		ta.Equal("hello", string(ev.Data))
+	}
+	ta.NoError(<-ch)
+	ta.NoError(sub.LastError())
+
+	// force a watch error and wait for the watch to be re-established
+	sub.lock.Lock()
+	oldCookie := sub.cookie
+	sub.lock.Unlock()
+	watchErr := radosError(-int(syscall.ENOTCONN))
+	sub.handleWatchError(watchErr)
+	ta.Eventually(func() bool {
+		sub.lock.Lock()
+		defer sub.lock.Unlock()
+		return sub.watching && sub.cookie != oldCookie
+	}, 10*time.Second, 100*time.Millisecond)
+	ta.Equal(watchErr, sub.LastError())
+
+	watchers, err = suite.ioctx.ListWatchers(oid)
+	ta.NoError(err)
+	ta.Len(watchers, 1)
+
+	// notifications keep being delivered on the new watch
+	go func() { ch <- suite.ioctx.Notify(oid, []byte("again"), 5*time.Second) }()
+	ev = recv()
+	if ta.NotNil(ev) {
+		ta.Equal("again", string(ev.Data))
+	}
+	ta.NoError(<-ch)
+
+	err = sub.Close()
+	ta.NoError(err)
+	_, ok := <-sub.Events()
+	ta.False(ok)
+
+	watchers, err = suite.ioctx.ListWatchers(oid)
+	ta.NoError(err)
+	ta.Len(watchers, 0)
+
+	// closing again does nothing
+	ta.NotPanics(func() {
+		ta.NoError(sub.Close())
+	})
+}
+
+func (suite *RadosTestSuite) TestSubscriptionInvalid() {
+	suite.SetupConnection()
+
+	_, err := suite.ioctx.Subscribe(suite.GenObjectName())
+	assert.Equal(suite.T(), ErrNotFound, err)
+
+	ioctx := &IOContext{}
+	_, err = ioctx.Subscribe("foo")
+	assert.Equal(suite.T(), ErrInvalidIOContext, err)
+	err = ioctx.Notify("foo", nil, 0)
+	assert.Equal(suite.T(), ErrInvalidIOContext, err)
+}
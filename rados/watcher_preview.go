@@ -0,0 +1,64 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+// #cgo LDFLAGS: -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+//
+import "C"
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/retry"
+)
+
+// ObjWatcher is a representation of the obj_watch_t from librados.h
+type ObjWatcher struct {
+	Addr      string
+	WatcherID int64
+	Cookie    uint64
+	Timeout   time.Duration
+}
+
+// ListWatchers returns the watchers registered on the object with key oid.
+//  PREVIEW
+//
+// Implements:
+//  int rados_list_watchers(rados_ioctx_t io, const char *o,
+//                          obj_watch_t *watchers, size_t *max_watchers);
+func (ioctx *IOContext) ListWatchers(oid string) ([]ObjWatcher, error) {
+	if err := ioctx.validate(); err != nil {
+		return nil, err
+	}
+	coid := C.CString(oid)
+	defer C.free(unsafe.Pointer(coid))
+
+	var (
+		err      error
+		count    C.size_t
+		watchers []C.obj_watch_t
+	)
+	retry.WithSizes(16, 4096, func(size int) retry.Hint {
+		count = C.size_t(size)
+		watchers = make([]C.obj_watch_t, count)
+		ret := C.rados_list_watchers(ioctx.ioctx, coid, &watchers[0], &count)
+		err = getErrorIfNegative(ret)
+		return retry.Size(int(count)).If(err == errRange)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objWatchers := make([]ObjWatcher, count)
+	for i, watcher := range watchers[:count] {
+		objWatchers[i].Addr = C.GoString(&watcher.addr[0])
+		objWatchers[i].WatcherID = int64(watcher.watcher_id)
+		objWatchers[i].Cookie = uint64(watcher.cookie)
+		objWatchers[i].Timeout = time.Duration(watcher.timeout_seconds) * time.Second
+	}
+	return objWatchers, nil
+}
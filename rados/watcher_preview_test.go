@@ -0,0 +1,51 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rados
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *RadosTestSuite) TestListWatchers() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	err := suite.ioctx.Create(oid, CreateExclusive)
+	ta.NoError(err)
+
+	watchers, err := suite.ioctx.ListWatchers(oid)
+	ta.NoError(err)
+	ta.Len(watchers, 0)
+
+	suite.T().Run("watched", func(t *testing.T) {
+		sub, err := suite.ioctx.Subscribe(oid)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, sub.Close()) }()
+
+		watchers, err := suite.ioctx.ListWatchers(oid)
+		assert.NoError(t, err)
+		if assert.Len(t, watchers, 1) {
+			w := watchers[0]
+			assert.EqualValues(t, suite.conn.GetInstanceID(), w.WatcherID)
+			assert.Equal(t, uint64(sub.cookie), w.Cookie)
+			assert.NotEmpty(t, w.Addr)
+			assert.NotZero(t, w.Timeout)
+		}
+	})
+
+	suite.T().Run("missingObject", func(t *testing.T) {
+		_, err := suite.ioctx.ListWatchers(suite.GenObjectName())
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	suite.T().Run("invalidIOContext", func(t *testing.T) {
+		ioctx := &IOContext{}
+		_, err := ioctx.ListWatchers(oid)
+		assert.Equal(t, ErrInvalidIOContext, err)
+	})
+}
@@ -112,7 +112,11 @@ func (w *WriteOp) RmOmapKeys(keys []string) {
 		roks.cNum)
 }
 
-// CleanOmap clears the omap `oid`.
+// CleanOmap clears the omap `oid`. All keys, along with the omap header, are
+// removed in a single step.
+//
+// Implements:
+//  void rados_write_op_omap_clear(rados_write_op_t write_op);
 func (w *WriteOp) CleanOmap() {
 	C.rados_write_op_omap_clear(w.op)
 }
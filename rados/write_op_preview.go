@@ -34,9 +34,27 @@ func (s *WriteOpCmpExtStep) free() {
 }
 
 func newWriteOpCmpExtStep() *WriteOpCmpExtStep {
-	return &WriteOpCmpExtStep{
+	s := &WriteOpCmpExtStep{
 		prval: (*C.int)(C.malloc(C.sizeof_int)),
 	}
+	// steps that were not reached because an earlier step failed keep this
+	// value
+	*s.prval = 0
+	return s
+}
+
+// Err returns the error of this step of a write operation that contains
+// multiple steps, or nil if the comparison succeeded or was not performed
+// because an earlier step failed. It is valid only after Operate() was
+// called. Note that librados only reports the results of individual steps
+// for comparisons; for other steps only the error of the whole operation is
+// known.
+//  PREVIEW
+func (s *WriteOpCmpExtStep) Err() error {
+	if s.Result == 0 {
+		return nil
+	}
+	return radosError(s.Result)
 }
 
 // CmpExt ensures that given object range (extent) satisfies comparison.
@@ -61,3 +79,232 @@ func (w *WriteOp) CmpExt(b []byte, offset uint64) *WriteOpCmpExtStep {
 
 	return cmpExtStep
 }
+
+// CmpOp is used to select how the value of an xattr is compared.
+type CmpOp C.uint8_t
+
+const (
+	// CmpOpEQ passes if the values are equal.
+	CmpOpEQ = CmpOp(C.LIBRADOS_CMPXATTR_OP_EQ)
+	// CmpOpNE passes if the values are not equal.
+	CmpOpNE = CmpOp(C.LIBRADOS_CMPXATTR_OP_NE)
+	// CmpOpGT passes if the given value is greater than the xattr value.
+	CmpOpGT = CmpOp(C.LIBRADOS_CMPXATTR_OP_GT)
+	// CmpOpGTE passes if the given value is greater than or equal to the
+	// xattr value.
+	CmpOpGTE = CmpOp(C.LIBRADOS_CMPXATTR_OP_GTE)
+	// CmpOpLT passes if the given value is less than the xattr value.
+	CmpOpLT = CmpOp(C.LIBRADOS_CMPXATTR_OP_LT)
+	// CmpOpLTE passes if the given value is less than or equal to the xattr
+	// value.
+	CmpOpLTE = CmpOp(C.LIBRADOS_CMPXATTR_OP_LTE)
+)
+
+// xattrStep keeps the C copies of an xattr name and value alive until the
+// operation is released.
+type xattrStep struct {
+	withRefs
+	withoutUpdate
+}
+
+// CmpXattr ensures that the given value satisfies the comparison against
+// the value of the object's xattr with the given name. The values are
+// compared as strings, with the given value on the left hand side of the
+// comparison. If the comparison fails the entire write operation is aborted
+// and no part of it is applied.
+//  PREVIEW
+//
+// Implements:
+//  void rados_write_op_cmpxattr(rados_write_op_t write_op,
+//                               const char *name,
+//                               uint8_t comparison_operator,
+//                               const char *value,
+//                               size_t value_len);
+func (w *WriteOp) CmpXattr(name string, op CmpOp, value []byte) {
+	s := &xattrStep{}
+	w.steps = append(w.steps, s)
+
+	cName := C.CString(name)
+	s.add(unsafe.Pointer(cName))
+	var cValue *C.char
+	if len(value) > 0 {
+		cValue = (*C.char)(C.CBytes(value))
+		s.add(unsafe.Pointer(cValue))
+	}
+
+	C.rados_write_op_cmpxattr(
+		w.op,
+		cName,
+		C.uint8_t(op),
+		cValue,
+		C.size_t(len(value)))
+}
+
+// WriteOpOmapCmpStep holds the result of the OmapCmp write operation.
+// Result is valid only after Operate() was called.
+type WriteOpOmapCmpStep struct {
+	withRefs
+
+	// C returned data:
+	prval *C.int
+
+	// Result of the OmapCmp write operation.
+	Result int
+}
+
+func newWriteOpOmapCmpStep() *WriteOpOmapCmpStep {
+	s := &WriteOpOmapCmpStep{
+		prval: (*C.int)(C.malloc(C.sizeof_int)),
+	}
+	// steps that were not reached because an earlier step failed keep this
+	// value
+	*s.prval = 0
+	return s
+}
+
+func (s *WriteOpOmapCmpStep) update() error {
+	s.Result = int(*s.prval)
+	return nil
+}
+
+func (s *WriteOpOmapCmpStep) free() {
+	C.free(unsafe.Pointer(s.prval))
+	s.prval = nil
+	s.withRefs.free()
+}
+
+// Err returns the error of the comparison, or nil if the comparison
+// succeeded or was not performed because an earlier step failed. It is valid
+// only after Operate() was called.
+//  PREVIEW
+func (s *WriteOpOmapCmpStep) Err() error {
+	if s.Result == 0 {
+		return nil
+	}
+	return radosError(s.Result)
+}
+
+// OmapCmp ensures that the given value satisfies the comparison against the
+// value of the omap key of the object. The values are compared as strings,
+// with the given value on the left hand side of the comparison. If the
+// comparison fails the entire write operation is aborted and the result of
+// the comparison is available from the returned step.
+//  PREVIEW
+//
+// Implements:
+//  void rados_write_op_omap_cmp(rados_write_op_t write_op,
+//                               const char *key,
+//                               uint8_t comparison_operator,
+//                               const char *val,
+//                               size_t val_len,
+//                               int *prval);
+func (w *WriteOp) OmapCmp(key string, op CmpOp, value []byte) *WriteOpOmapCmpStep {
+	s := newWriteOpOmapCmpStep()
+	w.steps = append(w.steps, s)
+
+	cKey := C.CString(key)
+	s.add(unsafe.Pointer(cKey))
+	var cValue *C.char
+	if len(value) > 0 {
+		cValue = (*C.char)(C.CBytes(value))
+		s.add(unsafe.Pointer(cValue))
+	}
+
+	C.rados_write_op_omap_cmp(
+		w.op,
+		cKey,
+		C.uint8_t(op),
+		cValue,
+		C.size_t(len(value)),
+		s.prval)
+	return s
+}
+
+// SetXattr sets the xattr with the given name on the object to value.
+//  PREVIEW
+//
+// Implements:
+//  void rados_write_op_setxattr(rados_write_op_t write_op,
+//                               const char *name,
+//                               const char *value,
+//                               size_t value_len);
+func (w *WriteOp) SetXattr(name string, value []byte) {
+	s := &xattrStep{}
+	w.steps = append(w.steps, s)
+
+	cName := C.CString(name)
+	s.add(unsafe.Pointer(cName))
+	var cValue *C.char
+	if len(value) > 0 {
+		cValue = (*C.char)(C.CBytes(value))
+		s.add(unsafe.Pointer(cValue))
+	}
+
+	C.rados_write_op_setxattr(w.op, cName, cValue, C.size_t(len(value)))
+}
+
+// RmXattr removes the xattr with the given name from the object.
+//  PREVIEW
+//
+// Implements:
+//  void rados_write_op_rmxattr(rados_write_op_t write_op,
+//                              const char *name);
+func (w *WriteOp) RmXattr(name string) {
+	s := &xattrStep{}
+	w.steps = append(w.steps, s)
+
+	cName := C.CString(name)
+	s.add(unsafe.Pointer(cName))
+
+	C.rados_write_op_rmxattr(w.op, cName)
+}
+
+// truncate sets the size of the object, discarding any data past size.
+//
+// Implements:
+//  void rados_write_op_truncate(rados_write_op_t write_op, uint64_t offset);
+func (w *WriteOp) truncate(size uint64) {
+	C.rados_write_op_truncate(w.op, C.uint64_t(size))
+}
+
+// append appends the data to the end of the object.
+//
+// Implements:
+//  void rados_write_op_append(rados_write_op_t write_op,
+//                             const char *buffer,
+//                             size_t len);
+func (w *WriteOp) append(b []byte) {
+	oe := newWriteStep(b, 0, 0)
+	w.steps = append(w.steps, oe)
+	C.rados_write_op_append(
+		w.op,
+		oe.cBuffer,
+		oe.cDataLen)
+}
+
+// WriteWithFlags writes the given byte slice at the offset within the object,
+// like Write, and applies the op flags, for example OpFlagFAdviseDontNeed, to
+// this write step only.
+//  PREVIEW
+//
+// Implements:
+//  void rados_write_op_write(rados_write_op_t write_op,
+//                            const char *buffer,
+//                            size_t len,
+//                            uint64_t offset);
+//  void rados_write_op_set_flags(rados_write_op_t write_op, int flags);
+func (w *WriteOp) WriteWithFlags(b []byte, offset uint64, flags OpFlags) {
+	w.Write(b, offset)
+	C.rados_write_op_set_flags(w.op, C.int(flags))
+}
+
+// AssertVersion ensures that the object exists and that its version matches
+// the given version. The operation fails with -ERANGE if the object is newer
+// and with -EOVERFLOW if it is older.
+//  PREVIEW
+//
+// Implements:
+//  void rados_write_op_assert_version(rados_write_op_t write_op, uint64_t ver);
+func (w *WriteOp) AssertVersion(version uint64) {
+	C.rados_write_op_assert_version(w.op, C.uint64_t(version))
+}
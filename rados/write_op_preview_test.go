@@ -38,3 +38,227 @@ func (suite *RadosTestSuite) TestWriteOpCmpExt() {
 	ta.Error(err)
 	ta.NotEqual(cmpExtRes2.Result, int(0))
 }
+
+func (suite *RadosTestSuite) TestWriteOpCmpExtStepErr() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	err := suite.ioctx.WriteFull(oid, []byte("0123456789"))
+	ta.NoError(err)
+
+	// the second of three comparisons fails
+	op := CreateWriteOp()
+	defer op.Release()
+	step1 := op.CmpExt([]byte("0123"), 0)
+	step2 := op.CmpExt([]byte("45x7"), 4)
+	step3 := op.CmpExt([]byte("89"), 8)
+	op.Write([]byte("changed"), 0)
+	err = op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.Error(err)
+	ta.NoError(step1.Err())
+	ta.Error(step2.Err())
+	ta.NoError(step3.Err())
+
+	// the write was not applied
+	buf := make([]byte, 16)
+	n, err := suite.ioctx.Read(oid, buf, 0)
+	ta.NoError(err)
+	ta.Equal("0123456789", string(buf[:n]))
+
+	// all steps pass
+	op2 := CreateWriteOp()
+	defer op2.Release()
+	step1 = op2.CmpExt([]byte("0123"), 0)
+	step2 = op2.CmpExt([]byte("4567"), 4)
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	ta.NoError(step1.Err())
+	ta.NoError(step2.Err())
+}
+
+func (suite *RadosTestSuite) TestWriteOpOmapCmpStepErr() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	err := suite.ioctx.SetOmap(oid, map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+	})
+	ta.NoError(err)
+
+	// the second comparison fails
+	op := CreateWriteOp()
+	defer op.Release()
+	step1 := op.OmapCmp("a", CmpOpEQ, []byte("1"))
+	step2 := op.OmapCmp("b", CmpOpEQ, []byte("x"))
+	op.SetOmap(map[string][]byte{"c": []byte("3")})
+	err = op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.Error(err)
+	ta.NoError(step1.Err())
+	ta.Error(step2.Err())
+
+	// the omap was not changed
+	values, err := suite.ioctx.GetOmapValues(oid, "", "", 10)
+	ta.NoError(err)
+	ta.Len(values, 2)
+
+	// all steps pass
+	op2 := CreateWriteOp()
+	defer op2.Release()
+	step1 = op2.OmapCmp("a", CmpOpEQ, []byte("1"))
+	step2 = op2.OmapCmp("b", CmpOpEQ, []byte("2"))
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+	ta.NoError(step1.Err())
+	ta.NoError(step2.Err())
+}
+
+func (suite *RadosTestSuite) TestWriteOpCmpXattr() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	err := suite.ioctx.WriteFull(oid, []byte("v5 data"))
+	ta.NoError(err)
+	err = suite.ioctx.SetXattr(oid, "version", []byte("5"))
+	ta.NoError(err)
+
+	gatedWrite := func(op CmpOp, value, data string) error {
+		wop := CreateWriteOp()
+		defer wop.Release()
+		wop.CmpXattr("version", op, []byte(value))
+		wop.WriteFull([]byte(data))
+		return wop.Operate(suite.ioctx, oid, OperationNoFlag)
+	}
+	content := func() string {
+		buf := make([]byte, 32)
+		n, err := suite.ioctx.Read(oid, buf, 0)
+		ta.NoError(err)
+		return string(buf[:n])
+	}
+
+	// equality
+	err = gatedWrite(CmpOpEQ, "4", "eq fail")
+	ta.Error(err)
+	ta.Equal("v5 data", content())
+	err = gatedWrite(CmpOpEQ, "5", "eq pass")
+	ta.NoError(err)
+	ta.Equal("eq pass", content())
+
+	// greater-than: the given value must be greater than the xattr value
+	err = gatedWrite(CmpOpGT, "5", "gt fail")
+	ta.Error(err)
+	ta.Equal("eq pass", content())
+	err = gatedWrite(CmpOpGT, "4", "gt fail")
+	ta.Error(err)
+	ta.Equal("eq pass", content())
+	err = gatedWrite(CmpOpGT, "6", "gt pass")
+	ta.NoError(err)
+	ta.Equal("gt pass", content())
+
+	// a missing xattr fails the comparison
+	wop := CreateWriteOp()
+	defer wop.Release()
+	wop.CmpXattr("nosuchxattr", CmpOpEQ, []byte("5"))
+	wop.WriteFull([]byte("missing"))
+	err = wop.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.Error(err)
+	ta.Equal("gt pass", content())
+}
+
+func (suite *RadosTestSuite) TestWriteOpSetRmXattr() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	op := CreateWriteOp()
+	defer op.Release()
+	op.Create(CreateIdempotent)
+	op.SetXattr("color", []byte("blue"))
+	op.SetXattr("shape", []byte("round"))
+	op.SetXattr("empty", nil)
+	err := op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+
+	xattrs, err := suite.ioctx.ListXattrs(oid)
+	ta.NoError(err)
+	ta.Equal(map[string][]byte{
+		"color": []byte("blue"),
+		"shape": []byte("round"),
+		"empty": {},
+	}, xattrs)
+
+	op2 := CreateWriteOp()
+	defer op2.Release()
+	op2.RmXattr("shape")
+	op2.SetXattr("color", []byte("green"))
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+
+	xattrs, err = suite.ioctx.ListXattrs(oid)
+	ta.NoError(err)
+	ta.Equal(map[string][]byte{
+		"color": []byte("green"),
+		"empty": {},
+	}, xattrs)
+}
+
+func (suite *RadosTestSuite) TestWriteOpAssertVersion() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	ta.NoError(suite.ioctx.WriteFull(oid, []byte("v1")))
+	version, err := suite.ioctx.GetLastVersion()
+	ta.NoError(err)
+
+	op := CreateWriteOp()
+	defer op.Release()
+	op.AssertVersion(version)
+	op.WriteFull([]byte("v2"))
+	ta.NoError(op.Operate(suite.ioctx, oid, OperationNoFlag))
+
+	// the object is now newer than version
+	op2 := CreateWriteOp()
+	defer op2.Release()
+	op2.AssertVersion(version)
+	op2.WriteFull([]byte("v3"))
+	ta.Equal(errRange, op2.operateCompat(suite.ioctx, oid))
+
+	op3 := CreateWriteOp()
+	defer op3.Release()
+	op3.AssertVersion(version + 1000)
+	op3.WriteFull([]byte("v3"))
+	ta.Equal(errOverflow, op3.operateCompat(suite.ioctx, oid))
+
+	buf := make([]byte, 8)
+	n, err := suite.ioctx.Read(oid, buf, 0)
+	ta.NoError(err)
+	ta.Equal("v2", string(buf[:n]))
+}
+
+func (suite *RadosTestSuite) TestWriteOpWriteWithFlags() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	op := CreateWriteOp()
+	defer op.Release()
+	op.Create(CreateIdempotent)
+	op.WriteWithFlags([]byte("cold data"), 0, OpFlagFAdviseDontNeed)
+	op.WriteWithFlags([]byte("uncached"), 16, OpFlagFAdviseNoCache)
+	op.Write([]byte("plain"), 32)
+	err := op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+
+	d := make([]byte, 64)
+	n, err := suite.ioctx.Read(oid, d, 0)
+	ta.NoError(err)
+	expected := make([]byte, 37)
+	copy(expected, "cold data")
+	copy(expected[16:], "uncached")
+	copy(expected[32:], "plain")
+	ta.Equal(expected, d[:n])
+}
@@ -1,6 +1,7 @@
 package rados
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,6 +36,47 @@ func (suite *RadosTestSuite) TestWriteOpCreate() {
 	})
 }
 
+func (suite *RadosTestSuite) TestWriteOpCreateWithData() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	op := CreateWriteOp()
+	defer op.Release()
+	op.Create(CreateExclusive)
+	op.WriteFull([]byte("first"))
+	err := op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+
+	// a second exclusive create must fail and the bundled write must not
+	// be applied
+	op2 := CreateWriteOp()
+	defer op2.Release()
+	op2.Create(CreateExclusive)
+	op2.WriteFull([]byte("second"))
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	if ta.IsType(OperationError{}, err) {
+		ta.Equal(ErrObjectExists, err.(OperationError).OpError)
+	}
+
+	data := make([]byte, 16)
+	n, err := suite.ioctx.Read(oid, data, 0)
+	ta.NoError(err)
+	ta.Equal("first", string(data[:n]))
+
+	// a non-exclusive create of the existing object succeeds
+	op3 := CreateWriteOp()
+	defer op3.Release()
+	op3.Create(CreateIdempotent)
+	op3.WriteFull([]byte("third"))
+	err = op3.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+
+	n, err = suite.ioctx.Read(oid, data, 0)
+	ta.NoError(err)
+	ta.Equal("third", string(data[:n]))
+}
+
 func (suite *RadosTestSuite) TestWriteOpCreateWithTimestamp() {
 	suite.SetupConnection()
 
@@ -157,6 +199,42 @@ func (suite *RadosTestSuite) TestWriteOpCleanOmap() {
 	ta.Equal("lab", string(fetched["dogbert"]))
 }
 
+func (suite *RadosTestSuite) TestWriteOpCleanOmapAllKeys() {
+	suite.SetupConnection()
+	ta := assert.New(suite.T())
+
+	oid := suite.GenObjectName()
+	pairs := map[string][]byte{}
+	for i := 0; i < 500; i++ {
+		pairs[fmt.Sprintf("key%04d", i)] = []byte(fmt.Sprintf("value%d", i))
+	}
+	op := CreateWriteOp()
+	defer op.Release()
+	op.Create(CreateIdempotent)
+	op.SetOmap(pairs)
+	err := op.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+
+	fetched, err := suite.ioctx.GetAllOmapValues(oid, "", "", 100)
+	ta.NoError(err)
+	ta.Len(fetched, 500)
+
+	// a single clear step removes every key
+	op2 := CreateWriteOp()
+	defer op2.Release()
+	op2.CleanOmap()
+	err = op2.Operate(suite.ioctx, oid, OperationNoFlag)
+	ta.NoError(err)
+
+	fetched, err = suite.ioctx.GetAllOmapValues(oid, "", "", 100)
+	ta.NoError(err)
+	ta.Len(fetched, 0)
+
+	// the object itself is not removed
+	_, err = suite.ioctx.Stat(oid)
+	ta.NoError(err)
+}
+
 func (suite *RadosTestSuite) TestWriteOpAssertExists() {
 	suite.SetupConnection()
 	ta := assert.New(suite.T())
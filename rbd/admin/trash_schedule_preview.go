@@ -0,0 +1,109 @@
+//go:build !nautilus && ceph_preview
+// +build !nautilus,ceph_preview
+
+package admin
+
+import (
+	ccom "github.com/ceph/go-ceph/common/commands"
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+// TrashPurgeScheduleAdmin encapsulates management functions for
+// ceph rbd trash purge schedules.
+type TrashPurgeScheduleAdmin struct {
+	conn ccom.MgrCommander
+}
+
+// TrashPurgeSchedule returns a TrashPurgeScheduleAdmin type for
+// managing ceph rbd trash purge schedules.
+//  PREVIEW
+func (ra *RBDAdmin) TrashPurgeSchedule() *TrashPurgeScheduleAdmin {
+	return &TrashPurgeScheduleAdmin{conn: ra.conn}
+}
+
+// Add a new trash purge schedule to the given pool or namespace based on
+// the supplied level spec.
+//  PREVIEW
+//
+// Similar To:
+//  rbd trash purge schedule add <level_spec> <interval> <start_time>
+func (tps *TrashPurgeScheduleAdmin) Add(l LevelSpec, i Interval, s StartTime) error {
+	m := map[string]string{
+		"prefix":     "rbd trash purge schedule add",
+		"level_spec": l.spec,
+		"format":     "json",
+	}
+	if i != NoInterval {
+		m["interval"] = string(i)
+	}
+	if s != NoStartTime {
+		m["start_time"] = string(s)
+	}
+	return commands.MarshalMgrCommand(tps.conn, m).NoData().End()
+}
+
+// TrashPurgeSchedule contains values representing an entire trash purge
+// schedule for a pool or namespace.
+type TrashPurgeSchedule struct {
+	Name        string
+	LevelSpecID string
+	Schedule    []ScheduleTerm
+}
+
+// List the trash purge schedules based on the supplied level spec.
+//  PREVIEW
+//
+// Similar To:
+//  rbd trash purge schedule list <level_spec>
+func (tps *TrashPurgeScheduleAdmin) List(l LevelSpec) ([]TrashPurgeSchedule, error) {
+	m := map[string]string{
+		"prefix":     "rbd trash purge schedule list",
+		"level_spec": l.spec,
+		"format":     "json",
+	}
+	return parseTrashPurgeScheduleList(
+		commands.MarshalMgrCommand(tps.conn, m))
+}
+
+func parseTrashPurgeScheduleList(res commands.Response) (
+	[]TrashPurgeSchedule, error) {
+
+	// the mgr reports trash purge schedules in the same form as mirror
+	// snapshot schedules
+	var ss snapshotScheduleMap
+	if err := res.NoStatus().Unmarshal(&ss).End(); err != nil {
+		return nil, err
+	}
+
+	var sched []TrashPurgeSchedule
+	for k, v := range ss {
+		sched = append(sched, TrashPurgeSchedule{
+			Name:        v.Name,
+			LevelSpecID: k,
+			Schedule:    v.Schedule,
+		})
+	}
+	return sched, nil
+}
+
+// Remove a trash purge schedule matching the supplied arguments.
+//  PREVIEW
+//
+// Similar To:
+//  rbd trash purge schedule remove <level_spec> <interval> <start_time>
+func (tps *TrashPurgeScheduleAdmin) Remove(
+	l LevelSpec, i Interval, s StartTime) error {
+
+	m := map[string]string{
+		"prefix":     "rbd trash purge schedule remove",
+		"level_spec": l.spec,
+		"format":     "json",
+	}
+	if i != NoInterval {
+		m["interval"] = string(i)
+	}
+	if s != NoStartTime {
+		m["start_time"] = string(s)
+	}
+	return commands.MarshalMgrCommand(tps.conn, m).NoData().End()
+}
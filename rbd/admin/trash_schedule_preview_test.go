@@ -0,0 +1,108 @@
+//go:build !nautilus && ceph_preview
+// +build !nautilus,ceph_preview
+
+package admin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ceph/go-ceph/internal/commands"
+)
+
+var tpsList1 = `
+{
+    "4": {
+        "name": "rbd/",
+        "schedule": [
+            {
+                "interval": "1d",
+                "start_time": null
+            }
+        ]
+    },
+    "4/ns1": {
+        "name": "rbd/ns1/",
+        "schedule": [
+            {
+                "interval": "6h",
+                "start_time": "02:00:00"
+            }
+        ]
+    }
+}
+`
+
+func TestParseTrashPurgeScheduleList(t *testing.T) {
+	t.Run("list1", func(t *testing.T) {
+		r := commands.NewResponse([]byte(tpsList1), "", nil)
+		l, err := parseTrashPurgeScheduleList(r)
+		assert.NoError(t, err)
+		if assert.Len(t, l, 2) {
+			s1 := l[0]
+			s2 := l[1]
+			if s1.Name != "rbd/" {
+				// the order of the map entries does not matter to the test
+				s1, s2 = s2, s1
+			}
+			assert.Equal(t, "rbd/", s1.Name)
+			assert.Equal(t, "4", s1.LevelSpecID)
+			if assert.Len(t, s1.Schedule, 1) {
+				assert.EqualValues(t, "1d", s1.Schedule[0].Interval)
+				assert.EqualValues(t, "", s1.Schedule[0].StartTime)
+			}
+
+			assert.Equal(t, "rbd/ns1/", s2.Name)
+			assert.Equal(t, "4/ns1", s2.LevelSpecID)
+			if assert.Len(t, s2.Schedule, 1) {
+				assert.EqualValues(t, "6h", s2.Schedule[0].Interval)
+				assert.EqualValues(t, "02:00:00", s2.Schedule[0].StartTime)
+			}
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		r := commands.NewResponse([]byte("{}"), "", nil)
+		l, err := parseTrashPurgeScheduleList(r)
+		assert.NoError(t, err)
+		assert.Len(t, l, 0)
+	})
+	t.Run("error", func(t *testing.T) {
+		r := commands.NewResponse([]byte{}, "", errors.New("yikes"))
+		l, err := parseTrashPurgeScheduleList(r)
+		assert.Error(t, err)
+		assert.Len(t, l, 0)
+	})
+}
+
+func TestTrashPurgeScheduleAddListRemove(t *testing.T) {
+	ensureDefaultPool(t)
+	ra := getAdmin(t)
+	scheduler := ra.TrashPurgeSchedule()
+	ls := NewLevelSpec(defaultPoolName, "", "")
+
+	err := scheduler.Add(ls, Interval("1d"), NoStartTime)
+	assert.NoError(t, err)
+	defer func() {
+		err = scheduler.Remove(ls, Interval("1d"), NoStartTime)
+		assert.NoError(t, err)
+
+		slist, err := scheduler.List(ls)
+		assert.NoError(t, err)
+		assert.Len(t, slist, 0)
+	}()
+
+	slist, err := scheduler.List(ls)
+	assert.NoError(t, err)
+	if assert.Len(t, slist, 1) {
+		assert.Equal(t, "rbd/", slist[0].Name)
+		if assert.Len(t, slist[0].Schedule, 1) {
+			assert.Equal(t, Interval("1d"), slist[0].Schedule[0].Interval)
+			assert.Equal(t, NoStartTime, slist[0].Schedule[0].StartTime)
+		}
+	}
+
+	err = scheduler.Add(ls, Interval("1d"), StartTime("henry"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #include <errno.h>
+import "C"
+
+import (
+	"errors"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// ErrDeepFlattenRequired is returned by CloneWithMaxDepth when the parent
+// needs to be flattened but lacks the deep-flatten feature. Without it
+// flattening an image does not detach its snapshots from their parent, so
+// it would not shorten the chain of a clone of one of the snapshots.
+var ErrDeepFlattenRequired = errors.New(
+	"RBD parent image lacks the deep-flatten feature")
+
+// CloneWithMaxDepth creates a clone of the image parentName from the named
+// snapshot like CloneImage, while keeping the number of ancestors of the new
+// clone at no more than maxDepth. If the parent is itself a clone with
+// maxDepth ancestors the parent is flattened first, which requires the
+// parent to have the deep-flatten feature.
+//  PREVIEW
+func CloneWithMaxDepth(ioctx *rados.IOContext, parentName, snapName string,
+	destctx *rados.IOContext, name string, rio *ImageOptions, maxDepth int) error {
+
+	if ioctx == nil || destctx == nil {
+		return ErrNoIOContext
+	}
+	if maxDepth < 1 {
+		return rbdError(C.EINVAL)
+	}
+
+	snap, err := OpenImageReadOnly(ioctx, parentName, snapName)
+	if err != nil {
+		return err
+	}
+	depth, err := snap.GetCloneDepth()
+	snap.Close()
+	if err != nil {
+		return err
+	}
+
+	if depth+1 > maxDepth {
+		if err := flattenImage(ioctx, parentName); err != nil {
+			return err
+		}
+	}
+	return CloneImage(ioctx, parentName, snapName, destctx, name, rio)
+}
+
+// flattenImage detaches the named image, including its snapshots, from its
+// parent.
+func flattenImage(ioctx *rados.IOContext, name string) error {
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer image.Close()
+
+	features, err := image.GetFeatures()
+	if err != nil {
+		return err
+	}
+	if features&FeatureDeepFlatten == 0 {
+		return ErrDeepFlattenRequired
+	}
+	return image.Flatten()
+}
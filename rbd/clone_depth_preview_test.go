@@ -0,0 +1,123 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/ceph/go-ceph/rados"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// imageCloneDepth returns the number of ancestors of the named image.
+func imageCloneDepth(t *testing.T, ioctx *rados.IOContext, name string) int {
+	image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+	depth, err := image.GetCloneDepth()
+	require.NoError(t, err)
+	return depth
+}
+
+// protectedSnapshot creates a protected snapshot of the named image and
+// returns a function removing it again.
+func protectedSnapshot(t *testing.T, ioctx *rados.IOContext, name, snapName string) func() {
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+	snap, err := image.CreateSnapshot(snapName)
+	require.NoError(t, err)
+	require.NoError(t, snap.Protect())
+	return func() {
+		image, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+		snap := image.GetSnapshot(snapName)
+		assert.NoError(t, snap.Unprotect())
+		assert.NoError(t, snap.Remove())
+	}
+}
+
+func TestCloneWithMaxDepth(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering|FeatureDeepFlatten))
+
+	base := GetUUID()
+	err = CreateImage(ioctx, base, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, base)) }()
+	defer protectedSnapshot(t, ioctx, base, "snap")()
+
+	// base <- clone1 <- clone2 stays within a depth of two
+	clone1 := GetUUID()
+	err = CloneWithMaxDepth(ioctx, base, "snap", ioctx, clone1, options, 2)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, clone1)) }()
+	defer protectedSnapshot(t, ioctx, clone1, "snap")()
+
+	clone2 := GetUUID()
+	err = CloneWithMaxDepth(ioctx, clone1, "snap", ioctx, clone2, options, 2)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, clone2)) }()
+	defer protectedSnapshot(t, ioctx, clone2, "snap")()
+	assert.Equal(t, 1, imageCloneDepth(t, ioctx, clone1))
+	assert.Equal(t, 2, imageCloneDepth(t, ioctx, clone2))
+
+	t.Run("flattensParent", func(t *testing.T) {
+		clone3 := GetUUID()
+		err := CloneWithMaxDepth(ioctx, clone2, "snap", ioctx, clone3, options, 2)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, clone3)) }()
+
+		// the intermediate image was flattened, clone1 is untouched
+		assert.Equal(t, 0, imageCloneDepth(t, ioctx, clone2))
+		assert.Equal(t, 1, imageCloneDepth(t, ioctx, clone3))
+		assert.Equal(t, 1, imageCloneDepth(t, ioctx, clone1))
+	})
+
+	t.Run("deepFlattenRequired", func(t *testing.T) {
+		plainOptions := NewRbdImageOptions()
+		defer plainOptions.Destroy()
+		assert.NoError(t,
+			plainOptions.SetUint64(ImageOptionFeatures, FeatureLayering))
+
+		plain := GetUUID()
+		err := CloneImage(ioctx, base, "snap", ioctx, plain, plainOptions)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, plain)) }()
+		defer protectedSnapshot(t, ioctx, plain, "snap")()
+
+		name := GetUUID()
+		err = CloneWithMaxDepth(ioctx, plain, "snap", ioctx, name, options, 1)
+		assert.Equal(t, ErrDeepFlattenRequired, err)
+		assert.Equal(t, 1, imageCloneDepth(t, ioctx, plain))
+	})
+
+	t.Run("invalidArgs", func(t *testing.T) {
+		name := GetUUID()
+		err := CloneWithMaxDepth(ioctx, base, "snap", ioctx, name, options, 0)
+		assert.Error(t, err)
+		err = CloneWithMaxDepth(nil, base, "snap", ioctx, name, options, 2)
+		assert.Equal(t, ErrNoIOContext, err)
+		err = CloneWithMaxDepth(ioctx, base, "missing", ioctx, name, options, 2)
+		assert.Error(t, err)
+	})
+}
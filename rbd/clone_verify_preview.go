@@ -0,0 +1,148 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd -lrados
+// #include <stdlib.h>
+// #include <rados/librados.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// extent is a byte range of an image.
+type extent struct {
+	offset uint64
+	length uint64
+}
+
+// unownedExtents returns the ranges of [0, end) not covered by the sorted
+// and non-overlapping owned extents.
+func unownedExtents(owned []extent, end uint64) []extent {
+	gaps := []extent{}
+	pos := uint64(0)
+	for _, e := range owned {
+		if e.offset >= end {
+			break
+		}
+		if e.offset > pos {
+			gaps = append(gaps, extent{pos, e.offset - pos})
+		}
+		if e.offset+e.length > pos {
+			pos = e.offset + e.length
+		}
+	}
+	if pos < end {
+		gaps = append(gaps, extent{pos, end - pos})
+	}
+	return gaps
+}
+
+// VerifyAgainstParent checks that the parts of a clone that were not
+// written to since it was cloned read the same as the parent image at the
+// named snapshot. If snap is empty the snapshot the clone was created from
+// is used. The ranges are compared in chunks of at most the object size of
+// the clone, and the offset of every chunk that differs is returned. A
+// healthy clone returns no mismatches. ErrNotFound is returned if the image
+// is not a clone.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_get_parent(rbd_image_t image,
+//                     rbd_linked_image_spec_t *parent_image,
+//                     rbd_snap_spec_t *parent_snap);
+//  int rbd_open_by_id_read_only(rados_ioctx_t io, const char *id,
+//                               rbd_image_t *image, const char *snap_name);
+func (image *Image) VerifyAgainstParent(snap string) ([]uint64, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+
+	parentImage := C.rbd_linked_image_spec_t{}
+	parentSnap := C.rbd_snap_spec_t{}
+	ret := C.rbd_get_parent(image.image, &parentImage, &parentSnap)
+	if ret != 0 {
+		return nil, getError(ret)
+	}
+	defer C.rbd_linked_image_spec_cleanup(&parentImage)
+	defer C.rbd_snap_spec_cleanup(&parentSnap)
+
+	cSnap := parentSnap.name
+	if snap != "" {
+		cSnap = C.CString(snap)
+		defer C.free(unsafe.Pointer(cSnap))
+	}
+
+	var ioctx C.rados_ioctx_t
+	cluster := C.rados_ioctx_get_cluster(cephIoctx(image.ioctx))
+	ret = C.rados_ioctx_create2(cluster, C.int64_t(parentImage.pool_id), &ioctx)
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	defer C.rados_ioctx_destroy(ioctx)
+	C.rados_ioctx_set_namespace(ioctx, parentImage.pool_namespace)
+
+	var parent C.rbd_image_t
+	ret = C.rbd_open_by_id_read_only(ioctx, parentImage.image_id, &parent, cSnap)
+	if ret < 0 {
+		return nil, getError(ret)
+	}
+	defer C.rbd_close(parent)
+
+	overlap, err := image.GetOverlap()
+	if err != nil {
+		return nil, err
+	}
+	info, err := image.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// everything the clone reports without its parent is owned by the
+	// clone itself, including discarded ranges that read as zeros
+	owned := []extent{}
+	err = image.DiffIterate(DiffIterateConfig{
+		Offset:        0,
+		Length:        overlap,
+		IncludeParent: ExcludeParent,
+		WholeObject:   DisableWholeObject,
+		Callback: func(offset, length uint64, _ int, _ interface{}) int {
+			owned = append(owned, extent{offset, length})
+			return 0
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chunkSize := info.Obj_size
+	cloneBuf := make([]byte, chunkSize)
+	parentBuf := make([]byte, chunkSize)
+	mismatches := []uint64{}
+	for _, gap := range unownedExtents(owned, overlap) {
+		for off := gap.offset; off < gap.offset+gap.length; {
+			// chunks end at object boundaries
+			n := chunkSize - off%chunkSize
+			if end := gap.offset + gap.length; off+n > end {
+				n = end - off
+			}
+			if _, err := image.ReadAt(cloneBuf[:n], int64(off)); err != nil {
+				return nil, err
+			}
+			ret := C.rbd_read(parent, C.uint64_t(off), C.size_t(n),
+				(*C.char)(unsafe.Pointer(&parentBuf[0])))
+			if ret < 0 {
+				return nil, getError(C.int(ret))
+			}
+			if uint64(ret) != n || !bytes.Equal(cloneBuf[:n], parentBuf[:n]) {
+				mismatches = append(mismatches, off)
+			}
+			off += n
+		}
+	}
+	return mismatches, nil
+}
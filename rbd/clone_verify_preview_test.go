@@ -0,0 +1,106 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnownedExtents(t *testing.T) {
+	assert.Equal(t, []extent{{0, 100}}, unownedExtents(nil, 100))
+	assert.Equal(t,
+		[]extent{{0, 10}, {20, 30}, {60, 40}},
+		unownedExtents([]extent{{10, 10}, {50, 10}}, 100))
+	assert.Equal(t,
+		[]extent{{10, 40}},
+		unownedExtents([]extent{{0, 10}, {50, 60}}, 100))
+	assert.Equal(t, []extent{}, unownedExtents([]extent{{0, 100}}, 100))
+}
+
+func TestVerifyAgainstParent(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering))
+
+	parentName := GetUUID()
+	err = CreateImage(ioctx, parentName, 4*testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, parentName)) }()
+
+	parent, err := OpenImage(ioctx, parentName, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, parent.Close()) }()
+	for i := uint64(0); i < 4; i++ {
+		_, err = parent.WriteAt([]byte("parent data"), int64(i*testImageSize))
+		require.NoError(t, err)
+	}
+
+	snapshot, err := parent.CreateSnapshot("snap1")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snapshot.Remove()) }()
+	require.NoError(t, snapshot.Protect())
+	defer func() { assert.NoError(t, snapshot.Unprotect()) }()
+
+	cloneName := GetUUID()
+	err = CloneImage(ioctx, parentName, "snap1", ioctx, cloneName, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, cloneName)) }()
+
+	clone, err := OpenImage(ioctx, cloneName, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, clone.Close()) }()
+
+	t.Run("healthy", func(t *testing.T) {
+		mismatches, err := clone.VerifyAgainstParent("")
+		assert.NoError(t, err)
+		assert.Len(t, mismatches, 0)
+
+		// data written to the clone is not compared
+		_, err = clone.WriteAt([]byte("clone data"), int64(testImageSize))
+		require.NoError(t, err)
+		mismatches, err = clone.VerifyAgainstParent("snap1")
+		assert.NoError(t, err)
+		assert.Len(t, mismatches, 0)
+	})
+
+	t.Run("otherSnapshot", func(t *testing.T) {
+		_, err := parent.WriteAt([]byte("changed"), int64(2*testImageSize))
+		require.NoError(t, err)
+		snapshot2, err := parent.CreateSnapshot("snap2")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, snapshot2.Remove()) }()
+
+		mismatches, err := clone.VerifyAgainstParent("snap2")
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{2 * testImageSize}, mismatches)
+	})
+
+	t.Run("notAClone", func(t *testing.T) {
+		_, err := parent.VerifyAgainstParent("")
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("missingSnapshot", func(t *testing.T) {
+		_, err := clone.VerifyAgainstParent("nope")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,173 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <rados/librados.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/retry"
+	"github.com/ceph/go-ceph/rados"
+)
+
+// configMetadataPrefix is the prefix librbd uses for metadata keys that
+// override configuration options.
+const configMetadataPrefix = "conf_"
+
+// ConfigSource indicates where the value of a configuration option comes
+// from.
+type ConfigSource int
+
+const (
+	// ConfigSourceConfig indicates the value comes from the global (Ceph)
+	// configuration.
+	ConfigSourceConfig = ConfigSource(C.RBD_CONFIG_SOURCE_CONFIG)
+	// ConfigSourcePool indicates the value is overridden at the pool level.
+	ConfigSourcePool = ConfigSource(C.RBD_CONFIG_SOURCE_POOL)
+	// ConfigSourceImage indicates the value is overridden at the image level.
+	ConfigSourceImage = ConfigSource(C.RBD_CONFIG_SOURCE_IMAGE)
+)
+
+// ConfigOption represents the name, value and source of an rbd
+// configuration option.
+type ConfigOption struct {
+	Name   string
+	Value  string
+	Source ConfigSource
+}
+
+func convertConfigOptions(cOptions []C.rbd_config_option_t) []ConfigOption {
+	options := make([]ConfigOption, len(cOptions))
+	for i := range cOptions {
+		options[i].Name = C.GoString(cOptions[i].name)
+		options[i].Value = C.GoString(cOptions[i].value)
+		options[i].Source = ConfigSource(cOptions[i].source)
+	}
+	return options
+}
+
+// ConfigList returns the rbd configuration options in effect for the image
+// along with the source of each value.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_config_image_list(rbd_image_t image,
+//                            rbd_config_option_t *options,
+//                            int *max_options);
+func (image *Image) ConfigList() ([]ConfigOption, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+
+	var (
+		cOptions []C.rbd_config_option_t
+		cSize    C.int
+		err      error
+	)
+	retry.WithSizes(256, 4096, func(size int) retry.Hint {
+		cSize = C.int(size)
+		cOptions = make([]C.rbd_config_option_t, cSize)
+		ret := C.rbd_config_image_list(
+			image.image,
+			(*C.rbd_config_option_t)(unsafe.Pointer(&cOptions[0])),
+			&cSize)
+		err = getErrorIfNegative(ret)
+		return retry.Size(int(cSize)).If(err == errRange)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	options := convertConfigOptions(cOptions[:cSize])
+	C.rbd_config_image_list_cleanup(
+		(*C.rbd_config_option_t)(unsafe.Pointer(&cOptions[0])),
+		cSize)
+	return options, nil
+}
+
+// RemoveConfig removes the image level override of the configuration option
+// named by key. The value of the option reverts to the pool level override,
+// if any, or the global configuration.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_metadata_remove(rbd_image_t image, const char *key);
+func (image *Image) RemoveConfig(key string) error {
+	return image.RemoveMetadata(configMetadataPrefix + key)
+}
+
+// PoolConfigList returns the rbd configuration options in effect for the
+// pool along with the source of each value. Images in the pool inherit these
+// values unless they are overridden at the image level.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_config_pool_list(rados_ioctx_t io_ctx,
+//                           rbd_config_option_t *options,
+//                           int *max_options);
+func PoolConfigList(ioctx *rados.IOContext) ([]ConfigOption, error) {
+	if ioctx == nil {
+		return nil, ErrNoIOContext
+	}
+
+	var (
+		cOptions []C.rbd_config_option_t
+		cSize    C.int
+		err      error
+	)
+	retry.WithSizes(256, 4096, func(size int) retry.Hint {
+		cSize = C.int(size)
+		cOptions = make([]C.rbd_config_option_t, cSize)
+		ret := C.rbd_config_pool_list(
+			cephIoctx(ioctx),
+			(*C.rbd_config_option_t)(unsafe.Pointer(&cOptions[0])),
+			&cSize)
+		err = getErrorIfNegative(ret)
+		return retry.Size(int(cSize)).If(err == errRange)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	options := convertConfigOptions(cOptions[:cSize])
+	C.rbd_config_pool_list_cleanup(
+		(*C.rbd_config_option_t)(unsafe.Pointer(&cOptions[0])),
+		cSize)
+	return options, nil
+}
+
+// PoolConfigGet returns the pool level override of the configuration option
+// named by key.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_pool_metadata_get(rados_ioctx_t io_ctx, const char *key, char *value, size_t *val_len);
+func PoolConfigGet(ioctx *rados.IOContext, key string) (string, error) {
+	return GetPoolMetadata(ioctx, configMetadataPrefix+key)
+}
+
+// PoolConfigSet sets a pool level override of the configuration option
+// named by key.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_pool_metadata_set(rados_ioctx_t io_ctx, const char *key, const char *value);
+func PoolConfigSet(ioctx *rados.IOContext, key, value string) error {
+	return SetPoolMetadata(ioctx, configMetadataPrefix+key, value)
+}
+
+// PoolConfigRemove removes the pool level override of the configuration
+// option named by key. The value of the option reverts to the global
+// configuration.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_pool_metadata_remove(rados_ioctx_t io_ctx, const char *key)
+func PoolConfigRemove(ioctx *rados.IOContext, key string) error {
+	return RemovePoolMetadata(ioctx, configMetadataPrefix+key)
+}
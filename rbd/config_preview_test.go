@@ -0,0 +1,165 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findConfigOption(options []ConfigOption, name string) (ConfigOption, bool) {
+	for _, o := range options {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return ConfigOption{}, false
+}
+
+func TestImageConfig(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	key := "rbd_qos_iops_limit"
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		image := GetImage(ioctx, name)
+		_, err := image.ConfigList()
+		assert.Equal(t, ErrImageNotOpen, err)
+		err = image.RemoveConfig(key)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	t.Run("setAndRemove", func(t *testing.T) {
+		image, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		err = image.SetMetadata(configMetadataPrefix+key, "100")
+		assert.NoError(t, err)
+		assert.NoError(t, image.Close())
+
+		image, err = OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+
+		cl, err := image.ConfigList()
+		assert.NoError(t, err)
+		o, found := findConfigOption(cl, key)
+		if assert.True(t, found) {
+			assert.Equal(t, "100", o.Value)
+			assert.Equal(t, ConfigSourceImage, o.Source)
+		}
+
+		err = image.RemoveConfig(key)
+		assert.NoError(t, err)
+
+		cl, err = image.ConfigList()
+		assert.NoError(t, err)
+		o, found = findConfigOption(cl, key)
+		if assert.True(t, found) {
+			assert.NotEqual(t, ConfigSourceImage, o.Source)
+		}
+
+		// removing an override that is not set fails
+		err = image.RemoveConfig(key)
+		assert.Error(t, err)
+	})
+}
+
+func TestPoolConfig(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	key := "rbd_qos_bps_limit"
+
+	t.Run("NullIOContext", func(t *testing.T) {
+		_, err := PoolConfigList(nil)
+		assert.Equal(t, ErrNoIOContext, err)
+		_, err = PoolConfigGet(nil, key)
+		assert.Equal(t, ErrNoIOContext, err)
+		err = PoolConfigSet(nil, key, "1")
+		assert.Equal(t, ErrNoIOContext, err)
+		err = PoolConfigRemove(nil, key)
+		assert.Equal(t, ErrNoIOContext, err)
+	})
+
+	t.Run("setGetRemove", func(t *testing.T) {
+		err := PoolConfigSet(ioctx, key, "2048000")
+		require.NoError(t, err)
+
+		v, err := PoolConfigGet(ioctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, "2048000", v)
+
+		cl, err := PoolConfigList(ioctx)
+		assert.NoError(t, err)
+		o, found := findConfigOption(cl, key)
+		if assert.True(t, found) {
+			assert.Equal(t, "2048000", o.Value)
+			assert.Equal(t, ConfigSourcePool, o.Source)
+		}
+
+		// a new image inherits the pool level value
+		name := GetUUID()
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		assert.NoError(t,
+			options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+		err = CreateImage(ioctx, name, testImageSize, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		cl, err = image.ConfigList()
+		assert.NoError(t, err)
+		assert.NoError(t, image.Close())
+		o, found = findConfigOption(cl, key)
+		if assert.True(t, found) {
+			assert.Equal(t, "2048000", o.Value)
+			assert.Equal(t, ConfigSourcePool, o.Source)
+		}
+
+		err = PoolConfigRemove(ioctx, key)
+		assert.NoError(t, err)
+
+		_, err = PoolConfigGet(ioctx, key)
+		assert.Error(t, err)
+
+		cl, err = PoolConfigList(ioctx)
+		assert.NoError(t, err)
+		o, found = findConfigOption(cl, key)
+		if assert.True(t, found) {
+			assert.Equal(t, ConfigSourceConfig, o.Source)
+		}
+	})
+}
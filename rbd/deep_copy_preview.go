@@ -0,0 +1,286 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd -lrados
+// #include <errno.h>
+// #include <stdlib.h>
+// #include <rados/librados.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"sort"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// DeepCopySnapshotsOptions controls the snapshots DeepCopySnapshots copies.
+type DeepCopySnapshotsOptions struct {
+	// FlattenSnaps makes the destination a standalone image rather than a
+	// clone of the parent of the source image.
+	FlattenSnaps bool
+	// Snapshots lists the names of the snapshots of the source image to
+	// keep on the destination. If it is nil all snapshots are kept.
+	Snapshots []string
+}
+
+// DeepCopySnapshots deep copies the image to a new image like DeepCopy, but
+// copies only the selected snapshots to the destination. As librbd always
+// copies the full snapshot history, a selective copy is built by creating
+// the destination and copying the changes up to every selected snapshot
+// before creating the same snapshot on the destination, and finally the
+// changes up to the current state of the image. If all snapshots are copied
+// the flatten option of rio is set for the duration of the call only.
+//  PREVIEW
+func (image *Image) DeepCopySnapshots(ioctx *rados.IOContext, destname string,
+	rio *ImageOptions, opts DeepCopySnapshotsOptions) error {
+
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if rio == nil {
+		return rbdError(C.EINVAL)
+	}
+
+	if opts.Snapshots == nil {
+		if opts.FlattenSnaps {
+			restore, err := setFlattenOption(rio)
+			if err != nil {
+				return err
+			}
+			defer restore()
+		}
+		return image.DeepCopy(ioctx, destname, rio)
+	}
+
+	snaps, err := image.GetSnapshotNames()
+	if err != nil {
+		return err
+	}
+	byName := map[string]SnapInfo{}
+	for _, s := range snaps {
+		byName[s.Name] = s
+	}
+	selected := make([]SnapInfo, 0, len(opts.Snapshots))
+	for _, name := range opts.Snapshots {
+		s, ok := byName[name]
+		if !ok {
+			return ErrSnapshotNotFound
+		}
+		selected = append(selected, s)
+	}
+	// the snapshots are recreated in the order they were taken
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Id < selected[j].Id
+	})
+
+	return image.copySnapshots(
+		ioctx, destname, rio, selected, opts.FlattenSnaps)
+}
+
+// copySnapshots creates the destination image and copies the snaps of the
+// image to it. Unless flatten is set a clone becomes a clone of the same
+// parent and only the data the image itself holds is copied. A destination
+// that could not be completed is removed again.
+func (image *Image) copySnapshots(ioctx *rados.IOContext, destname string,
+	rio *ImageOptions, snaps []SnapInfo, flatten bool) error {
+
+	cloned := false
+	if !flatten {
+		err := image.cloneParent(ioctx, destname, rio)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		cloned = err == nil
+	}
+	includeParent := ExcludeParent
+	if !cloned {
+		includeParent = IncludeParent
+		size, err := image.GetSize()
+		if err != nil {
+			return err
+		}
+		if err := CreateImage(ioctx, destname, size, rio); err != nil {
+			return err
+		}
+	}
+	if err := image.fillCopy(ioctx, destname, snaps, includeParent); err != nil {
+		_ = removeWithSnapshots(ioctx, destname)
+		return err
+	}
+	return nil
+}
+
+// fillCopy copies each of the snaps of the image, and then the current state
+// of the image, to the newly created destination image.
+func (image *Image) fillCopy(ioctx *rados.IOContext, destname string,
+	snaps []SnapInfo, includeParent DiffIncludeParent) error {
+
+	id, err := image.GetId()
+	if err != nil {
+		return err
+	}
+	dest, err := OpenImage(ioctx, destname, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	fromSnap := NoSnapshot
+	for _, s := range append(snaps, SnapInfo{Name: NoSnapshot}) {
+		src, err := OpenImageByIdReadOnly(image.ioctx, id, s.Name)
+		if err != nil {
+			return err
+		}
+		err = copyChanges(src, dest, fromSnap, includeParent)
+		src.Close()
+		if err != nil {
+			return err
+		}
+		if s.Name == NoSnapshot {
+			break
+		}
+		snap, err := dest.CreateSnapshot(s.Name)
+		if err != nil {
+			return err
+		}
+		protected, err := image.GetSnapshot(s.Name).IsProtected()
+		if err != nil {
+			return err
+		}
+		if protected {
+			if err := snap.Protect(); err != nil {
+				return err
+			}
+		}
+		fromSnap = s.Name
+	}
+	return nil
+}
+
+// copyChanges resizes dest to the size of src and applies the changes made
+// to src since fromSnap to it.
+func copyChanges(src, dest *Image, fromSnap string,
+	includeParent DiffIncludeParent) error {
+
+	size, err := src.GetSize()
+	if err != nil {
+		return err
+	}
+	destSize, err := dest.GetSize()
+	if err != nil {
+		return err
+	}
+	if size != destSize {
+		if err := dest.Resize(size); err != nil {
+			return err
+		}
+	}
+	return src.diffToCallback(fromSnap, false, includeParent,
+		func(e DiffExtent) error {
+			var err error
+			if e.Exists {
+				_, err = dest.WriteAt(e.Data, int64(e.Offset))
+			} else {
+				_, err = dest.Discard(e.Offset, e.Length)
+			}
+			return err
+		})
+}
+
+// cloneParent creates the destination image as a clone of the parent of the
+// image, from the same snapshot. ErrNotFound is returned if the image is not
+// a clone.
+//
+// Implements:
+//  int rbd_get_parent(rbd_image_t image,
+//                     rbd_linked_image_spec_t *parent_image,
+//                     rbd_snap_spec_t *parent_snap);
+//  int rbd_clone3(rados_ioctx_t p_ioctx, const char *p_name,
+//                 const char *p_snapname, rados_ioctx_t c_ioctx,
+//                 const char *c_name, rbd_image_options_t c_opts);
+func (image *Image) cloneParent(ioctx *rados.IOContext, destname string,
+	rio *ImageOptions) error {
+
+	parentImage := C.rbd_linked_image_spec_t{}
+	parentSnap := C.rbd_snap_spec_t{}
+	ret := C.rbd_get_parent(image.image, &parentImage, &parentSnap)
+	if ret != 0 {
+		return getError(ret)
+	}
+	defer C.rbd_linked_image_spec_cleanup(&parentImage)
+	defer C.rbd_snap_spec_cleanup(&parentSnap)
+
+	var parentIoctx C.rados_ioctx_t
+	cluster := C.rados_ioctx_get_cluster(cephIoctx(image.ioctx))
+	ret = C.rados_ioctx_create2(
+		cluster, C.int64_t(parentImage.pool_id), &parentIoctx)
+	if ret < 0 {
+		return getError(ret)
+	}
+	defer C.rados_ioctx_destroy(parentIoctx)
+	C.rados_ioctx_set_namespace(parentIoctx, parentImage.pool_namespace)
+
+	cDestName := C.CString(destname)
+	defer C.free(unsafe.Pointer(cDestName))
+	ret = C.rbd_clone3(
+		parentIoctx,
+		parentImage.image_name,
+		parentSnap.name,
+		cephIoctx(ioctx),
+		cDestName,
+		C.rbd_image_options_t(rio.options))
+	return getError(ret)
+}
+
+// removeWithSnapshots removes the named image along with its snapshots.
+func removeWithSnapshots(ioctx *rados.IOContext, name string) error {
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	snaps, err := img.GetSnapshotNames()
+	if err != nil {
+		img.Close()
+		return err
+	}
+	for _, s := range snaps {
+		snap := img.GetSnapshot(s.Name)
+		if protected, _ := snap.IsProtected(); protected {
+			_ = snap.Unprotect()
+		}
+		_ = snap.Remove()
+	}
+	if err := img.Close(); err != nil {
+		return err
+	}
+	return RemoveImage(ioctx, name)
+}
+
+// setFlattenOption enables the flatten option and returns a function that
+// puts the option back into its previous state.
+func setFlattenOption(rio *ImageOptions) (func(), error) {
+	wasSet, err := rio.IsSet(ImageOptionFlatten)
+	if err != nil {
+		return nil, err
+	}
+	var prev uint64
+	if wasSet {
+		if prev, err = rio.GetUint64(ImageOptionFlatten); err != nil {
+			return nil, err
+		}
+	}
+	if err := rio.SetUint64(ImageOptionFlatten, 1); err != nil {
+		return nil, err
+	}
+	return func() {
+		if wasSet {
+			_ = rio.SetUint64(ImageOptionFlatten, prev)
+		} else {
+			_ = rio.Unset(ImageOptionFlatten)
+		}
+	}, nil
+}
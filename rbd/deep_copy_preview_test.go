@@ -0,0 +1,203 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/ceph/go-ceph/rados"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeepCopySnapshots(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+
+	name := GetUUID()
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	for _, snapName := range []string{"one", "two"} {
+		_, err = image.WriteAt([]byte("data at "+snapName), 0)
+		require.NoError(t, err)
+		snap, err := image.CreateSnapshot(snapName)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, snap.Remove()) }()
+	}
+	// protected snapshots are copied as protected
+	require.NoError(t, image.GetSnapshot("one").Protect())
+	defer func() { assert.NoError(t, image.GetSnapshot("one").Unprotect()) }()
+
+	destSnaps := func(destname string) []string {
+		dest, err := OpenImage(ioctx, destname, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, dest.Close()) }()
+		snaps, err := dest.GetSnapshotNames()
+		require.NoError(t, err)
+		names := []string{}
+		for _, s := range snaps {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	t.Run("onlyOne", func(t *testing.T) {
+		for _, keep := range []string{"one", "two"} {
+			destname := GetUUID()
+			err := image.DeepCopySnapshots(ioctx, destname, options,
+				DeepCopySnapshotsOptions{Snapshots: []string{keep}})
+			require.NoError(t, err)
+			assert.Equal(t, []string{keep}, destSnaps(destname))
+
+			dest, err := OpenImageReadOnly(ioctx, destname, keep)
+			require.NoError(t, err)
+			buf := make([]byte, 11)
+			_, err = dest.ReadAt(buf, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, "data at "+keep, string(buf))
+			assert.NoError(t, dest.Close())
+
+			dest, err = OpenImage(ioctx, destname, NoSnapshot)
+			require.NoError(t, err)
+			_, err = dest.ReadAt(buf, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, "data at two", string(buf))
+			protected, err := dest.GetSnapshot(keep).IsProtected()
+			assert.NoError(t, err)
+			assert.Equal(t, keep == "one", protected)
+			assert.NoError(t, dest.Close())
+
+			cleanupDest(t, ioctx, destname)
+		}
+	})
+
+	t.Run("allSnapshots", func(t *testing.T) {
+		destname := GetUUID()
+		err := image.DeepCopySnapshots(ioctx, destname, options,
+			DeepCopySnapshotsOptions{FlattenSnaps: true})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"one", "two"}, destSnaps(destname))
+		cleanupDest(t, ioctx, destname)
+
+		// the flatten option is not left behind
+		isSet, err := options.IsSet(ImageOptionFlatten)
+		assert.NoError(t, err)
+		assert.False(t, isSet)
+	})
+
+	t.Run("noSnapshots", func(t *testing.T) {
+		destname := GetUUID()
+		err := image.DeepCopySnapshots(ioctx, destname, options,
+			DeepCopySnapshotsOptions{Snapshots: []string{}})
+		require.NoError(t, err)
+		assert.Empty(t, destSnaps(destname))
+		cleanupDest(t, ioctx, destname)
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		cloneName := GetUUID()
+		err := CloneImage(ioctx, name, "one", ioctx, cloneName, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, removeWithSnapshots(ioctx, cloneName)) }()
+		clone, err := OpenImage(ioctx, cloneName, NoSnapshot)
+		require.NoError(t, err)
+		for _, snapName := range []string{"c1", "c2"} {
+			_, err = clone.WriteAt([]byte("clone "+snapName), 0)
+			require.NoError(t, err)
+			_, err = clone.CreateSnapshot(snapName)
+			require.NoError(t, err)
+		}
+
+		for _, flatten := range []bool{false, true} {
+			destname := GetUUID()
+			err = clone.DeepCopySnapshots(ioctx, destname, options,
+				DeepCopySnapshotsOptions{
+					FlattenSnaps: flatten,
+					Snapshots:    []string{"c2"},
+				})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"c2"}, destSnaps(destname))
+
+			dest, err := OpenImageReadOnly(ioctx, destname, "c2")
+			require.NoError(t, err)
+			buf := make([]byte, 11)
+			_, err = dest.ReadAt(buf, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, "clone c2one", string(buf))
+			parent, err := dest.GetParent()
+			if flatten {
+				assert.Equal(t, ErrNotFound, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, name, parent.Image.ImageName)
+				assert.Equal(t, "one", parent.Snap.SnapName)
+			}
+			assert.NoError(t, dest.Close())
+			cleanupDest(t, ioctx, destname)
+		}
+		assert.NoError(t, clone.Close())
+	})
+
+	t.Run("existingDestination", func(t *testing.T) {
+		err := image.DeepCopySnapshots(ioctx, name, options,
+			DeepCopySnapshotsOptions{Snapshots: []string{"one"}})
+		assert.Error(t, err)
+		// the source is left alone
+		snaps, err := image.GetSnapshotNames()
+		assert.NoError(t, err)
+		assert.Len(t, snaps, 2)
+	})
+
+	t.Run("unknownSnapshot", func(t *testing.T) {
+		err := image.DeepCopySnapshots(ioctx, GetUUID(), options,
+			DeepCopySnapshotsOptions{Snapshots: []string{"three"}})
+		assert.Equal(t, ErrSnapshotNotFound, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := image.DeepCopySnapshots(ioctx, GetUUID(), nil,
+			DeepCopySnapshotsOptions{})
+		assert.Error(t, err)
+		err = GetImage(ioctx, name).DeepCopySnapshots(ioctx, GetUUID(),
+			options, DeepCopySnapshotsOptions{})
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
+
+// cleanupDest removes an image created by a deep copy along with any
+// snapshots it has.
+func cleanupDest(t *testing.T, ioctx *rados.IOContext, destname string) {
+	dest, err := OpenImage(ioctx, destname, NoSnapshot)
+	require.NoError(t, err)
+	snaps, err := dest.GetSnapshotNames()
+	assert.NoError(t, err)
+	for _, s := range snaps {
+		snap := dest.GetSnapshot(s.Name)
+		if protected, _ := snap.IsProtected(); protected {
+			assert.NoError(t, snap.Unprotect())
+		}
+		assert.NoError(t, snap.Remove())
+	}
+	assert.NoError(t, dest.Close())
+	assert.NoError(t, RemoveImage(ioctx, destname))
+}
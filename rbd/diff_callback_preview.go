@@ -0,0 +1,106 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// DiffExtent is a changed region of an image as reported by DiffToCallback.
+type DiffExtent struct {
+	// Offset is the position of the region in the image.
+	Offset uint64
+	// Length is the size of the region in bytes.
+	Length uint64
+	// Exists is false if the region is known to be all zeros, for example
+	// because it was discarded.
+	Exists bool
+	// Data is the content of the region, or nil if Exists is false. It is
+	// only valid until the callback returns.
+	Data []byte
+}
+
+// DiffToCallback calls cb for each region of the image that changed since
+// the snapshot fromSnap, or for each region holding data if fromSnap is
+// empty. Unlike DiffIterate the content of the region, as of the snapshot
+// the image is opened at, is passed to the callback along with its extent.
+// Regions holding data are read, and passed to the callback, in pieces of
+// at most 4MiB so that large regions are not held in memory at once.
+// If wholeObject is true the regions cover whole backing objects, which is
+// faster to determine for images using the fast-diff feature. Data of a
+// parent image is included. If the callback returns an error the iteration
+// stops and the error is returned.
+//  PREVIEW
+func (image *Image) DiffToCallback(
+	fromSnap string, wholeObject bool, cb func(extent DiffExtent) error) error {
+
+	return image.diffToCallback(fromSnap, wholeObject, IncludeParent, cb)
+}
+
+// diffToCallback implements DiffToCallback, with includeParent controlling
+// if data of a parent image is included.
+func (image *Image) diffToCallback(fromSnap string, wholeObject bool,
+	includeParent DiffIncludeParent, cb func(extent DiffExtent) error) error {
+
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	size, err := image.GetSize()
+	if err != nil {
+		return err
+	}
+
+	// the extents are collected first so that the image is not read from
+	// within the diff iterate callback
+	var extents []DiffExtent
+	config := DiffIterateConfig{
+		SnapName:      fromSnap,
+		Length:        size,
+		IncludeParent: includeParent,
+		WholeObject:   DisableWholeObject,
+		Callback: func(offset, length uint64, exists int, _ interface{}) int {
+			extents = append(extents, DiffExtent{
+				Offset: offset,
+				Length: length,
+				Exists: exists != 0,
+			})
+			return 0
+		},
+	}
+	if wholeObject {
+		config.WholeObject = EnableWholeObject
+	}
+	if err := image.DiffIterate(config); err != nil {
+		return err
+	}
+
+	var buf []byte
+	for _, e := range extents {
+		if !e.Exists {
+			if err := cb(e); err != nil {
+				return err
+			}
+			continue
+		}
+		if buf == nil {
+			buf = make([]byte, diffChunkSize)
+		}
+		for done := uint64(0); done < e.Length; {
+			data := buf
+			if left := e.Length - done; left < uint64(len(data)) {
+				data = data[:left]
+			}
+			if _, err := image.ReadAt(data, int64(e.Offset+done)); err != nil {
+				return err
+			}
+			err := cb(DiffExtent{
+				Offset: e.Offset + done,
+				Length: uint64(len(data)),
+				Exists: true,
+				Data:   data,
+			})
+			if err != nil {
+				return err
+			}
+			done += uint64(len(data))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffToCallback(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	isize := uint64(1 << 23) // 8MiB
+	iorder := 20             // 1MiB
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t, options.SetUint64(ImageOptionOrder, uint64(iorder)))
+	err = CreateImage(ioctx, name, isize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	collect := func(fromSnap string, wholeObject bool) []DiffExtent {
+		extents := []DiffExtent{}
+		err := img.DiffToCallback(fromSnap, wholeObject, func(e DiffExtent) error {
+			// the data is only valid during the callback
+			if e.Data != nil {
+				e.Data = append([]byte(nil), e.Data...)
+			}
+			extents = append(extents, e)
+			return nil
+		})
+		assert.NoError(t, err)
+		return extents
+	}
+
+	assert.Len(t, collect("", false), 0)
+
+	_, err = img.WriteAt([]byte("first extent"), 0)
+	require.NoError(t, err)
+	_, err = img.WriteAt([]byte("second extent"), 3<<20)
+	require.NoError(t, err)
+
+	t.Run("extents", func(t *testing.T) {
+		extents := collect("", false)
+		if assert.Len(t, extents, 2) {
+			assert.Equal(t, DiffExtent{
+				Offset: 0,
+				Length: 12,
+				Exists: true,
+				Data:   []byte("first extent"),
+			}, extents[0])
+			assert.Equal(t, DiffExtent{
+				Offset: 3 << 20,
+				Length: 13,
+				Exists: true,
+				Data:   []byte("second extent"),
+			}, extents[1])
+		}
+	})
+
+	t.Run("wholeObject", func(t *testing.T) {
+		extents := collect("", true)
+		if assert.Len(t, extents, 2) {
+			assert.EqualValues(t, 0, extents[0].Offset)
+			assert.True(t, bytes.HasPrefix(extents[0].Data, []byte("first extent")))
+			assert.EqualValues(t, 3<<20, extents[1].Offset)
+			assert.True(t, bytes.HasPrefix(extents[1].Data, []byte("second extent")))
+		}
+		for _, e := range extents {
+			assert.Len(t, e.Data, int(e.Length))
+		}
+	})
+
+	t.Run("fromSnapshot", func(t *testing.T) {
+		snap, err := img.CreateSnapshot("snap1")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, snap.Remove()) }()
+
+		_, err = img.WriteAt([]byte("changed"), 5<<20)
+		require.NoError(t, err)
+		_, err = img.Discard(0, 1<<20)
+		require.NoError(t, err)
+
+		extents := collect("snap1", false)
+		if assert.Len(t, extents, 2) {
+			assert.EqualValues(t, 0, extents[0].Offset)
+			assert.False(t, extents[0].Exists)
+			assert.Nil(t, extents[0].Data)
+			assert.Equal(t, DiffExtent{
+				Offset: 5 << 20,
+				Length: 7,
+				Exists: true,
+				Data:   []byte("changed"),
+			}, extents[1])
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := img.DiffToCallback("", false, func(e DiffExtent) error {
+			calls++
+			return errStop
+		})
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		data := bytes.Repeat([]byte("chunk"), (diffChunkSize+1<<20)/5)
+		_, err := img.WriteAt(data, 1<<20)
+		require.NoError(t, err)
+
+		var got []byte
+		for _, e := range collect("", false) {
+			assert.LessOrEqual(t, e.Length, uint64(diffChunkSize))
+			if e.Offset >= 1<<20 && e.Offset < 1<<20+uint64(len(data)) {
+				assert.EqualValues(t, 1<<20+len(got), e.Offset)
+				got = append(got, e.Data...)
+			}
+		}
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		err := GetImage(ioctx, name).DiffToCallback("", false,
+			func(DiffExtent) error { return nil })
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
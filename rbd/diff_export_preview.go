@@ -0,0 +1,292 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The rbd diff v1 stream format, as written by "rbd export-diff" and read by
+// "rbd import-diff", is a header line followed by tagged records. Integers
+// are little endian.
+const (
+	diffHeaderV1 = "rbd diff v1\n"
+
+	diffTagFromSnap = 'f' // le32 length, snapshot name
+	diffTagToSnap   = 't' // le32 length, snapshot name
+	diffTagSize     = 's' // le64 image size
+	diffTagWrite    = 'w' // le64 offset, le64 length, data
+	diffTagZero     = 'z' // le64 offset, le64 length
+	diffTagEnd      = 'e'
+
+	// diffChunkSize bounds the amount of image data held in memory for a
+	// single record, both when exporting and when applying a diff.
+	diffChunkSize = 1 << 22
+
+	// diffMaxNameLength bounds the length of snapshot names accepted from
+	// a diff stream.
+	diffMaxNameLength = 4096
+)
+
+var errInvalidDiff = errors.New("invalid rbd diff stream")
+
+// ExportDiff writes the changes made to the image between the snapshots
+// fromSnap and toSnap to w in the rbd diff v1 format used by the
+// "rbd export-diff" command. If fromSnap is empty the stream describes all
+// data up to toSnap, and if toSnap is empty the changes up to the current
+// state of the image are exported.
+//  PREVIEW
+func (image *Image) ExportDiff(w io.Writer, fromSnap, toSnap string) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	id, err := image.GetId()
+	if err != nil {
+		return err
+	}
+	src, err := OpenImageByIdReadOnly(image.ioctx, id, toSnap)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	size, err := src.GetSize()
+	if err != nil {
+		return err
+	}
+
+	dw := &diffWriter{w: bufio.NewWriter(w)}
+	dw.writeString(diffHeaderV1)
+	if fromSnap != "" {
+		dw.writeName(diffTagFromSnap, fromSnap)
+	}
+	if toSnap != "" {
+		dw.writeName(diffTagToSnap, toSnap)
+	}
+	dw.writeTag(diffTagSize)
+	dw.writeUint64(size)
+
+	err = src.DiffToCallback(fromSnap, false, func(e DiffExtent) error {
+		if e.Exists {
+			dw.writeTag(diffTagWrite)
+		} else {
+			dw.writeTag(diffTagZero)
+		}
+		dw.writeUint64(e.Offset)
+		dw.writeUint64(e.Length)
+		dw.write(e.Data)
+		return dw.err
+	})
+	if err != nil {
+		return err
+	}
+	dw.writeTag(diffTagEnd)
+	if dw.err != nil {
+		return dw.err
+	}
+	return dw.w.Flush()
+}
+
+// ImportDiff applies a stream in the rbd diff v1 format, as written by
+// ExportDiff or the "rbd export-diff" command, to the image. Like
+// "rbd import-diff" the image must have a snapshot named after the start
+// snapshot of the diff, if the diff has one, and a snapshot named after the
+// end snapshot of the diff is created once all changes have been applied.
+//  PREVIEW
+func (image *Image) ImportDiff(r io.Reader) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	dr := &diffReader{r: bufio.NewReader(r)}
+	header := make([]byte, len(diffHeaderV1))
+	dr.read(header)
+	if dr.err != nil || string(header) != diffHeaderV1 {
+		return errInvalidDiff
+	}
+
+	var (
+		toSnap string
+		data   = make([]byte, diffChunkSize)
+		zeros  = make([]byte, diffChunkSize)
+	)
+	for {
+		tag := dr.readTag()
+		if dr.err != nil {
+			return dr.err
+		}
+		switch tag {
+		case diffTagFromSnap:
+			name := dr.readName()
+			if dr.err != nil {
+				return dr.err
+			}
+			exists, err := image.SnapshotExists(name)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrSnapshotNotFound
+			}
+		case diffTagToSnap:
+			toSnap = dr.readName()
+			if dr.err != nil {
+				return dr.err
+			}
+			exists, err := image.SnapshotExists(toSnap)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrExist
+			}
+		case diffTagSize:
+			size := dr.readUint64()
+			if dr.err != nil {
+				return dr.err
+			}
+			current, err := image.GetSize()
+			if err != nil {
+				return err
+			}
+			if size != current {
+				if err := image.Resize(size); err != nil {
+					return err
+				}
+			}
+		case diffTagWrite, diffTagZero:
+			offset := dr.readUint64()
+			length := dr.readUint64()
+			if dr.err != nil {
+				return dr.err
+			}
+			buf := zeros
+			if tag == diffTagWrite {
+				buf = data
+			}
+			if err := image.importExtent(dr, tag, offset, length, buf); err != nil {
+				return err
+			}
+		case diffTagEnd:
+			if err := image.Flush(); err != nil {
+				return err
+			}
+			if toSnap != "" {
+				if _, err := image.CreateSnapshot(toSnap); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			return errInvalidDiff
+		}
+	}
+}
+
+// importExtent writes a single write or zero record of a diff stream to the
+// image using buf, which must be zero filled for zero records. Zeroed ranges
+// are written explicitly so that they hide any data a parent image holds for
+// the range.
+func (image *Image) importExtent(dr *diffReader, tag byte,
+	offset, length uint64, buf []byte) error {
+
+	for done := uint64(0); done < length; {
+		data := buf
+		if left := length - done; left < uint64(len(data)) {
+			data = data[:left]
+		}
+		if tag == diffTagWrite {
+			dr.read(data)
+			if dr.err != nil {
+				return dr.err
+			}
+		}
+		if _, err := image.WriteAt(data, int64(offset+done)); err != nil {
+			return err
+		}
+		done += uint64(len(data))
+	}
+	return nil
+}
+
+// diffWriter encodes diff stream records, remembering the first error.
+type diffWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (dw *diffWriter) write(b []byte) {
+	if dw.err == nil {
+		_, dw.err = dw.w.Write(b)
+	}
+}
+
+func (dw *diffWriter) writeString(s string) {
+	dw.write([]byte(s))
+}
+
+func (dw *diffWriter) writeTag(tag byte) {
+	dw.write([]byte{tag})
+}
+
+func (dw *diffWriter) writeUint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	dw.write(b[:])
+}
+
+func (dw *diffWriter) writeName(tag byte, name string) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(len(name)))
+	dw.writeTag(tag)
+	dw.write(b[:])
+	dw.writeString(name)
+}
+
+// diffReader decodes diff stream records, remembering the first error. A
+// stream ending before the end record is reported as errInvalidDiff.
+type diffReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (dr *diffReader) read(b []byte) {
+	if dr.err != nil {
+		return
+	}
+	_, err := io.ReadFull(dr.r, b)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = errInvalidDiff
+	}
+	dr.err = err
+}
+
+func (dr *diffReader) readTag() byte {
+	var b [1]byte
+	dr.read(b[:])
+	return b[0]
+}
+
+func (dr *diffReader) readUint64() uint64 {
+	var b [8]byte
+	dr.read(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (dr *diffReader) readName() string {
+	var b [4]byte
+	dr.read(b[:])
+	length := binary.LittleEndian.Uint32(b[:])
+	if dr.err == nil && length > diffMaxNameLength {
+		dr.err = errInvalidDiff
+	}
+	if dr.err != nil {
+		return ""
+	}
+	name := make([]byte, length)
+	dr.read(name)
+	return string(name)
+}
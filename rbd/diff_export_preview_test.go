@@ -0,0 +1,160 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readImage returns the full content of the image at the given snapshot.
+func readImage(t *testing.T, image *Image, snap string) []byte {
+	id, err := image.GetId()
+	require.NoError(t, err)
+	img, err := OpenImageByIdReadOnly(image.ioctx, id, snap)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	size, err := img.GetSize()
+	require.NoError(t, err)
+	data := make([]byte, size)
+	_, err = img.ReadAt(data, 0)
+	require.NoError(t, err)
+	return data
+}
+
+func TestExportImportDiff(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering))
+
+	name := GetUUID()
+	err = CreateImage(ioctx, name, 4*testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	for i := int64(0); i < 4; i++ {
+		_, err = image.WriteAt(bytes.Repeat([]byte("first"), 1000),
+			i*int64(testImageSize))
+		require.NoError(t, err)
+	}
+	snap1, err := image.CreateSnapshot("snap1")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snap1.Remove()) }()
+	require.NoError(t, snap1.Protect())
+	defer func() { assert.NoError(t, snap1.Unprotect()) }()
+
+	// overwrite, zero and extend the image
+	_, err = image.WriteAt([]byte("second"), 100)
+	require.NoError(t, err)
+	_, err = image.Discard(2*testImageSize, testImageSize)
+	require.NoError(t, err)
+	require.NoError(t, image.Resize(5*testImageSize))
+	_, err = image.WriteAt([]byte("tail"), int64(5*testImageSize-4))
+	require.NoError(t, err)
+	snap2, err := image.CreateSnapshot("snap2")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snap2.Remove()) }()
+
+	t.Run("incremental", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := image.ExportDiff(buf, "snap1", "snap2")
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte(diffHeaderV1)))
+
+		cloneName := GetUUID()
+		err = CloneImage(ioctx, name, "snap1", ioctx, cloneName, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, cloneName)) }()
+
+		clone, err := OpenImage(ioctx, cloneName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, clone.Close()) }()
+
+		// the start snapshot of the diff must exist on the target
+		err = clone.ImportDiff(bytes.NewReader(buf.Bytes()))
+		assert.Equal(t, ErrSnapshotNotFound, err)
+
+		cloneSnap1, err := clone.CreateSnapshot("snap1")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, cloneSnap1.Remove()) }()
+
+		err = clone.ImportDiff(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, clone.GetSnapshot("snap2").Remove()) }()
+
+		assert.Equal(t, readImage(t, image, "snap2"), readImage(t, clone, "snap2"))
+		assert.Equal(t, readImage(t, image, "snap2"), readImage(t, clone, NoSnapshot))
+
+		// the end snapshot of the diff must not exist yet
+		err = clone.ImportDiff(bytes.NewReader(buf.Bytes()))
+		assert.Equal(t, ErrExist, err)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := image.ExportDiff(buf, "", NoSnapshot)
+		require.NoError(t, err)
+
+		targetName := GetUUID()
+		err = CreateImage(ioctx, targetName, testImageSize, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, targetName)) }()
+
+		target, err := OpenImage(ioctx, targetName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, target.Close()) }()
+
+		err = target.ImportDiff(buf)
+		require.NoError(t, err)
+		assert.Equal(t, readImage(t, image, NoSnapshot), readImage(t, target, NoSnapshot))
+
+		snaps, err := target.GetSnapshotNames()
+		assert.NoError(t, err)
+		assert.Len(t, snaps, 0)
+	})
+
+	t.Run("invalidStream", func(t *testing.T) {
+		err := image.ImportDiff(bytes.NewReader([]byte("rbd diff v9\n")))
+		assert.Equal(t, errInvalidDiff, err)
+
+		err = image.ImportDiff(bytes.NewReader([]byte(diffHeaderV1 + "x")))
+		assert.Equal(t, errInvalidDiff, err)
+
+		// a truncated copy of the image content, applying it is harmless
+		buf := &bytes.Buffer{}
+		require.NoError(t, image.ExportDiff(buf, "", NoSnapshot))
+		truncated := buf.Bytes()[:buf.Len()/2]
+		err = image.ImportDiff(bytes.NewReader(truncated))
+		assert.Equal(t, errInvalidDiff, err)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		img := GetImage(ioctx, name)
+		assert.Equal(t, ErrImageNotOpen, img.ExportDiff(&bytes.Buffer{}, "", ""))
+		assert.Equal(t, ErrImageNotOpen, img.ImportDiff(&bytes.Buffer{}))
+	})
+}
@@ -0,0 +1,190 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #include <errno.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// featureDependencies lists, in a fixed order, the features that can only
+// be enabled on an image when another feature is enabled too.
+var featureDependencies = []struct {
+	feature  uint64
+	requires uint64
+}{
+	{FeatureObjectMap, FeatureExclusiveLock},
+	{FeatureFastDiff, FeatureObjectMap},
+	{FeatureJournaling, FeatureExclusiveLock},
+}
+
+// listImagesByFeatureWorkers bounds the number of images ListImagesByFeature
+// keeps open at the same time.
+const listImagesByFeatureWorkers = 8
+
+// featuresInternal are managed by librbd itself and can not be requested
+// when an image is created.
+var featuresInternal = []uint64{
+	FeatureDataPool,
+	FeatureOperations,
+	FeatureMigrating,
+}
+
+// GetFeatureNames returns the names of the features enabled on the rbd
+// image, for example "layering" or "exclusive-lock", in sorted order.
+//  PREVIEW
+func (image *Image) GetFeatureNames() ([]string, error) {
+	features, err := image.GetFeatures()
+	if err != nil {
+		return nil, err
+	}
+	fs := FeatureSet(features)
+	names := fs.Names()
+	sort.Strings(names)
+	return names, nil
+}
+
+// ValidateFeatures checks that the given feature bits form a combination
+// librbd accepts for a new image, for example that fast-diff is only used
+// together with object-map. A descriptive error is returned for the first
+// problem found, nil otherwise.
+//  PREVIEW
+func ValidateFeatures(features uint64) error {
+	if unknown := features &^ uint64(C.RBD_FEATURES_ALL); unknown != 0 {
+		return fmt.Errorf("unknown RBD feature bits 0x%x", unknown)
+	}
+	for _, f := range featuresInternal {
+		if features&f != 0 {
+			return fmt.Errorf("RBD feature %s can not be set explicitly",
+				featureName(f))
+		}
+	}
+	for _, d := range featureDependencies {
+		if features&d.feature != 0 && features&d.requires == 0 {
+			return fmt.Errorf("RBD feature %s requires feature %s",
+				featureName(d.feature), featureName(d.requires))
+		}
+	}
+	return nil
+}
+
+func featureName(bit uint64) string {
+	for name, b := range featureNameToBit {
+		if b == bit {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%x", bit)
+}
+
+// ListImagesByFeature returns the names of the images in the pool that have
+// all of the given feature bits enabled, or, if enabled is false, that have
+// none of them enabled. The images are opened read-only, a few at a time, to
+// read their features. Images that are removed while the features are being
+// read are left out of the result. The names are returned in sorted order.
+//  PREVIEW
+func ListImagesByFeature(ioctx *rados.IOContext, feature uint64, enabled bool) ([]string, error) {
+	var lock sync.Mutex
+	matching := []string{}
+	err := forEachImage(ioctx, listImagesByFeatureWorkers, func(name string) error {
+		features, err := imageFeatures(ioctx, name)
+		if err != nil {
+			return err
+		}
+		if enabled && features&feature == feature ||
+			!enabled && features&feature == 0 {
+
+			lock.Lock()
+			defer lock.Unlock()
+			matching = append(matching, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matching)
+	return matching, nil
+}
+
+func imageFeatures(ioctx *rados.IOContext, name string) (uint64, error) {
+	image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+	if err != nil {
+		return 0, err
+	}
+	defer image.Close()
+	return image.GetFeatures()
+}
+
+// FeaturesInUseError is returned by DisableFeatures if features can not be
+// disabled because another client holds the exclusive lock of the image.
+type FeaturesInUseError struct {
+	// Features are the features that were to be disabled.
+	Features uint64
+	// Owners are the clients holding the exclusive lock.
+	Owners []string
+}
+
+// Error returns a string describing the features in use.
+//  PREVIEW
+func (e *FeaturesInUseError) Error() string {
+	return fmt.Sprintf(
+		"rbd: can not disable features 0x%x: image is locked by %s",
+		e.Features, strings.Join(e.Owners, ", "))
+}
+
+// ErrorCode returns -EBUSY.
+//  PREVIEW
+func (e *FeaturesInUseError) ErrorCode() int {
+	return -int(C.EBUSY)
+}
+
+// checkFeaturesInUse returns a FeaturesInUseError if disabling the features
+// requires the exclusive lock of the image and another client holds it.
+//
+// Implements:
+//  int rbd_is_exclusive_lock_owner(rbd_image_t image, int *is_owner);
+func (image *Image) checkFeaturesInUse(features uint64) error {
+	if features&FeatureExclusiveLock == 0 {
+		return nil
+	}
+	var cOwner C.int
+	if ret := C.rbd_is_exclusive_lock_owner(image.image, &cOwner); ret < 0 {
+		return getError(ret)
+	}
+	if cOwner != 0 {
+		return nil
+	}
+	_, owners, err := image.getLockOwners()
+	if err != nil {
+		return err
+	}
+	if len(owners) > 0 {
+		return &FeaturesInUseError{Features: features, Owners: owners}
+	}
+	return nil
+}
+
+// DisableFeatures disables the given features on the image, like
+// UpdateFeatures with enabled set to false. Disabling the exclusive-lock
+// feature while another client holds the exclusive lock of the image fails
+// with a FeaturesInUseError before the image is changed.
+//  PREVIEW
+func (image *Image) DisableFeatures(features uint64) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if err := image.checkFeaturesInUse(features); err != nil {
+		return err
+	}
+	return image.UpdateFeatures(features, false)
+}
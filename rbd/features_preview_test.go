@@ -0,0 +1,249 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFeatureNames(t *testing.T) {
+	conn := radosConnect(t)
+	require.NotNil(t, conn)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	t.Run("defaultFeatures", func(t *testing.T) {
+		name := GetUUID()
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		err := CreateImage(ioctx, name, testImageSize, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+
+		names, err := image.GetFeatureNames()
+		assert.NoError(t, err)
+		// the default feature set of the rbd_default_features option
+		assert.Equal(t, []string{
+			FeatureNameDeepFlatten,
+			FeatureNameExclusiveLock,
+			FeatureNameFastDiff,
+			FeatureNameLayering,
+			FeatureNameObjectMap,
+		}, names)
+	})
+
+	t.Run("explicitFeatures", func(t *testing.T) {
+		name := GetUUID()
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		err := options.SetUint64(ImageOptionFeatures,
+			FeatureLayering|FeatureExclusiveLock|FeatureJournaling)
+		require.NoError(t, err)
+		err = CreateImage(ioctx, name, testImageSize, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+
+		names, err := image.GetFeatureNames()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			FeatureNameExclusiveLock,
+			FeatureNameJournaling,
+			FeatureNameLayering,
+		}, names)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		image := GetImage(ioctx, GetUUID())
+		_, err := image.GetFeatureNames()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
+
+func TestValidateFeatures(t *testing.T) {
+	valid := []uint64{
+		0,
+		FeatureLayering,
+		FeatureLayering | FeatureExclusiveLock,
+		FeatureExclusiveLock | FeatureObjectMap,
+		FeatureExclusiveLock | FeatureObjectMap | FeatureFastDiff,
+		FeatureExclusiveLock | FeatureJournaling,
+		FeatureLayering | FeatureStripingV2 | FeatureDeepFlatten,
+	}
+	for _, features := range valid {
+		assert.NoError(t, ValidateFeatures(features), "features: 0x%x", features)
+	}
+
+	invalid := []struct {
+		features uint64
+		message  string
+	}{
+		{
+			FeatureObjectMap,
+			"RBD feature object-map requires feature exclusive-lock",
+		},
+		{
+			FeatureExclusiveLock | FeatureFastDiff,
+			"RBD feature fast-diff requires feature object-map",
+		},
+		{
+			FeatureObjectMap | FeatureFastDiff,
+			"RBD feature object-map requires feature exclusive-lock",
+		},
+		{
+			FeatureLayering | FeatureJournaling,
+			"RBD feature journaling requires feature exclusive-lock",
+		},
+		{
+			FeatureLayering | FeatureDataPool,
+			"RBD feature data-pool can not be set explicitly",
+		},
+		{
+			FeatureOperations,
+			"RBD feature operations can not be set explicitly",
+		},
+		{
+			FeatureMigrating,
+			"RBD feature migrating can not be set explicitly",
+		},
+		{
+			FeatureLayering | 1<<62,
+			"unknown RBD feature bits 0x4000000000000000",
+		},
+	}
+	for _, tc := range invalid {
+		err := ValidateFeatures(tc.features)
+		if assert.Error(t, err, "features: 0x%x", tc.features) {
+			assert.Equal(t, tc.message, err.Error())
+		}
+	}
+}
+
+func TestListImagesByFeature(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	_, err = ListImagesByFeature(nil, FeatureJournaling, true)
+	assert.Equal(t, ErrNoIOContext, err)
+
+	create := func(features uint64) string {
+		name := GetUUID()
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		assert.NoError(t,
+			options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+		require.NoError(t, options.SetUint64(ImageOptionFeatures, features))
+		err := CreateImage(ioctx, name, testImageSize, options)
+		require.NoError(t, err)
+		return name
+	}
+	journaled := create(FeatureLayering | FeatureExclusiveLock | FeatureJournaling)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, journaled)) }()
+	locked := create(FeatureLayering | FeatureExclusiveLock)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, locked)) }()
+	plain := create(FeatureLayering)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, plain)) }()
+
+	names, err := ListImagesByFeature(ioctx, FeatureJournaling, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{journaled}, names)
+
+	names, err = ListImagesByFeature(ioctx, FeatureJournaling, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{locked, plain}, names)
+
+	// all of the given features must be enabled
+	names, err = ListImagesByFeature(ioctx, FeatureExclusiveLock|FeatureJournaling, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{journaled}, names)
+
+	names, err = ListImagesByFeature(ioctx, FeatureExclusiveLock, true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{journaled, locked}, names)
+}
+
+func TestDisableFeaturesInUse(t *testing.T) {
+	conn := radosConnect(t)
+	require.NotNil(t, conn)
+	defer conn.Shutdown()
+	conn2 := radosConnect(t)
+	require.NotNil(t, conn2)
+	defer conn2.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+	ioctx2, err := conn2.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx2.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	err = options.SetUint64(ImageOptionFeatures, FeatureLayering|FeatureExclusiveLock)
+	require.NoError(t, err)
+	err = CreateImage(ioctx, name, 16*1024*1024, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	owner, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	// writing acquires the exclusive lock
+	_, err = owner.WriteAt([]byte("locked"), 0)
+	require.NoError(t, err)
+
+	other, err := OpenImage(ioctx2, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, other.Close()) }()
+
+	err = other.DisableFeatures(FeatureExclusiveLock)
+	if assert.Error(t, err) {
+		inUse, ok := err.(*FeaturesInUseError)
+		if assert.True(t, ok, "unexpected error type %T", err) {
+			assert.Equal(t, FeatureExclusiveLock, inUse.Features)
+			assert.Len(t, inUse.Owners, 1)
+			assert.Equal(t, -int(syscall.EBUSY), inUse.ErrorCode())
+		}
+	}
+	features, err := other.GetFeatures()
+	assert.NoError(t, err)
+	assert.Equal(t, FeatureExclusiveLock, features&FeatureExclusiveLock)
+
+	// the lock owner itself may disable the feature
+	err = owner.DisableFeatures(FeatureExclusiveLock)
+	assert.NoError(t, err)
+	assert.NoError(t, owner.Close())
+}
@@ -15,7 +15,8 @@ import (
 	"github.com/ceph/go-ceph/rados"
 )
 
-// GroupCreate is used to create an image group.
+// GroupCreate is used to create an image group. The group is created in
+// the namespace the IO context is set to.
 //
 // Implements:
 //  int rbd_group_create(rados_ioctx_t p, const char *name);
@@ -54,7 +55,8 @@ func GroupRename(ioctx *rados.IOContext, src, dest string) error {
 	return getError(ret)
 }
 
-// GroupList returns a slice of image group names.
+// GroupList returns a slice of image group names. Only the groups in the
+// namespace the IO context is set to are returned.
 //
 // Implements:
 //  int rbd_group_list(rados_ioctx_t p, char *names, size_t *size);
@@ -217,6 +217,42 @@ func TestGroupSnapshots(t *testing.T) {
 		err = GroupSnapRemove(ioctx, gname, snapname)
 		assert.NoError(t, err)
 	})
+	t.Run("groupSnapRollbackWithProgressAbort", func(t *testing.T) {
+		snapname := "snap3r"
+		err := GroupSnapCreate(ioctx, gname, snapname)
+		assert.NoError(t, err)
+		defer func() { assert.NoError(t, GroupSnapRemove(ioctx, gname, snapname)) }()
+
+		img, err := OpenImage(ioctx, name1, NoSnapshot)
+		assert.NoError(t, err)
+		_, err = img.WriteAt([]byte("NOT ROLLED BACK"), 0)
+		assert.NoError(t, err)
+		err = img.Close()
+		assert.NoError(t, err)
+
+		cc := 0
+		cb := func(offset, total uint64, v interface{}) int {
+			cc++
+			return -1
+		}
+		err = GroupSnapRollbackWithProgress(ioctx, gname, snapname, cb, nil)
+		assert.Error(t, err)
+		assert.GreaterOrEqual(t, cc, 1)
+
+		// a later rollback without aborting succeeds
+		cb = func(offset, total uint64, v interface{}) int { return 0 }
+		err = GroupSnapRollbackWithProgress(ioctx, gname, snapname, cb, nil)
+		assert.NoError(t, err)
+
+		b := make([]byte, 8)
+		img, err = OpenImage(ioctx, name1, NoSnapshot)
+		assert.NoError(t, err)
+		_, err = img.ReadAt(b, 0)
+		assert.NoError(t, err)
+		err = img.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("SAY CHEE"), b)
+	})
 	t.Run("invalidIOContext", func(t *testing.T) {
 		assert.Panics(t, func() {
 			GroupSnapCreate(nil, gname, "foo")
@@ -375,3 +375,80 @@ func TestGroupImageGetGroup(t *testing.T) {
 		})
 	})
 }
+
+func TestGroupNamespace(t *testing.T) {
+	conn := radosConnect(t)
+	require.NotNil(t, conn)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	nsIoctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer nsIoctx.Destroy()
+
+	ns := "grns"
+	err = NamespaceCreate(ioctx, ns)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, NamespaceRemove(ioctx, ns)) }()
+	nsIoctx.SetNamespace(ns)
+
+	err = GroupCreate(nsIoctx, "nsgroup")
+	require.NoError(t, err)
+	err = GroupCreate(ioctx, "defgroup")
+	require.NoError(t, err)
+
+	groups, err := GroupList(nsIoctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nsgroup"}, groups)
+	groups, err = GroupList(ioctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"defgroup"}, groups)
+
+	// the same group name can exist in both namespaces
+	err = GroupCreate(ioctx, "nsgroup")
+	assert.NoError(t, err)
+	err = GroupRemove(ioctx, "nsgroup")
+	assert.NoError(t, err)
+
+	name := GetUUID()
+	err = quickCreate(nsIoctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+
+	err = GroupImageAdd(nsIoctx, "nsgroup", nsIoctx, name)
+	assert.NoError(t, err)
+	// the group is not visible from the default namespace
+	err = GroupImageAdd(ioctx, "nsgroup", nsIoctx, name)
+	assert.Error(t, err)
+	_, err = GroupImageList(ioctx, "nsgroup")
+	assert.Error(t, err)
+
+	gimgs, err := GroupImageList(nsIoctx, "nsgroup")
+	assert.NoError(t, err)
+	if assert.Len(t, gimgs, 1) {
+		assert.Equal(t, name, gimgs[0].Name)
+	}
+
+	img, err := OpenImage(nsIoctx, name, NoSnapshot)
+	require.NoError(t, err)
+	info, err := img.GetGroup()
+	assert.NoError(t, err)
+	assert.Equal(t, "nsgroup", info.Name)
+	assert.NoError(t, img.Close())
+
+	err = GroupImageRemove(nsIoctx, "nsgroup", nsIoctx, name)
+	assert.NoError(t, err)
+	assert.NoError(t, RemoveImage(nsIoctx, name))
+
+	err = GroupRemove(nsIoctx, "nsgroup")
+	assert.NoError(t, err)
+	err = GroupRemove(ioctx, "defgroup")
+	assert.NoError(t, err)
+}
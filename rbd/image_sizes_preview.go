@@ -0,0 +1,47 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"sync"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// imageSizesWorkers bounds the number of images ImageSizes keeps open at
+// the same time.
+const imageSizesWorkers = 8
+
+// ImageSizes returns the size, in bytes, of every image in the pool keyed
+// by image name. The images are opened read-only, a few at a time, to read
+// their size. Images that are removed while the sizes are being collected
+// are left out of the result.
+//  PREVIEW
+func ImageSizes(ioctx *rados.IOContext) (map[string]uint64, error) {
+	var lock sync.Mutex
+	sizes := map[string]uint64{}
+	err := forEachImage(ioctx, imageSizesWorkers, func(name string) error {
+		size, err := imageSize(ioctx, name)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		sizes[name] = size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+func imageSize(ioctx *rados.IOContext, name string) (uint64, error) {
+	image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+	if err != nil {
+		return 0, err
+	}
+	defer image.Close()
+	return image.GetSize()
+}
@@ -0,0 +1,115 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/ceph/go-ceph/rados"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeSizedImages creates count images in the pool, each a multiple of
+// testImageSize in size, and returns the expected sizes by name.
+func makeSizedImages(t testing.TB, ioctx *rados.IOContext, count int) map[string]uint64 {
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	require.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+
+	expected := map[string]uint64{}
+	for i := 0; i < count; i++ {
+		name := GetUUID()
+		size := uint64(testImageSize) * uint64(i%4+1)
+		require.NoError(t, CreateImage(ioctx, name, size, options))
+		expected[name] = size
+	}
+	return expected
+}
+
+func TestImageSizes(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	t.Run("noIOContext", func(t *testing.T) {
+		_, err := ImageSizes(nil)
+		assert.Equal(t, ErrNoIOContext, err)
+	})
+
+	t.Run("emptyPool", func(t *testing.T) {
+		sizes, err := ImageSizes(ioctx)
+		assert.NoError(t, err)
+		assert.Len(t, sizes, 0)
+	})
+
+	t.Run("severalImages", func(t *testing.T) {
+		expected := makeSizedImages(t, ioctx, 12)
+		defer func() {
+			for name := range expected {
+				assert.NoError(t, RemoveImage(ioctx, name))
+			}
+		}()
+
+		sizes, err := ImageSizes(ioctx)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, sizes)
+	})
+}
+
+func benchmarkImageSizes(b *testing.B, f func(*rados.IOContext) (map[string]uint64, error)) {
+	conn, err := rados.NewConn()
+	require.NoError(b, err)
+	require.NoError(b, conn.ReadDefaultConfigFile())
+	require.NoError(b, conn.Connect())
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err = conn.MakePool(poolname)
+	require.NoError(b, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(b, err)
+	defer ioctx.Destroy()
+
+	expected := makeSizedImages(b, ioctx, 32)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sizes, err := f(ioctx)
+		require.NoError(b, err)
+		require.Len(b, sizes, len(expected))
+	}
+}
+
+func BenchmarkImageSizes(b *testing.B) {
+	benchmarkImageSizes(b, ImageSizes)
+}
+
+func BenchmarkImageSizesSerial(b *testing.B) {
+	benchmarkImageSizes(b, func(ioctx *rados.IOContext) (map[string]uint64, error) {
+		names, err := GetImageNames(ioctx)
+		if err != nil {
+			return nil, err
+		}
+		sizes := map[string]uint64{}
+		for _, name := range names {
+			size, err := imageSize(ioctx, name)
+			if err != nil {
+				return nil, err
+			}
+			sizes[name] = size
+		}
+		return sizes, nil
+	})
+}
@@ -0,0 +1,277 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #include <errno.h>
+import "C"
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+const (
+	journalClass      = "journal"
+	journalObjPrefix  = "journal."
+	journalMaxClients = 1024
+
+	// the configuration option selecting the pool of the journal objects
+	journalPoolConfig = "rbd_journal_pool"
+
+	// the ID of the journal client registered by the image itself
+	journalImageClientID = ""
+	// the client meta type of the journal client of the image
+	journalImageClientMetaType = 0
+)
+
+var errJournalDecode = errors.New("failed to decode journal data")
+
+// JournalClientState indicates if a journal client is connected.
+type JournalClientState uint8
+
+const (
+	// JournalClientConnected indicates the client is connected.
+	JournalClientConnected = JournalClientState(0)
+	// JournalClientDisconnected indicates the client is disconnected, for
+	// example because it fell too far behind.
+	JournalClientDisconnected = JournalClientState(1)
+)
+
+// JournalObjectPosition is the position of a journal entry.
+type JournalObjectPosition struct {
+	ObjectNumber uint64
+	TagTID       uint64
+	EntryTID     uint64
+}
+
+// JournalClient describes a client registered with the journal of an image,
+// such as the image itself or an rbd-mirror peer.
+type JournalClient struct {
+	ID             string
+	State          JournalClientState
+	CommitPosition []JournalObjectPosition
+}
+
+// JournalStatus describes the state of the journal of an image.
+type JournalStatus struct {
+	// TagClass is the tag class of the image's own journal client.
+	TagClass   uint64
+	MinimumSet uint64
+	ActiveSet  uint64
+	Clients    []JournalClient
+}
+
+// JournalStatus returns the status of the journal of an image that has the
+// journaling feature enabled. The status is read from the journal header
+// object, which is assumed to be in the same pool as the image.
+//  PREVIEW
+//
+// Similar To:
+//  rbd journal status --image <image>
+func (image *Image) JournalStatus() (JournalStatus, error) {
+	var status JournalStatus
+	if err := image.validate(imageIsOpen | imageNeedsIOContext); err != nil {
+		return status, err
+	}
+	features, err := image.GetFeatures()
+	if err != nil {
+		return status, err
+	}
+	if features&FeatureJournaling == 0 {
+		return status, ErrNotFound
+	}
+	id, err := image.GetId()
+	if err != nil {
+		return status, err
+	}
+
+	// the input of client_list is the id to start after and the maximum
+	// number of clients to return
+	clientListIn := encodeString(nil, "")
+	clientListIn = encodeUint64(clientListIn, journalMaxClients)
+
+	op := rados.CreateReadOp()
+	defer op.Release()
+	minSet := op.Exec(journalClass, "get_minimum_set", nil)
+	activeSet := op.Exec(journalClass, "get_active_set", nil)
+	clients := op.Exec(journalClass, "client_list", clientListIn)
+	err = op.Operate(image.ioctx, journalObjPrefix+id, rados.OperationNoFlag)
+	if err != nil {
+		return status, err
+	}
+
+	d := &decoder{buf: minSet.Output}
+	status.MinimumSet = d.u64()
+	d = &decoder{buf: activeSet.Output}
+	status.ActiveSet = d.u64()
+	d = &decoder{buf: clients.Output}
+	status.Clients, status.TagClass = decodeJournalClients(d)
+	if d.err != nil {
+		return JournalStatus{}, d.err
+	}
+	return status, nil
+}
+
+// EnableJournaling enables the journaling feature of the image, storing the
+// journal objects in journalPool instead of the pool of the image. The
+// exclusive-lock feature, which journaling depends on, is enabled too if
+// needed. The pool is recorded as the image level override of the
+// rbd_journal_pool configuration option before the feature is enabled. If
+// enabling the feature fails the override is removed and the image is left
+// with its original features.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_metadata_set(rbd_image_t image, const char *key, const char *value);
+//  int rbd_update_features(rbd_image_t image, uint64_t features, uint8_t enabled);
+func (image *Image) EnableJournaling(journalPool string) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if journalPool == "" {
+		return rbdError(C.EINVAL)
+	}
+	features, err := image.GetFeatures()
+	if err != nil {
+		return err
+	}
+	if features&FeatureJournaling != 0 {
+		return ErrExist
+	}
+
+	err = image.SetMetadata(configMetadataPrefix+journalPoolConfig, journalPool)
+	if err != nil {
+		return err
+	}
+	needLock := features&FeatureExclusiveLock == 0
+	if needLock {
+		err = image.UpdateFeatures(FeatureExclusiveLock, true)
+	}
+	if err == nil {
+		err = image.UpdateFeatures(FeatureJournaling, true)
+		if err != nil && needLock {
+			_ = image.UpdateFeatures(FeatureExclusiveLock, false)
+		}
+	}
+	if err != nil {
+		_ = image.RemoveConfig(journalPoolConfig)
+		return err
+	}
+	return nil
+}
+
+func decodeJournalClients(d *decoder) ([]JournalClient, uint64) {
+	var tagClass uint64
+	count := d.u32()
+	clients := []JournalClient{}
+	for i := uint32(0); i < count && d.err == nil; i++ {
+		cd := d.structure()
+		client := JournalClient{ID: cd.string()}
+		data := cd.bytes()
+		positions := cd.structure()
+		n := positions.u32()
+		for j := uint32(0); j < n && positions.err == nil; j++ {
+			pd := positions.structure()
+			client.CommitPosition = append(client.CommitPosition,
+				JournalObjectPosition{
+					ObjectNumber: pd.u64(),
+					TagTID:       pd.u64(),
+					EntryTID:     pd.u64(),
+				})
+			d.setErr(pd.err)
+		}
+		client.State = JournalClientState(cd.u8())
+		d.setErr(positions.err)
+		d.setErr(cd.err)
+
+		if client.ID == journalImageClientID {
+			md := (&decoder{buf: data}).structure()
+			if md.u32() == journalImageClientMetaType {
+				tagClass = md.u64()
+			}
+			d.setErr(md.err)
+		}
+		clients = append(clients, client)
+	}
+	return clients, tagClass
+}
+
+func encodeUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func encodeString(buf []byte, s string) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(len(s)))
+	buf = append(buf, b[:]...)
+	return append(buf, s...)
+}
+
+// decoder reads values in the Ceph encoding from a buffer. Once a value can
+// not be decoded all further reads return zero values and err is set.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) setErr(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = errJournalDecode
+		d.buf = nil
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) u8() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) u32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) u64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) bytes() []byte {
+	return d.next(int(d.u32()))
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+// structure returns a decoder for a versioned structure, consisting of a
+// version byte, a compat version byte and a length prefixed payload.
+func (d *decoder) structure() *decoder {
+	d.u8() // struct_v
+	d.u8() // struct_compat
+	return &decoder{buf: d.bytes(), err: d.err}
+}
@@ -0,0 +1,192 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJournalClients(t *testing.T) {
+	// ClientData with ImageClientMeta{tag_class: 7, resync_requested: false}
+	meta := []byte{1, 1, 13, 0, 0, 0,
+		0, 0, 0, 0,
+		7, 0, 0, 0, 0, 0, 0, 0,
+		0}
+	position := []byte{1, 1, 24, 0, 0, 0,
+		2, 0, 0, 0, 0, 0, 0, 0,
+		3, 0, 0, 0, 0, 0, 0, 0,
+		4, 0, 0, 0, 0, 0, 0, 0}
+	positions := append([]byte{1, 1, 34, 0, 0, 0, 1, 0, 0, 0}, position...)
+
+	client := encodeString(nil, "")
+	client = append(client, byte(len(meta)), 0, 0, 0)
+	client = append(client, meta...)
+	client = append(client, positions...)
+	client = append(client, 0)
+
+	buf := []byte{1, 0, 0, 0, 1, 1, byte(len(client)), 0, 0, 0}
+	buf = append(buf, client...)
+
+	d := &decoder{buf: buf}
+	clients, tagClass := decodeJournalClients(d)
+	assert.NoError(t, d.err)
+	assert.Equal(t, uint64(7), tagClass)
+	if assert.Len(t, clients, 1) {
+		assert.Equal(t, "", clients[0].ID)
+		assert.Equal(t, JournalClientConnected, clients[0].State)
+		assert.Equal(t, []JournalObjectPosition{{2, 3, 4}}, clients[0].CommitPosition)
+	}
+
+	d = &decoder{buf: buf[:len(buf)-5]}
+	_, _ = decodeJournalClients(d)
+	assert.Error(t, d.err)
+}
+
+func TestJournalStatus(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	err = options.SetUint64(ImageOptionFeatures,
+		FeatureLayering|FeatureExclusiveLock|FeatureJournaling)
+	require.NoError(t, err)
+	err = CreateImage(ioctx, name, testImageSize, options)
+	if err != nil {
+		t.Skipf("journaling not supported: %v", err)
+	}
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	_, err = img.WriteAt([]byte("journaled data"), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, img.Flush())
+
+	status, err := img.JournalStatus()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, status.ActiveSet, status.MinimumSet)
+	found := false
+	for _, c := range status.Clients {
+		if c.ID == journalImageClientID {
+			found = true
+			assert.Equal(t, JournalClientConnected, c.State)
+		}
+	}
+	assert.True(t, found, "image journal client not found")
+
+	t.Run("notJournaled", func(t *testing.T) {
+		name := GetUUID()
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		err := options.SetUint64(ImageOptionFeatures, FeatureLayering)
+		require.NoError(t, err)
+		err = CreateImage(ioctx, name, testImageSize, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		img, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img.Close()) }()
+
+		_, err = img.JournalStatus()
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		img := GetImage(ioctx, name)
+		_, err := img.JournalStatus()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
+
+func TestEnableJournaling(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	journalPool := GetUUID()
+	err = conn.MakePool(journalPool)
+	require.NoError(t, err)
+	defer conn.DeletePool(journalPool)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	err = options.SetUint64(ImageOptionFeatures, FeatureLayering)
+	require.NoError(t, err)
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		img := GetImage(ioctx, name)
+		err := img.EnableJournaling(journalPool)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+
+	t.Run("emptyPool", func(t *testing.T) {
+		err := img.EnableJournaling("")
+		assert.Error(t, err)
+	})
+
+	err = img.EnableJournaling(journalPool)
+	require.NoError(t, err)
+
+	err = img.EnableJournaling(journalPool)
+	assert.Equal(t, ErrExist, err)
+	assert.NoError(t, img.Close())
+
+	img, err = OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	names, err := img.GetFeatureNames()
+	assert.NoError(t, err)
+	assert.Contains(t, names, FeatureNameJournaling)
+	assert.Contains(t, names, FeatureNameExclusiveLock)
+
+	cl, err := img.ConfigList()
+	assert.NoError(t, err)
+	o, found := findConfigOption(cl, journalPoolConfig)
+	if assert.True(t, found) {
+		assert.Equal(t, journalPool, o.Value)
+		assert.Equal(t, ConfigSourceImage, o.Source)
+	}
+
+	// the journal objects live in the journal pool
+	id, err := img.GetId()
+	require.NoError(t, err)
+	jioctx, err := conn.OpenIOContext(journalPool)
+	require.NoError(t, err)
+	defer jioctx.Destroy()
+	_, err = jioctx.Stat(journalObjPrefix + id)
+	assert.NoError(t, err)
+}
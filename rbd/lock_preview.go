@@ -0,0 +1,119 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <stdlib.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/retry"
+)
+
+// LockMode is the mode of the managed lock of an image.
+type LockMode int
+
+const (
+	// LockModeUnlocked indicates no client holds the managed lock of the
+	// image.
+	LockModeUnlocked = LockMode(-1)
+	// LockModeExclusive is the mode of a lock held by a single client.
+	LockModeExclusive = LockMode(C.RBD_LOCK_MODE_EXCLUSIVE)
+	// LockModeShared is the mode of a lock that may be held by several
+	// clients.
+	LockModeShared = LockMode(C.RBD_LOCK_MODE_SHARED)
+)
+
+// LockAcquire acquires the managed lock of the image in the given mode. The
+// image must have the exclusive-lock feature enabled.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_lock_acquire(rbd_image_t image, rbd_lock_mode_t lock_mode);
+func (image *Image) LockAcquire(mode LockMode) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+
+	return getError(C.rbd_lock_acquire(image.image, C.rbd_lock_mode_t(mode)))
+}
+
+// LockRelease releases the managed lock of the image held by this client.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_lock_release(rbd_image_t image);
+func (image *Image) LockRelease() error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+
+	return getError(C.rbd_lock_release(image.image))
+}
+
+// GetLockMode returns the mode of the managed lock of the image and the
+// clients currently holding it. LockModeUnlocked and no owners are returned
+// if the lock is not held.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_lock_get_owners(rbd_image_t image, rbd_lock_mode_t *lock_mode,
+//                          char **lock_owners, size_t *max_lock_owners);
+func (image *Image) GetLockMode() (LockMode, []string, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return LockModeUnlocked, nil, err
+	}
+
+	mode, names, err := image.getLockOwners()
+	if err != nil {
+		return LockModeUnlocked, nil, err
+	}
+	if len(names) == 0 {
+		return LockModeUnlocked, names, nil
+	}
+	return LockMode(mode), names, nil
+}
+
+// getLockOwners returns the mode of the exclusive lock of the image and the
+// clients holding it. No owners are returned if the lock is not held.
+//
+// Implements:
+//  int rbd_lock_get_owners(rbd_image_t image, rbd_lock_mode_t *lock_mode,
+//                          char **lock_owners, size_t *max_lock_owners);
+func (image *Image) getLockOwners() (C.rbd_lock_mode_t, []string, error) {
+	var (
+		err    error
+		cMode  C.rbd_lock_mode_t
+		cCount C.size_t
+		owners []*C.char
+	)
+	retry.WithSizes(4, 4096, func(size int) retry.Hint {
+		cCount = C.size_t(size)
+		owners = make([]*C.char, cCount)
+		ret := C.rbd_lock_get_owners(
+			image.image,
+			&cMode,
+			(**C.char)(unsafe.Pointer(&owners[0])),
+			&cCount)
+		err = getErrorIfNegative(ret)
+		return retry.Size(int(cCount)).If(err == errRange)
+	})
+	if err == ErrNotFound {
+		return cMode, []string{}, nil
+	}
+	if err != nil {
+		return cMode, nil, err
+	}
+	defer C.rbd_lock_get_owners_cleanup(
+		(**C.char)(unsafe.Pointer(&owners[0])), cCount)
+
+	names := make([]string, cCount)
+	for i := range names {
+		names[i] = C.GoString(owners[i])
+	}
+	return cMode, names, nil
+}
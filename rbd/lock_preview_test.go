@@ -0,0 +1,81 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLockMode(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering|FeatureExclusiveLock))
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		image := GetImage(ioctx, name)
+		_, _, err := image.GetLockMode()
+		assert.Equal(t, ErrImageNotOpen, err)
+		err = image.LockAcquire(LockModeExclusive)
+		assert.Equal(t, ErrImageNotOpen, err)
+		err = image.LockRelease()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	t.Run("unlocked", func(t *testing.T) {
+		mode, owners, err := image.GetLockMode()
+		assert.NoError(t, err)
+		assert.Equal(t, LockModeUnlocked, mode)
+		assert.Len(t, owners, 0)
+	})
+
+	t.Run("exclusive", func(t *testing.T) {
+		err := image.LockAcquire(LockModeExclusive)
+		require.NoError(t, err)
+
+		// the lock is visible through other handles too
+		image2, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image2.Close()) }()
+
+		for _, img := range []*Image{image, image2} {
+			mode, owners, err := img.GetLockMode()
+			assert.NoError(t, err)
+			assert.Equal(t, LockModeExclusive, mode)
+			assert.Len(t, owners, 1)
+		}
+
+		err = image.LockRelease()
+		assert.NoError(t, err)
+		mode, owners, err := image2.GetLockMode()
+		assert.NoError(t, err)
+		assert.Equal(t, LockModeUnlocked, mode)
+		assert.Len(t, owners, 0)
+	})
+}
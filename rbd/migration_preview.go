@@ -0,0 +1,286 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <errno.h>
+// #include <stdlib.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// errNotMigrating is returned by librbd for images without a migration in
+// progress.
+const errNotMigrating = rbdError(-C.EINVAL)
+
+// MigrationImageState represents the state of an image migration.
+type MigrationImageState int
+
+const (
+	// MigrationImageUnknown is the state of a migration that could not be
+	// determined.
+	MigrationImageUnknown = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_UNKNOWN)
+	// MigrationImageError is the state of a migration that failed.
+	MigrationImageError = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_ERROR)
+	// MigrationImagePreparing is the state of a migration being prepared.
+	MigrationImagePreparing = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_PREPARING)
+	// MigrationImagePrepared is the state of a prepared migration.
+	MigrationImagePrepared = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_PREPARED)
+	// MigrationImageExecuting is the state of a migration copying data.
+	MigrationImageExecuting = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_EXECUTING)
+	// MigrationImageExecuted is the state of a migration that copied all of
+	// the data and is ready to be committed.
+	MigrationImageExecuted = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_EXECUTED)
+	// MigrationImageCommitting is the state of a migration being committed.
+	MigrationImageCommitting = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_COMMITTING)
+	// MigrationImageAborting is the state of a migration being aborted.
+	MigrationImageAborting = MigrationImageState(C.RBD_IMAGE_MIGRATION_STATE_ABORTING)
+)
+
+// MigrationImageStatus describes the source, destination and state of an
+// image migration.
+type MigrationImageStatus struct {
+	SourcePoolID        int64
+	SourcePoolNamespace string
+	SourceImageName     string
+	SourceImageID       string
+	DestPoolID          int64
+	DestPoolNamespace   string
+	DestImageName       string
+	DestImageID         string
+	State               MigrationImageState
+	StateDescription    string
+}
+
+// MigrationPrepare prepares the migration of the named image to the image
+// destName in destIoctx, which takes the place of the source image.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_migration_prepare(rados_ioctx_t ioctx, const char *image_name,
+//                            rados_ioctx_t dest_ioctx, const char *dest_image_name,
+//                            rbd_image_options_t opts);
+func MigrationPrepare(ioctx *rados.IOContext, name string,
+	destIoctx *rados.IOContext, destName string, rio *ImageOptions) error {
+
+	if ioctx == nil || destIoctx == nil {
+		return ErrNoIOContext
+	}
+	if name == "" || destName == "" {
+		return ErrNoName
+	}
+	if rio == nil {
+		return rbdError(C.EINVAL)
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cDestName := C.CString(destName)
+	defer C.free(unsafe.Pointer(cDestName))
+
+	ret := C.rbd_migration_prepare(
+		cephIoctx(ioctx),
+		cName,
+		cephIoctx(destIoctx),
+		cDestName,
+		C.rbd_image_options_t(rio.options))
+	return getError(ret)
+}
+
+type migrationFunc func(C.rados_ioctx_t, *C.char) C.int
+
+func migrationCall(ioctx *rados.IOContext, name string, f migrationFunc) error {
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if name == "" {
+		return ErrNoName
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	return getError(f(cephIoctx(ioctx), cName))
+}
+
+// MigrationExecute copies the data of a prepared migration of the named
+// image to the destination image. The call returns once all of the data has
+// been copied.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_migration_execute(rados_ioctx_t ioctx, const char *image_name);
+func MigrationExecute(ioctx *rados.IOContext, name string) error {
+	return migrationCall(ioctx, name, func(io C.rados_ioctx_t, n *C.char) C.int {
+		return C.rbd_migration_execute(io, n)
+	})
+}
+
+// MigrationCommit completes an executed migration of the named image,
+// removing the source image.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_migration_commit(rados_ioctx_t ioctx, const char *image_name);
+func MigrationCommit(ioctx *rados.IOContext, name string) error {
+	return migrationCall(ioctx, name, func(io C.rados_ioctx_t, n *C.char) C.int {
+		return C.rbd_migration_commit(io, n)
+	})
+}
+
+// MigrationAbort cancels the migration of the named image, restoring the
+// source image.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_migration_abort(rados_ioctx_t ioctx, const char *image_name);
+func MigrationAbort(ioctx *rados.IOContext, name string) error {
+	return migrationCall(ioctx, name, func(io C.rados_ioctx_t, n *C.char) C.int {
+		return C.rbd_migration_abort(io, n)
+	})
+}
+
+// MigrationStatus returns the status of the migration of the named image.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_migration_status(rados_ioctx_t ioctx, const char *image_name,
+//                           rbd_image_migration_status_t *status,
+//                           size_t status_size);
+func MigrationStatus(ioctx *rados.IOContext, name string) (*MigrationImageStatus, error) {
+	if ioctx == nil {
+		return nil, ErrNoIOContext
+	}
+	if name == "" {
+		return nil, ErrNoName
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var cStatus C.rbd_image_migration_status_t
+	ret := C.rbd_migration_status(
+		cephIoctx(ioctx),
+		cName,
+		&cStatus,
+		C.sizeof_rbd_image_migration_status_t)
+	if ret != 0 {
+		return nil, getError(ret)
+	}
+	defer C.rbd_migration_status_cleanup(&cStatus)
+
+	return &MigrationImageStatus{
+		SourcePoolID:        int64(cStatus.source_pool_id),
+		SourcePoolNamespace: C.GoString(cStatus.source_pool_namespace),
+		SourceImageName:     C.GoString(cStatus.source_image_name),
+		SourceImageID:       C.GoString(cStatus.source_image_id),
+		DestPoolID:          int64(cStatus.dest_pool_id),
+		DestPoolNamespace:   C.GoString(cStatus.dest_pool_namespace),
+		DestImageName:       C.GoString(cStatus.dest_image_name),
+		DestImageID:         C.GoString(cStatus.dest_image_id),
+		State:               MigrationImageState(cStatus.state),
+		StateDescription:    C.GoString(cStatus.state_description),
+	}, nil
+}
+
+// MigrationProgress is a value sent by MigrationStatusStream.
+type MigrationProgress struct {
+	State       MigrationImageState
+	Description string
+	// Percent is the percentage of the data copied. It is 100 once the
+	// migration has been executed.
+	Percent int
+}
+
+// migrationPollInterval is the time between successive status requests of
+// MigrationStatusStream.
+var migrationPollInterval = time.Second
+
+var percentComplete = regexp.MustCompile(`(\d+)% complete`)
+
+func newMigrationProgress(status *MigrationImageStatus) MigrationProgress {
+	p := MigrationProgress{
+		State:       status.State,
+		Description: status.StateDescription,
+	}
+	switch status.State {
+	case MigrationImageExecuted, MigrationImageCommitting:
+		p.Percent = 100
+	case MigrationImageExecuting:
+		if m := percentComplete.FindStringSubmatch(p.Description); m != nil {
+			p.Percent, _ = strconv.Atoi(m[1])
+		}
+	}
+	return p
+}
+
+// MigrationStatusStream polls the status of the migration of the named
+// image and sends the progress over the first returned channel every time
+// it changes. Once the migration is no longer found, because it has been
+// committed (or aborted), the progress channel is closed and a nil error is
+// sent over the error channel. If the status can not be read, or the
+// migration fails, the progress channel is closed and the error is sent
+// instead. The context can be used to stop polling, in which case the error
+// of the context is sent. The error channel receives exactly one value.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_migration_status(rados_ioctx_t ioctx, const char *image_name,
+//                           rbd_image_migration_status_t *status,
+//                           size_t status_size);
+func MigrationStatusStream(ctx context.Context, ioctx *rados.IOContext, name string) (
+	<-chan MigrationProgress, <-chan error) {
+
+	progress := make(chan MigrationProgress)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		errc <- streamMigrationStatus(ctx, ioctx, name, progress)
+	}()
+	return progress, errc
+}
+
+func streamMigrationStatus(
+	ctx context.Context, ioctx *rados.IOContext, name string,
+	progress chan<- MigrationProgress) error {
+
+	defer close(progress)
+	ticker := time.NewTicker(migrationPollInterval)
+	defer ticker.Stop()
+	var last *MigrationProgress
+	for {
+		status, err := MigrationStatus(ioctx, name)
+		switch {
+		case last != nil && (err == errNotMigrating || err == ErrNotFound):
+			return nil
+		case err != nil:
+			return err
+		}
+		p := newMigrationProgress(status)
+		if last == nil || *last != p {
+			select {
+			case progress <- p:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			last = &p
+		}
+		if p.State == MigrationImageError {
+			return rbdError(-C.EIO)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,165 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationStatusStream(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	orig := migrationPollInterval
+	migrationPollInterval = 50 * time.Millisecond
+	defer func() { migrationPollInterval = orig }()
+
+	t.Run("notMigrating", func(t *testing.T) {
+		name := GetUUID()
+		err := quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		progress, errc := MigrationStatusStream(context.Background(), ioctx, name)
+		for range progress {
+			t.Fatal("unexpected progress")
+		}
+		assert.Error(t, <-errc)
+	})
+
+	t.Run("missingImage", func(t *testing.T) {
+		progress, errc := MigrationStatusStream(context.Background(), ioctx, "no-such-image")
+		for range progress {
+			t.Fatal("unexpected progress")
+		}
+		assert.Equal(t, ErrNotFound, <-errc)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		name := GetUUID()
+		destName := GetUUID()
+		err := quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		err = MigrationPrepare(ioctx, name, ioctx, destName, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, MigrationAbort(ioctx, name)) }()
+
+		// nobody receives the progress, cancelling must still stop the stream
+		ctx, cancel := context.WithCancel(context.Background())
+		progress, errc := MigrationStatusStream(ctx, ioctx, destName)
+		cancel()
+		select {
+		case err := <-errc:
+			assert.Equal(t, context.Canceled, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream not stopped")
+		}
+		_, ok := <-progress
+		assert.False(t, ok)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		name := GetUUID()
+		destName := GetUUID()
+		err := quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		err = MigrationPrepare(ioctx, name, ioctx, destName, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, destName)) }()
+
+		status, err := MigrationStatus(ioctx, destName)
+		require.NoError(t, err)
+		assert.Equal(t, name, status.SourceImageName)
+		assert.Equal(t, destName, status.DestImageName)
+		assert.Equal(t, MigrationImagePrepared, status.State)
+
+		progress, errc := MigrationStatusStream(context.Background(), ioctx, destName)
+		go func() {
+			if err := MigrationExecute(ioctx, destName); err != nil {
+				t.Errorf("MigrationExecute: %v", err)
+				return
+			}
+			if err := MigrationCommit(ioctx, destName); err != nil {
+				t.Errorf("MigrationCommit: %v", err)
+			}
+		}()
+
+		var seen []MigrationProgress
+		for p := range progress {
+			if len(seen) > 0 {
+				assert.GreaterOrEqual(t, p.Percent, seen[len(seen)-1].Percent)
+			}
+			seen = append(seen, p)
+		}
+		assert.NoError(t, <-errc)
+		require.NotEmpty(t, seen)
+		assert.Equal(t, MigrationImagePrepared, seen[0].State)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		name := GetUUID()
+		destName := GetUUID()
+		err := quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		err = MigrationPrepare(ioctx, name, ioctx, destName, options)
+		require.NoError(t, err)
+
+		err = MigrationAbort(ioctx, name)
+		assert.NoError(t, err)
+		_, err = MigrationStatus(ioctx, name)
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrationProgress(t *testing.T) {
+	p := newMigrationProgress(&MigrationImageStatus{
+		State:            MigrationImageExecuting,
+		StateDescription: "42% complete",
+	})
+	assert.Equal(t, 42, p.Percent)
+
+	p = newMigrationProgress(&MigrationImageStatus{
+		State: MigrationImagePrepared,
+	})
+	assert.Equal(t, 0, p.Percent)
+
+	p = newMigrationProgress(&MigrationImageStatus{
+		State: MigrationImageExecuted,
+	})
+	assert.Equal(t, 100, p.Percent)
+}
+
+func TestMigrationNilArgs(t *testing.T) {
+	err := MigrationPrepare(nil, "a", nil, "b", nil)
+	assert.Equal(t, ErrNoIOContext, err)
+	err = MigrationExecute(nil, "a")
+	assert.Equal(t, ErrNoIOContext, err)
+	_, err = MigrationStatus(nil, "a")
+	assert.Equal(t, ErrNoIOContext, err)
+}
@@ -287,6 +287,28 @@ func TestGetMirrorImageInfo(t *testing.T) {
 		assert.Equal(t, mii.State, MirrorImageEnabled)
 		assert.Equal(t, mii.Primary, true)
 	})
+
+	t.Run("demotePromote", func(t *testing.T) {
+		img, err := OpenImage(ioctx, imgName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, img.Close())
+		}()
+
+		// the primary flag follows demotion and promotion of the image
+		err = img.MirrorDemote()
+		require.NoError(t, err)
+		mii, err := img.GetMirrorImageInfo()
+		assert.NoError(t, err)
+		assert.Equal(t, MirrorImageEnabled, mii.State)
+		assert.False(t, mii.Primary)
+
+		err = img.MirrorPromote(false)
+		require.NoError(t, err)
+		mii, err = img.GetMirrorImageInfo()
+		assert.NoError(t, err)
+		assert.True(t, mii.Primary)
+	})
 }
 
 func TestMirrorConstantStrings(t *testing.T) {
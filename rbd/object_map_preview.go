@@ -0,0 +1,94 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <stdlib.h>
+#include <rbd/librbd.h>
+
+extern int imageRebuildObjectMapCallback(uint64_t, uint64_t, uintptr_t);
+
+// inline wrapper to cast uintptr_t to void*
+static inline int wrap_rbd_rebuild_object_map(rbd_image_t image, uintptr_t arg) {
+	return rbd_rebuild_object_map(
+		image, (librbd_progress_fn_t)imageRebuildObjectMapCallback, (void*)arg);
+};
+*/
+import "C"
+
+import (
+	"github.com/ceph/go-ceph/internal/callbacks"
+)
+
+// ImageFlags is a set of flags librbd records for an image.
+type ImageFlags uint64
+
+const (
+	// ImageFlagObjectMapInvalid indicates the object map of the image is
+	// invalid and must be rebuilt.
+	ImageFlagObjectMapInvalid = ImageFlags(C.RBD_FLAG_OBJECT_MAP_INVALID)
+	// ImageFlagFastDiffInvalid indicates the fast-diff data of the image is
+	// invalid and must be rebuilt.
+	ImageFlagFastDiffInvalid = ImageFlags(C.RBD_FLAG_FAST_DIFF_INVALID)
+)
+
+// GetFlags returns the flags librbd records for the image, for example
+// ImageFlagObjectMapInvalid.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_get_flags(rbd_image_t image, uint64_t *flags);
+func (image *Image) GetFlags() (ImageFlags, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return 0, err
+	}
+
+	var cFlags C.uint64_t
+	ret := C.rbd_get_flags(image.image, &cFlags)
+	if err := getError(ret); err != nil {
+		return 0, err
+	}
+	return ImageFlags(cFlags), nil
+}
+
+var imageRebuildObjectMapCallbacks = callbacks.New()
+
+// RebuildObjectMap rebuilds the object map of the image, and its fast-diff
+// data, by checking which of the data objects of the image exist. This
+// clears the ImageFlagObjectMapInvalid and ImageFlagFastDiffInvalid flags.
+// The given callback, if not nil, is called to report the progress of the
+// rebuild with the amount of work done and the total amount of work.
+// Returning a non-zero value from the callback aborts the rebuild.
+//
+// An image created with the object-map feature starts with a valid object
+// map, so there is nothing to rebuild after creating an image.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_rebuild_object_map(rbd_image_t image, librbd_progress_fn_t cb,
+//                             void *cbdata);
+func (image *Image) RebuildObjectMap(cb func(done, total uint64) int) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if cb == nil {
+		cb = func(done, total uint64) int { return 0 }
+	}
+
+	cbIndex := imageRebuildObjectMapCallbacks.Add(cb)
+	defer imageRebuildObjectMapCallbacks.Remove(cbIndex)
+
+	ret := C.wrap_rbd_rebuild_object_map(image.image, C.uintptr_t(cbIndex))
+	return getError(ret)
+}
+
+//export imageRebuildObjectMapCallback
+func imageRebuildObjectMapCallback(
+	offset, total C.uint64_t, index uintptr) C.int {
+
+	v := imageRebuildObjectMapCallbacks.Lookup(index)
+	cb := v.(func(done, total uint64) int)
+	return C.int(cb(uint64(offset), uint64(total)))
+}
@@ -0,0 +1,83 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildObjectMap(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures,
+			FeatureLayering|FeatureExclusiveLock|FeatureObjectMap|FeatureFastDiff))
+	err = CreateImage(ioctx, name, 4*testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		img := GetImage(ioctx, name)
+		err := img.RebuildObjectMap(nil)
+		assert.Equal(t, ErrImageNotOpen, err)
+		_, err = img.GetFlags()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	flags, err := img.GetFlags()
+	assert.NoError(t, err)
+	assert.Zero(t, flags&ImageFlagObjectMapInvalid)
+	_, err = img.WriteAt([]byte("mapped data"), 0)
+	assert.NoError(t, err)
+	id, err := img.GetId()
+	require.NoError(t, err)
+	assert.NoError(t, img.Close())
+
+	// removing the object map forces librbd to invalidate it the next time
+	// the exclusive lock is acquired
+	err = ioctx.Delete("rbd_object_map." + id)
+	require.NoError(t, err)
+
+	img, err = OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+	_, err = img.WriteAt([]byte("more data"), int64(testImageSize))
+	assert.NoError(t, err)
+	flags, err = img.GetFlags()
+	require.NoError(t, err)
+	require.NotZero(t, flags&ImageFlagObjectMapInvalid)
+
+	cc := 0
+	err = img.RebuildObjectMap(func(done, total uint64) int {
+		cc++
+		return 0
+	})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, cc, 1)
+
+	flags, err = img.GetFlags()
+	assert.NoError(t, err)
+	assert.Zero(t, flags&ImageFlagObjectMapInvalid)
+	assert.Zero(t, flags&ImageFlagFastDiffInvalid)
+}
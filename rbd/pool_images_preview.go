@@ -0,0 +1,55 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"sync"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// forEachImage calls fn with the name of every image in the pool, with up
+// to workers calls running at the same time. Calls returning ErrNotFound,
+// because the image was removed in the meantime, are ignored. Otherwise the
+// first error returned by fn is returned once all calls have finished.
+func forEachImage(ioctx *rados.IOContext, workers int, fn func(name string) error) error {
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	names, err := GetImageNames(ioctx)
+	if err != nil {
+		return err
+	}
+
+	work := make(chan string)
+	errs := make(chan error)
+	if len(names) < workers {
+		workers = len(names)
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range work {
+				errs <- fn(name)
+			}
+		}()
+	}
+	go func() {
+		for _, name := range names {
+			work <- name
+		}
+		close(work)
+		wg.Wait()
+		close(errs)
+	}()
+
+	for e := range errs {
+		if e != nil && e != ErrNotFound && err == nil {
+			err = e
+		}
+	}
+	return err
+}
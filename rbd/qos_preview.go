@@ -0,0 +1,90 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"strconv"
+)
+
+// QoSLimits are the quality of service limits of an image. Limits are per
+// second, I/O operations (IOPS) or bytes (BPS), and burst values are the
+// amount an image may exceed a limit for short periods. A value of zero
+// means no limit.
+type QoSLimits struct {
+	IOPS      uint64
+	ReadIOPS  uint64
+	WriteIOPS uint64
+	BPS       uint64
+	ReadBPS   uint64
+	WriteBPS  uint64
+
+	IOPSBurst      uint64
+	ReadIOPSBurst  uint64
+	WriteIOPSBurst uint64
+	BPSBurst       uint64
+	ReadBPSBurst   uint64
+	WriteBPSBurst  uint64
+}
+
+// fields maps the names of the rbd_qos_* configuration options to the
+// corresponding fields of the QoSLimits.
+func (q *QoSLimits) fields() map[string]*uint64 {
+	return map[string]*uint64{
+		"rbd_qos_iops_limit":       &q.IOPS,
+		"rbd_qos_read_iops_limit":  &q.ReadIOPS,
+		"rbd_qos_write_iops_limit": &q.WriteIOPS,
+		"rbd_qos_bps_limit":        &q.BPS,
+		"rbd_qos_read_bps_limit":   &q.ReadBPS,
+		"rbd_qos_write_bps_limit":  &q.WriteBPS,
+		"rbd_qos_iops_burst":       &q.IOPSBurst,
+		"rbd_qos_read_iops_burst":  &q.ReadIOPSBurst,
+		"rbd_qos_write_iops_burst": &q.WriteIOPSBurst,
+		"rbd_qos_bps_burst":        &q.BPSBurst,
+		"rbd_qos_read_bps_burst":   &q.ReadBPSBurst,
+		"rbd_qos_write_bps_burst":  &q.WriteBPSBurst,
+	}
+}
+
+// GetQoS returns the quality of service limits in effect for the image,
+// whether they are set on the image, the pool or in the global
+// configuration.
+//  PREVIEW
+func (image *Image) GetQoS() (QoSLimits, error) {
+	var q QoSLimits
+	options, err := image.ConfigList()
+	if err != nil {
+		return q, err
+	}
+	fields := q.fields()
+	for _, o := range options {
+		f, ok := fields[o.Name]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(o.Value, 10, 64)
+		if err != nil {
+			return QoSLimits{}, err
+		}
+		*f = v
+	}
+	return q, nil
+}
+
+// SetQoS sets all of the quality of service limits of the image, overriding
+// the values of the pool and the global configuration. Use RemoveConfig to
+// make the image inherit a limit again.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_metadata_set(rbd_image_t image, const char *key, const char *value);
+func (image *Image) SetQoS(q QoSLimits) error {
+	for name, f := range q.fields() {
+		err := image.SetMetadata(
+			configMetadataPrefix+name, strconv.FormatUint(*f, 10))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageQoS(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		image := GetImage(ioctx, name)
+		_, err := image.GetQoS()
+		assert.Equal(t, ErrImageNotOpen, err)
+		err = image.SetQoS(QoSLimits{IOPS: 100})
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	t.Run("defaults", func(t *testing.T) {
+		q, err := image.GetQoS()
+		assert.NoError(t, err)
+		assert.Equal(t, QoSLimits{}, q)
+	})
+
+	t.Run("setAndGet", func(t *testing.T) {
+		limits := QoSLimits{
+			IOPS:          500,
+			IOPSBurst:     1000,
+			WriteBPS:      4 << 20,
+			WriteBPSBurst: 8 << 20,
+		}
+		err := image.SetQoS(limits)
+		assert.NoError(t, err)
+
+		q, err := image.GetQoS()
+		assert.NoError(t, err)
+		assert.Equal(t, limits, q)
+
+		// the values are image level overrides
+		options, err := image.ConfigList()
+		assert.NoError(t, err)
+		for _, o := range options {
+			if o.Name == "rbd_qos_iops_limit" {
+				assert.Equal(t, "500", o.Value)
+				assert.Equal(t, ConfigSourceImage, o.Source)
+			}
+		}
+	})
+
+	t.Run("inheritPool", func(t *testing.T) {
+		err := PoolConfigSet(ioctx, "rbd_qos_bps_limit", "2048")
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, PoolConfigRemove(ioctx, "rbd_qos_bps_limit"))
+		}()
+		assert.NoError(t, image.RemoveConfig("rbd_qos_bps_limit"))
+
+		q, err := image.GetQoS()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2048), q.BPS)
+		assert.Equal(t, uint64(500), q.IOPS)
+	})
+}
@@ -0,0 +1,110 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"io"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// defaultRawChunkSize is the chunk size used by ExportRaw and ImportRaw when
+// the caller does not specify one.
+const defaultRawChunkSize = 4 * 1024 * 1024
+
+// ExportRaw writes the full contents of the image to w as a dense stream of
+// raw bytes, the same output that dd would produce when reading the mapped
+// device. The image is read sequentially in chunks of chunkSize bytes. If
+// chunkSize is zero a default chunk size is used.
+//  PREVIEW
+func (image *Image) ExportRaw(w io.Writer, chunkSize uint64) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if chunkSize == 0 {
+		chunkSize = defaultRawChunkSize
+	}
+
+	size, err := image.GetSize()
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunkSize)
+	for offset := uint64(0); offset < size; {
+		length := size - offset
+		if length > chunkSize {
+			length = chunkSize
+		}
+		n, err := image.ReadAt(buf[:length], int64(offset))
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if uint64(n) != length {
+			return io.ErrUnexpectedEOF
+		}
+		if _, err = w.Write(buf[:n]); err != nil {
+			return err
+		}
+		offset += length
+	}
+	return nil
+}
+
+// ImportRaw creates a new image with the given name and size and fills it
+// with size bytes of raw data read from r, for example a stream produced by
+// ExportRaw or dd. The data is written sequentially in chunks of chunkSize
+// bytes. If chunkSize is zero a default chunk size is used. An error is
+// returned if r holds fewer than size bytes. If the data can not be copied
+// the partially written image is removed again.
+//  PREVIEW
+func ImportRaw(ioctx *rados.IOContext, name string, size uint64, r io.Reader,
+	chunkSize uint64) error {
+
+	if chunkSize == 0 {
+		chunkSize = defaultRawChunkSize
+	}
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	if err := CreateImage(ioctx, name, size, options); err != nil {
+		return err
+	}
+	if err := importRaw(ioctx, name, size, r, chunkSize); err != nil {
+		// the image is of no use, the error of the copy is what matters
+		_ = RemoveImage(ioctx, name)
+		return err
+	}
+	return nil
+}
+
+// importRaw copies size bytes of raw data from r to the named image.
+func importRaw(ioctx *rados.IOContext, name string, size uint64, r io.Reader,
+	chunkSize uint64) error {
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer image.Close()
+
+	buf := make([]byte, chunkSize)
+	for offset := uint64(0); offset < size; {
+		length := size - offset
+		if length > chunkSize {
+			length = chunkSize
+		}
+		if _, err = io.ReadFull(r, buf[:length]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if _, err = image.WriteAt(buf[:length], int64(offset)); err != nil {
+			return err
+		}
+		offset += length
+	}
+	return image.Flush()
+}
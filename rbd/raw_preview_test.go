@@ -0,0 +1,100 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportRaw(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	// leave zero filled gaps at the start and between the written ranges
+	data := []byte("raw data with a \x00 in the middle")
+	_, err = img.WriteAt(data, 4096)
+	require.NoError(t, err)
+	_, err = img.WriteAt(data, int64(testImageSize)-int64(len(data)))
+	require.NoError(t, err)
+
+	t.Run("exportRaw", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := img.ExportRaw(buf, 64*1024)
+		require.NoError(t, err)
+		assert.Equal(t, int(testImageSize), buf.Len())
+
+		expected := make([]byte, testImageSize)
+		copy(expected[4096:], data)
+		copy(expected[testImageSize-uint64(len(data)):], data)
+		assert.Equal(t, expected, buf.Bytes())
+	})
+
+	t.Run("roundTrip", func(t *testing.T) {
+		exported := &bytes.Buffer{}
+		err := img.ExportRaw(exported, 0)
+		require.NoError(t, err)
+
+		name2 := GetUUID()
+		err = ImportRaw(ioctx, name2, testImageSize,
+			bytes.NewReader(exported.Bytes()), 100*1024)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name2)) }()
+
+		img2, err := OpenImage(ioctx, name2, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, img2.Close()) }()
+
+		size, err := img2.GetSize()
+		assert.NoError(t, err)
+		assert.Equal(t, testImageSize, size)
+
+		imported := &bytes.Buffer{}
+		err = img2.ExportRaw(imported, 0)
+		require.NoError(t, err)
+		assert.Equal(t, exported.Bytes(), imported.Bytes())
+	})
+
+	t.Run("shortInput", func(t *testing.T) {
+		name2 := GetUUID()
+		err := ImportRaw(ioctx, name2, testImageSize,
+			bytes.NewReader(data), 0)
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		// the partially written image was removed
+		_, err = OpenImage(ioctx, name2, NoSnapshot)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		img2 := GetImage(ioctx, name)
+		err := img2.ExportRaw(&bytes.Buffer{}, 0)
+		assert.Error(t, err)
+	})
+}
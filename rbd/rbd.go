@@ -290,11 +290,18 @@ func (image *Image) Remove() error {
 }
 
 // Trash will move an image into the RBD trash, where it will be protected (i.e., salvageable) for
-// at least the specified delay.
+// at least the specified delay. A zero (or negative) delay moves the image to the trash right
+// away and makes it eligible for immediate removal with TrashRemove.
+//
+// Implements:
+//  int rbd_trash_move(rados_ioctx_t io, const char *name, uint64_t delay);
 func (image *Image) Trash(delay time.Duration) error {
 	if err := image.validate(imageNeedsIOContext | imageNeedsName); err != nil {
 		return err
 	}
+	if delay < 0 {
+		delay = 0
+	}
 
 	cName := C.CString(image.name)
 	defer C.free(unsafe.Pointer(cName))
@@ -1014,7 +1021,12 @@ func TrashRemove(ioctx *rados.IOContext, id string, force bool) error {
 }
 
 // TrashRestore restores the trashed RBD with the specified id back to the pool from whence it
-// came, with the specified new name.
+// came, with the specified new name. An empty name restores the image with its original name.
+// If an image with the resulting name already exists an EEXIST error is returned and the image
+// stays in the trash.
+//
+// Implements:
+//  int rbd_trash_restore(rados_ioctx_t io, const char *id, const char *name);
 func TrashRestore(ioctx *rados.IOContext, id, name string) error {
 	cid := C.CString(id)
 	cName := C.CString(name)
@@ -18,7 +18,8 @@ import (
 	"github.com/ceph/go-ceph/rados"
 )
 
-// GetImageNames returns the list of current RBD images.
+// GetImageNames returns the list of current RBD images in the namespace the
+// IO context is set to.
 func GetImageNames(ioctx *rados.IOContext) ([]string, error) {
 	var (
 		err    error
@@ -0,0 +1,314 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd
+// #include <errno.h>
+// #include <stdlib.h>
+// #include <rados/librados.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/retry"
+	"github.com/ceph/go-ceph/rados"
+)
+
+const (
+	// ErrExist indicates that a resource, such as an image, already exists.
+	ErrExist = rbdError(-C.EEXIST)
+)
+
+// GetDataPoolID returns the ID of the pool holding the data objects of the
+// image. For images created without a separate data pool this is the ID of
+// the pool the image itself lives in.
+//  PREVIEW
+//
+// Implements:
+//  int64_t rbd_get_data_pool_id(rbd_image_t image);
+func (image *Image) GetDataPoolID() (int64, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return 0, err
+	}
+
+	ret := C.rbd_get_data_pool_id(image.image)
+	if ret < 0 {
+		return 0, rbdError(ret)
+	}
+	return int64(ret), nil
+}
+
+// RenameImage renames the image oldName in the pool of the given IO context
+// to newName. ErrExist is returned if an image named newName already exists
+// and ErrNotFound is returned if there is no image named oldName.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_rename(rados_ioctx_t src_io_ctx, const char *srcname, const char *destname);
+func RenameImage(ioctx *rados.IOContext, oldName, newName string) error {
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if oldName == "" || newName == "" {
+		return ErrNoName
+	}
+
+	cOldName := C.CString(oldName)
+	defer C.free(unsafe.Pointer(cOldName))
+	cNewName := C.CString(newName)
+	defer C.free(unsafe.Pointer(cNewName))
+
+	ret := C.rbd_rename(cephIoctx(ioctx), cOldName, cNewName)
+	return getError(ret)
+}
+
+// ExtendedImageInfo contains the basic information about an image returned
+// by Stat along with the parent of the image, if any.
+type ExtendedImageInfo struct {
+	ImageInfo
+	// Parent is the pool and name of the parent image, or nil if the image
+	// is not a clone.
+	Parent *ImageSpec
+}
+
+// GetImageInfo returns the size, object size, number of objects, order and
+// block name prefix of the image together with the parent of the image,
+// saving the need to call several separate getters.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_stat(rbd_image_t image, rbd_image_info_t *info, size_t infosize);
+//  int rbd_get_parent(rbd_image_t image, rbd_linked_image_spec_t *parent_image,
+//                     rbd_snap_spec_t *parent_snap);
+func (image *Image) GetImageInfo() (*ExtendedImageInfo, error) {
+	info, err := image.Stat()
+	if err != nil {
+		return nil, err
+	}
+	ei := &ExtendedImageInfo{ImageInfo: *info}
+
+	parent, err := image.GetParent()
+	switch err {
+	case nil:
+		ei.Parent = &parent.Image
+	case ErrNotFound:
+		// the image is not a clone
+	default:
+		return nil, err
+	}
+	return ei, nil
+}
+
+// GetBlockNamePrefix returns the prefix of the names of the rados objects
+// holding the data of the image.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_get_block_name_prefix(rbd_image_t image, char *prefix, size_t prefix_len);
+func (image *Image) GetBlockNamePrefix() (string, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return "", err
+	}
+
+	var (
+		buf []byte
+		err error
+	)
+	retry.WithSizes(64, 4096, func(size int) retry.Hint {
+		buf = make([]byte, size)
+		ret := C.rbd_get_block_name_prefix(
+			image.image,
+			(*C.char)(unsafe.Pointer(&buf[0])),
+			C.size_t(size))
+		err = getError(ret)
+		return retry.DoubleSize.If(err == errRange)
+	})
+	if err != nil {
+		return "", err
+	}
+	return C.GoString((*C.char)(unsafe.Pointer(&buf[0]))), nil
+}
+
+// GetCloneChain returns the ancestors of the image, starting with its parent
+// and ending with the top-most ancestor, which is not a clone itself. The
+// chain is empty if the image is not a clone.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_get_parent(rbd_image_t image, rbd_linked_image_spec_t *parent_image,
+//                     rbd_snap_spec_t *parent_snap);
+//  int rbd_open_by_id_read_only(rados_ioctx_t io, const char *id,
+//                               rbd_image_t *image, const char *snap_name);
+//  int rbd_snap_set_by_id(rbd_image_t image, uint64_t snap_id);
+func (image *Image) GetCloneChain() ([]ParentInfo, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return nil, err
+	}
+
+	cluster := C.rados_ioctx_get_cluster(cephIoctx(image.ioctx))
+	chain := []ParentInfo{}
+	current := image.image
+	closeCurrent := func() {}
+	defer func() { closeCurrent() }()
+	for {
+		parentImage := C.rbd_linked_image_spec_t{}
+		parentSnap := C.rbd_snap_spec_t{}
+		ret := C.rbd_get_parent(current, &parentImage, &parentSnap)
+		if err := getError(ret); err == ErrNotFound {
+			return chain, nil
+		} else if err != nil {
+			return nil, err
+		}
+		chain = append(chain, ParentInfo{
+			Image: ImageSpec{
+				ImageName: C.GoString(parentImage.image_name),
+				PoolName:  C.GoString(parentImage.pool_name),
+			},
+			Snap: SnapSpec{
+				ID:       uint64(parentSnap.id),
+				SnapName: C.GoString(parentSnap.name),
+			},
+		})
+		next, closeNext, err := openLinkedImage(cluster, &parentImage, parentSnap.id)
+		C.rbd_linked_image_spec_cleanup(&parentImage)
+		C.rbd_snap_spec_cleanup(&parentSnap)
+		if err != nil {
+			return nil, err
+		}
+		closeCurrent()
+		current, closeCurrent = next, closeNext
+	}
+}
+
+// GetCloneDepth returns the number of ancestors of the image: zero for an
+// image that is not a clone, one for a clone of a regular image and so on.
+//  PREVIEW
+func (image *Image) GetCloneDepth() (int, error) {
+	chain, err := image.GetCloneChain()
+	if err != nil {
+		return 0, err
+	}
+	return len(chain), nil
+}
+
+// GetCloneOwnBytes returns the number of bytes of a clone that are stored in
+// the clone itself, rather than read through from its parent. A fresh clone
+// owns no bytes and the figure grows as data is written to the clone. The
+// extents are found by iterating over the allocated areas of the image,
+// excluding the parent. For an image that is not a clone ErrNotFound is
+// returned.
+//  PREVIEW
+func (image *Image) GetCloneOwnBytes() (uint64, error) {
+	if err := image.validate(imageIsOpen); err != nil {
+		return 0, err
+	}
+	if _, err := image.GetParent(); err != nil {
+		return 0, err
+	}
+	size, err := image.GetSize()
+	if err != nil {
+		return 0, err
+	}
+
+	var owned uint64
+	err = image.DiffIterate(DiffIterateConfig{
+		Offset:        0,
+		Length:        size,
+		IncludeParent: ExcludeParent,
+		WholeObject:   DisableWholeObject,
+		Callback: func(offset, length uint64, exists int, _ interface{}) int {
+			if exists != 0 {
+				owned += length
+			}
+			return 0
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return owned, nil
+}
+
+// openLinkedImage opens the image described by spec, which may be in any
+// pool of the cluster, read-only at the given snapshot. The returned
+// function closes the image.
+func openLinkedImage(cluster C.rados_t, spec *C.rbd_linked_image_spec_t,
+	snapID C.uint64_t) (C.rbd_image_t, func(), error) {
+
+	var ioctx C.rados_ioctx_t
+	ret := C.rados_ioctx_create2(cluster, C.int64_t(spec.pool_id), &ioctx)
+	if ret < 0 {
+		return nil, nil, getError(ret)
+	}
+	C.rados_ioctx_set_namespace(ioctx, spec.pool_namespace)
+
+	var cImage C.rbd_image_t
+	ret = C.rbd_open_by_id_read_only(ioctx, spec.image_id, &cImage, nil)
+	if ret < 0 {
+		C.rados_ioctx_destroy(ioctx)
+		return nil, nil, getError(ret)
+	}
+	closeImage := func() {
+		C.rbd_close(cImage)
+		C.rados_ioctx_destroy(ioctx)
+	}
+	if ret = C.rbd_snap_set_by_id(cImage, snapID); ret < 0 {
+		closeImage()
+		return nil, nil, getError(ret)
+	}
+	return cImage, closeImage, nil
+}
+
+// ImageTimestamps holds the creation, access and modification times of an
+// image.
+type ImageTimestamps struct {
+	Create Timespec
+	Access Timespec
+	Modify Timespec
+}
+
+// GetTimestamps returns the creation, access and modification times of the
+// image in a single call.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_get_create_timestamp(rbd_image_t image, struct timespec *timestamp);
+//  int rbd_get_access_timestamp(rbd_image_t image, struct timespec *timestamp);
+//  int rbd_get_modify_timestamp(rbd_image_t image, struct timespec *timestamp);
+func (image *Image) GetTimestamps() (ImageTimestamps, error) {
+	var (
+		its ImageTimestamps
+		err error
+	)
+	if its.Create, err = image.GetCreateTimestamp(); err != nil {
+		return ImageTimestamps{}, err
+	}
+	if its.Access, err = image.GetAccessTimestamp(); err != nil {
+		return ImageTimestamps{}, err
+	}
+	if its.Modify, err = image.GetModifyTimestamp(); err != nil {
+		return ImageTimestamps{}, err
+	}
+	return its, nil
+}
+
+// ListImagesInNamespace returns the names of the images in the namespace ns
+// of the pool of the IO context. The namespace of the IO context is changed
+// while the images are listed and restored afterwards, so the IO context
+// must not be used concurrently.
+//  PREVIEW
+func ListImagesInNamespace(ioctx *rados.IOContext, ns string) ([]string, error) {
+	if ioctx == nil {
+		return nil, ErrNoIOContext
+	}
+	orig, err := ioctx.GetNamespace()
+	if err != nil {
+		return nil, err
+	}
+	ioctx.SetNamespace(ns)
+	defer ioctx.SetNamespace(orig)
+	return GetImageNames(ioctx)
+}
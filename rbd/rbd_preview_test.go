@@ -0,0 +1,568 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDataPoolID(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	datapoolname := GetUUID()
+	err = conn.MakePool(datapoolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(datapoolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	dataioctx, err := conn.OpenIOContext(datapoolname)
+	require.NoError(t, err)
+	defer dataioctx.Destroy()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		image := GetImage(ioctx, "foo")
+		_, err := image.GetDataPoolID()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	t.Run("noDataPool", func(t *testing.T) {
+		name := GetUUID()
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		assert.NoError(t,
+			options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+		err := CreateImage(ioctx, name, testImageSize, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+
+		id, err := image.GetDataPoolID()
+		assert.NoError(t, err)
+		assert.Equal(t, ioctx.GetPoolID(), id)
+	})
+
+	t.Run("withDataPool", func(t *testing.T) {
+		name := GetUUID()
+		options := NewRbdImageOptions()
+		defer options.Destroy()
+		assert.NoError(t,
+			options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+		assert.NoError(t,
+			options.SetString(ImageOptionDataPool, datapoolname))
+		err := CreateImage(ioctx, name, testImageSize, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+
+		id, err := image.GetDataPoolID()
+		assert.NoError(t, err)
+		assert.Equal(t, dataioctx.GetPoolID(), id)
+		assert.NotEqual(t, ioctx.GetPoolID(), id)
+	})
+}
+
+func TestRenameImage(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+
+	name := GetUUID()
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	otherName := GetUUID()
+	err = CreateImage(ioctx, otherName, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, otherName)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	_, err = image.WriteAt([]byte("keep me"), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, image.Close())
+
+	newName := GetUUID()
+	err = RenameImage(ioctx, name, newName)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, newName)) }()
+
+	_, err = OpenImage(ioctx, name, NoSnapshot)
+	assert.Error(t, err)
+
+	image, err = OpenImage(ioctx, newName, NoSnapshot)
+	require.NoError(t, err)
+	b := make([]byte, 7)
+	_, err = image.ReadAt(b, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "keep me", string(b))
+	assert.NoError(t, image.Close())
+
+	t.Run("destinationExists", func(t *testing.T) {
+		err := RenameImage(ioctx, newName, otherName)
+		assert.Equal(t, ErrExist, err)
+	})
+
+	t.Run("sourceMissing", func(t *testing.T) {
+		err := RenameImage(ioctx, name, GetUUID())
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("invalidArgs", func(t *testing.T) {
+		err := RenameImage(nil, newName, "foo")
+		assert.Equal(t, ErrNoIOContext, err)
+		err = RenameImage(ioctx, "", "foo")
+		assert.Equal(t, ErrNoName, err)
+		err = RenameImage(ioctx, newName, "")
+		assert.Equal(t, ErrNoName, err)
+	})
+}
+
+func TestGetImageInfo(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering))
+
+	name := GetUUID()
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		img := GetImage(ioctx, name)
+		_, err := img.GetImageInfo()
+		assert.Equal(t, ErrImageNotOpen, err)
+		_, err = img.GetBlockNamePrefix()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	t.Run("noParent", func(t *testing.T) {
+		info, err := image.GetImageInfo()
+		require.NoError(t, err)
+
+		size, err := image.GetSize()
+		assert.NoError(t, err)
+		assert.Equal(t, size, info.Size)
+		assert.Equal(t, uint64(1)<<testImageOrder, info.Obj_size)
+		assert.Equal(t, testImageOrder, info.Order)
+		assert.Equal(t, testImageSize/info.Obj_size, info.Num_objs)
+
+		prefix, err := image.GetBlockNamePrefix()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, prefix)
+		assert.Equal(t, prefix, info.Block_name_prefix)
+		assert.Nil(t, info.Parent)
+	})
+
+	t.Run("withParent", func(t *testing.T) {
+		snapshot, err := image.CreateSnapshot("infosnap")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, snapshot.Remove()) }()
+		require.NoError(t, snapshot.Protect())
+		defer func() { assert.NoError(t, snapshot.Unprotect()) }()
+
+		cloneName := GetUUID()
+		err = CloneImage(ioctx, name, "infosnap", ioctx, cloneName, options)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, cloneName)) }()
+
+		clone, err := OpenImage(ioctx, cloneName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, clone.Close()) }()
+
+		info, err := clone.GetImageInfo()
+		require.NoError(t, err)
+		if assert.NotNil(t, info.Parent) {
+			assert.Equal(t, poolname, info.Parent.PoolName)
+			assert.Equal(t, name, info.Parent.ImageName)
+		}
+		prefix, err := clone.GetBlockNamePrefix()
+		assert.NoError(t, err)
+		assert.Equal(t, prefix, info.Block_name_prefix)
+	})
+}
+
+func TestTrashRestoreOriginalName(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+
+	t.Run("emptyName", func(t *testing.T) {
+		err := GetImage(ioctx, name).Trash(time.Hour)
+		require.NoError(t, err)
+		trashList, err := GetTrashList(ioctx)
+		require.NoError(t, err)
+		require.Len(t, trashList, 1)
+
+		err = TrashRestore(ioctx, trashList[0].Id, "")
+		assert.NoError(t, err)
+
+		names, err := GetImageNames(ioctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{name}, names)
+	})
+
+	t.Run("nameConflict", func(t *testing.T) {
+		err := GetImage(ioctx, name).Trash(time.Hour)
+		require.NoError(t, err)
+		trashList, err := GetTrashList(ioctx)
+		require.NoError(t, err)
+		require.Len(t, trashList, 1)
+		id := trashList[0].Id
+
+		// a new image takes the original name
+		err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		err = TrashRestore(ioctx, id, "")
+		assert.Equal(t, ErrExist, err)
+
+		// the image is left in the trash
+		trashList, err = GetTrashList(ioctx)
+		assert.NoError(t, err)
+		if assert.Len(t, trashList, 1) {
+			assert.Equal(t, id, trashList[0].Id)
+		}
+		assert.NoError(t, TrashRemove(ioctx, id, true))
+	})
+}
+
+func TestGetCloneDepth(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering))
+
+	// makeClone creates a protected snapshot of the named image and a clone
+	// of that snapshot, returning the name of the clone and a cleanup
+	// function that removes both.
+	makeClone := func(parentName string) (string, func()) {
+		parent, err := OpenImage(ioctx, parentName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, parent.Close()) }()
+		snap, err := parent.CreateSnapshot("base")
+		require.NoError(t, err)
+		require.NoError(t, snap.Protect())
+
+		cloneName := GetUUID()
+		err = CloneImage(ioctx, parentName, "base", ioctx, cloneName, options)
+		require.NoError(t, err)
+		return cloneName, func() {
+			assert.NoError(t, RemoveImage(ioctx, cloneName))
+			parent, err := OpenImage(ioctx, parentName, NoSnapshot)
+			require.NoError(t, err)
+			defer func() { assert.NoError(t, parent.Close()) }()
+			snap := parent.GetSnapshot("base")
+			assert.NoError(t, snap.Unprotect())
+			assert.NoError(t, snap.Remove())
+		}
+	}
+
+	baseName := GetUUID()
+	err = CreateImage(ioctx, baseName, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, baseName)) }()
+	child1, cleanup1 := makeClone(baseName)
+	defer cleanup1()
+	child2, cleanup2 := makeClone(child1)
+	defer cleanup2()
+
+	depthOf := func(name string) (int, []ParentInfo) {
+		image, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+		depth, err := image.GetCloneDepth()
+		assert.NoError(t, err)
+		chain, err := image.GetCloneChain()
+		assert.NoError(t, err)
+		return depth, chain
+	}
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		image := GetImage(ioctx, baseName)
+		_, err := image.GetCloneDepth()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	t.Run("standalone", func(t *testing.T) {
+		depth, chain := depthOf(baseName)
+		assert.Equal(t, 0, depth)
+		assert.Len(t, chain, 0)
+	})
+
+	t.Run("oneLevel", func(t *testing.T) {
+		depth, chain := depthOf(child1)
+		assert.Equal(t, 1, depth)
+		if assert.Len(t, chain, 1) {
+			assert.Equal(t, baseName, chain[0].Image.ImageName)
+		}
+	})
+
+	t.Run("twoLevels", func(t *testing.T) {
+		depth, chain := depthOf(child2)
+		assert.Equal(t, 2, depth)
+		if assert.Len(t, chain, 2) {
+			assert.Equal(t, child1, chain[0].Image.ImageName)
+			assert.Equal(t, "base", chain[0].Snap.SnapName)
+			// the top-most ancestor
+			assert.Equal(t, baseName, chain[1].Image.ImageName)
+			assert.Equal(t, poolname, chain[1].Image.PoolName)
+		}
+	})
+}
+
+func TestGetTimestamps(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	its, err := image.GetTimestamps()
+	assert.NoError(t, err)
+	created, err := image.GetCreateTimestamp()
+	assert.NoError(t, err)
+	assert.Equal(t, created, its.Create)
+	assert.NotZero(t, its.Access.Sec)
+	assert.NotZero(t, its.Modify.Sec)
+
+	t.Run("closedImage", func(t *testing.T) {
+		_, err := GetImage(ioctx, name).GetTimestamps()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
+
+func TestListImagesInNamespace(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	images := map[string][]string{}
+	for _, ns := range []string{"", "a", "b"} {
+		if ns != "" {
+			require.NoError(t, NamespaceCreate(ioctx, ns))
+		}
+		nsIoctx, err := conn.OpenIOContext(poolname)
+		require.NoError(t, err)
+		nsIoctx.SetNamespace(ns)
+		for i := 0; i < 2; i++ {
+			name := GetUUID()
+			err := quickCreate(nsIoctx, name, testImageSize, testImageOrder)
+			require.NoError(t, err)
+			images[ns] = append(images[ns], name)
+		}
+		nsIoctx.Destroy()
+	}
+	defer func() {
+		for ns, names := range images {
+			ioctx.SetNamespace(ns)
+			for _, name := range names {
+				assert.NoError(t, RemoveImage(ioctx, name))
+			}
+		}
+		ioctx.SetNamespace("")
+		assert.NoError(t, NamespaceRemove(ioctx, "a"))
+		assert.NoError(t, NamespaceRemove(ioctx, "b"))
+	}()
+
+	ioctx.SetNamespace("a")
+	for _, ns := range []string{"", "a", "b"} {
+		names, err := ListImagesInNamespace(ioctx, ns)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, images[ns], names)
+		// the namespace of the IO context is restored
+		current, err := ioctx.GetNamespace()
+		assert.NoError(t, err)
+		assert.Equal(t, "a", current)
+	}
+
+	// GetImageNames lists the namespace set on the IO context
+	ioctx.SetNamespace("b")
+	names, err := GetImageNames(ioctx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, images["b"], names)
+
+	_, err = ListImagesInNamespace(nil, "a")
+	assert.Equal(t, ErrNoIOContext, err)
+}
+
+func TestGetCloneOwnBytes(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering))
+
+	parentName := GetUUID()
+	// several objects, so writes can land in different objects
+	err = CreateImage(ioctx, parentName, 4*testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, parentName)) }()
+
+	parent, err := OpenImage(ioctx, parentName, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, parent.Close()) }()
+	data := make([]byte, 64*1024)
+	for i := range data {
+		data[i] = 'p'
+	}
+	_, err = parent.WriteAt(data, 0)
+	require.NoError(t, err)
+	snap, err := parent.CreateSnapshot("base")
+	require.NoError(t, err)
+	require.NoError(t, snap.Protect())
+	defer func() {
+		assert.NoError(t, snap.Unprotect())
+		assert.NoError(t, snap.Remove())
+	}()
+
+	t.Run("notAClone", func(t *testing.T) {
+		_, err := parent.GetCloneOwnBytes()
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		_, err := GetImage(ioctx, parentName).GetCloneOwnBytes()
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	cloneName := GetUUID()
+	err = CloneImage(ioctx, parentName, "base", ioctx, cloneName, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, cloneName)) }()
+
+	clone, err := OpenImage(ioctx, cloneName, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, clone.Close()) }()
+
+	// nothing was written to the clone yet, the parent data does not count
+	owned, err := clone.GetCloneOwnBytes()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, owned)
+
+	_, err = clone.WriteAt([]byte("written to the clone"), 0)
+	require.NoError(t, err)
+	owned1, err := clone.GetCloneOwnBytes()
+	assert.NoError(t, err)
+	assert.NotZero(t, owned1)
+
+	// a write to a different object of the image
+	_, err = clone.WriteAt([]byte("more"), 2*int64(testImageSize))
+	require.NoError(t, err)
+	owned2, err := clone.GetCloneOwnBytes()
+	assert.NoError(t, err)
+	assert.Greater(t, owned2, owned1)
+}
@@ -0,0 +1,43 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #include <errno.h>
+import "C"
+
+// ShrinkAndReclaim reduces the size of the image to newSize and then makes
+// the remaining image sparse, deallocating runs of zeros that are at least
+// sparseSize bytes long, for example after the filesystem of a guest was
+// shrunk and trimmed. The sparseSize value must be a power of two no less
+// than 4096 and no larger than the new size of the image. Data beyond
+// newSize is discarded. An error is returned, without changing the image,
+// if newSize is larger than the current size or sparseSize is not valid.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_resize(rbd_image_t image, uint64_t size);
+//  int rbd_sparsify(rbd_image_t image, size_t sparse_size);
+func (image *Image) ShrinkAndReclaim(newSize uint64, sparseSize uint64) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	size, err := image.GetSize()
+	if err != nil {
+		return err
+	}
+	if newSize > size {
+		return rbdError(C.EINVAL)
+	}
+	// check sparseSize before resizing, so that the image is left as it is
+	// rather than shrunk but not sparsified
+	if sparseSize < 4096 || sparseSize&(sparseSize-1) != 0 || sparseSize > newSize {
+		return rbdError(C.EINVAL)
+	}
+	if newSize < size {
+		if err := image.Resize(newSize); err != nil {
+			return err
+		}
+	}
+	return image.Sparsify(uint(sparseSize))
+}
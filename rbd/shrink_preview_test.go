@@ -0,0 +1,116 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allocatedBytes returns the number of bytes of the image that are backed
+// by data objects.
+func allocatedBytes(t *testing.T, image *Image) uint64 {
+	size, err := image.GetSize()
+	require.NoError(t, err)
+	var total uint64
+	err = image.DiffIterate(DiffIterateConfig{
+		Length: size,
+		Callback: func(_, length uint64, exists int, _ interface{}) int {
+			if exists != 0 {
+				total += length
+			}
+			return 0
+		},
+	})
+	require.NoError(t, err)
+	return total
+}
+
+func TestShrinkAndReclaim(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+
+	name := GetUUID()
+	err = CreateImage(ioctx, name, 4*testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	// the first object holds some data followed by zeros, the second one
+	// only zeros and the last two are cut off by the shrink
+	data := bytes.Repeat([]byte("data"), 1<<18)
+	zeros := make([]byte, testImageSize)
+	_, err = image.WriteAt(zeros, 0)
+	require.NoError(t, err)
+	_, err = image.WriteAt(data, 0)
+	require.NoError(t, err)
+	_, err = image.WriteAt(zeros, int64(testImageSize))
+	require.NoError(t, err)
+	_, err = image.WriteAt(data, int64(2*testImageSize))
+	require.NoError(t, err)
+	_, err = image.WriteAt(data, int64(3*testImageSize))
+	require.NoError(t, err)
+	before := allocatedBytes(t, image)
+
+	t.Run("growRejected", func(t *testing.T) {
+		err := image.ShrinkAndReclaim(8*testImageSize, 4096)
+		assert.Error(t, err)
+		size, err := image.GetSize()
+		assert.NoError(t, err)
+		assert.Equal(t, 4*testImageSize, size)
+	})
+
+	t.Run("invalidSparseSize", func(t *testing.T) {
+		for _, sparseSize := range []uint64{0, 1024, 4096 + 512, 4 * testImageSize} {
+			err := image.ShrinkAndReclaim(2*testImageSize, sparseSize)
+			assert.Error(t, err, "sparseSize %d", sparseSize)
+		}
+		size, err := image.GetSize()
+		assert.NoError(t, err)
+		assert.Equal(t, 4*testImageSize, size)
+	})
+
+	t.Run("shrink", func(t *testing.T) {
+		err := image.ShrinkAndReclaim(2*testImageSize, 4096)
+		require.NoError(t, err)
+
+		size, err := image.GetSize()
+		assert.NoError(t, err)
+		assert.Equal(t, 2*testImageSize, size)
+		after := allocatedBytes(t, image)
+		assert.Less(t, after, before)
+		assert.LessOrEqual(t, after, uint64(len(data)))
+
+		// the data that is kept is unchanged
+		buf := make([]byte, len(data))
+		_, err = image.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, data, buf)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		err := GetImage(ioctx, name).ShrinkAndReclaim(testImageSize, 4096)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
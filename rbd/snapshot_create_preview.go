@@ -0,0 +1,88 @@
+//go:build !nautilus && ceph_preview
+// +build !nautilus,ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <stdlib.h>
+#include <rbd/librbd.h>
+
+extern int imageSnapCreateCallback(uint64_t, uint64_t, uintptr_t);
+
+// inline wrapper to cast uintptr_t to void*
+static inline int wrap_rbd_snap_create2(
+		rbd_image_t image, const char *snap_name, uint32_t flags, uintptr_t arg) {
+	return rbd_snap_create2(
+		image, snap_name, flags, (librbd_progress_fn_t)imageSnapCreateCallback, (void*)arg);
+};
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/callbacks"
+)
+
+// SnapCreateFlags controls how a snapshot is created by
+// CreateSnapshotWithFlags.
+type SnapCreateFlags uint32
+
+const (
+	// SnapCreateSkipQuiesce skips notifying the clients of the image to
+	// quiesce IO before the snapshot is taken.
+	SnapCreateSkipQuiesce = SnapCreateFlags(C.RBD_SNAP_CREATE_SKIP_QUIESCE)
+	// SnapCreateIgnoreQuiesceError takes the snapshot even if a client of
+	// the image failed to quiesce IO.
+	SnapCreateIgnoreQuiesceError = SnapCreateFlags(C.RBD_SNAP_CREATE_IGNORE_QUIESCE_ERROR)
+)
+
+var imageSnapCreateCallbacks = callbacks.New()
+
+// CreateSnapshotWithFlags creates a snapshot of the image with the given
+// name. The flags control whether the clients of the image are asked to
+// quiesce IO before the snapshot is taken. The optional callback is called
+// to report the progress of the snapshot creation with the amount of work
+// done and the total amount of work.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_snap_create2(rbd_image_t image, const char *snapname, uint32_t flags,
+//                       librbd_progress_fn_t cb, void *cbdata);
+func (image *Image) CreateSnapshotWithFlags(
+	name string, flags SnapCreateFlags, cb func(done, total uint64) int) error {
+
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if name == "" {
+		return ErrSnapshotNoName
+	}
+	// librbd always calls the progress function
+	if cb == nil {
+		cb = func(done, total uint64) int { return 0 }
+	}
+
+	cSnapName := C.CString(name)
+	defer C.free(unsafe.Pointer(cSnapName))
+
+	cbIndex := imageSnapCreateCallbacks.Add(cb)
+	defer imageSnapCreateCallbacks.Remove(cbIndex)
+
+	ret := C.wrap_rbd_snap_create2(
+		image.image,
+		cSnapName,
+		C.uint32_t(flags),
+		C.uintptr_t(cbIndex))
+	return getError(ret)
+}
+
+//export imageSnapCreateCallback
+func imageSnapCreateCallback(
+	offset, total C.uint64_t, index uintptr) C.int {
+
+	v := imageSnapCreateCallbacks.Lookup(index)
+	cb := v.(func(done, total uint64) int)
+	return C.int(cb(uint64(offset), uint64(total)))
+}
@@ -0,0 +1,75 @@
+//go:build !nautilus && ceph_preview
+// +build !nautilus,ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSnapshotWithFlags(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	t.Run("skipQuiesce", func(t *testing.T) {
+		// no quiesce watcher is registered on the image
+		cc := 0
+		err := image.CreateSnapshotWithFlags("snap1", SnapCreateSkipQuiesce,
+			func(done, total uint64) int {
+				cc++
+				return 0
+			})
+		assert.NoError(t, err)
+		defer func() { assert.NoError(t, image.GetSnapshot("snap1").Remove()) }()
+
+		snaps, err := image.GetSnapshotNames()
+		assert.NoError(t, err)
+		if assert.Len(t, snaps, 1) {
+			assert.Equal(t, "snap1", snaps[0].Name)
+		}
+	})
+
+	t.Run("ignoreQuiesceErrorNoCallback", func(t *testing.T) {
+		err := image.CreateSnapshotWithFlags(
+			"snap2", SnapCreateIgnoreQuiesceError, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, image.GetSnapshot("snap2").Remove())
+	})
+
+	t.Run("duplicateName", func(t *testing.T) {
+		err := image.CreateSnapshotWithFlags("snap3", SnapCreateSkipQuiesce, nil)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.GetSnapshot("snap3").Remove()) }()
+		err = image.CreateSnapshotWithFlags("snap3", SnapCreateSkipQuiesce, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := image.CreateSnapshotWithFlags("", SnapCreateSkipQuiesce, nil)
+		assert.Equal(t, ErrSnapshotNoName, err)
+		err = GetImage(ioctx, name).CreateSnapshotWithFlags(
+			"snap4", SnapCreateSkipQuiesce, nil)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
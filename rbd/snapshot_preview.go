@@ -0,0 +1,291 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #cgo LDFLAGS: -lrbd -lrados
+// #include <errno.h>
+// #include <stdlib.h>
+// #include <rados/librados.h>
+// #include <rbd/librbd.h>
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/retry"
+	"github.com/ceph/go-ceph/rados"
+)
+
+const (
+	errBusy = rbdError(-C.EBUSY)
+)
+
+// ErrSnapshotNotFound is returned by OpenSnapshotReadOnly if the image exists
+// but the snapshot does not.
+var ErrSnapshotNotFound = errors.New("RBD snapshot not found")
+
+// listAllSnapshotsWorkers bounds the number of images ListAllSnapshots keeps
+// open at the same time.
+const listAllSnapshotsWorkers = 8
+
+// PoolSnapInfo describes a snapshot of an image, including the time the
+// snapshot was taken.
+type PoolSnapInfo struct {
+	SnapInfo
+	Timestamp Timespec
+}
+
+// RemoveSnapshotWithOpts removes the named snapshot of the image. If the
+// snapshot can not be removed because it is in use by clones and force is
+// true, and the image has the deep-flatten feature enabled, all clones of the
+// snapshot are flattened, the snapshot is unprotected if needed, and the
+// removal is retried.
+//
+// Flattening copies all of the data from the parent into each clone and can
+// take a very long time for large images. The call does not return until all
+// clones have been flattened.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_snap_remove(rbd_image_t image, const char *snapname);
+func (image *Image) RemoveSnapshotWithOpts(snap string, force bool) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if snap == "" {
+		return ErrSnapshotNoName
+	}
+
+	snapshot := image.GetSnapshot(snap)
+	err := snapshot.Remove()
+	if err != errBusy || !force {
+		return err
+	}
+
+	features, ferr := image.GetFeatures()
+	if ferr != nil {
+		return ferr
+	}
+	if features&FeatureDeepFlatten == 0 {
+		return err
+	}
+
+	if err := image.flattenSnapshotChildren(snap); err != nil {
+		return err
+	}
+	protected, err := snapshot.IsProtected()
+	if err != nil {
+		return err
+	}
+	if protected {
+		if err := snapshot.Unprotect(); err != nil {
+			return err
+		}
+	}
+	return snapshot.Remove()
+}
+
+// flattenSnapshotChildren flattens every image that is a clone of the named
+// snapshot of the image, including clones in other pools or in the trash.
+func (image *Image) flattenSnapshotChildren(snap string) error {
+	snapImage, err := OpenImageReadOnly(image.ioctx, image.name, snap)
+	if err != nil {
+		return err
+	}
+	defer snapImage.Close()
+
+	var (
+		csize    C.size_t
+		children []C.rbd_linked_image_spec_t
+	)
+	retry.WithSizes(16, 4096, func(size int) retry.Hint {
+		csize = C.size_t(size)
+		children = make([]C.rbd_linked_image_spec_t, csize)
+		ret := C.rbd_list_children3(
+			snapImage.image,
+			(*C.rbd_linked_image_spec_t)(unsafe.Pointer(&children[0])),
+			&csize)
+		err = getErrorIfNegative(ret)
+		return retry.Size(int(csize)).If(err == errRange)
+	})
+	if err != nil {
+		return err
+	}
+	defer C.rbd_linked_image_spec_list_cleanup(
+		(*C.rbd_linked_image_spec_t)(unsafe.Pointer(&children[0])), csize)
+
+	cluster := C.rados_ioctx_get_cluster(cephIoctx(image.ioctx))
+	for i := range children[:csize] {
+		if err := flattenLinkedImage(cluster, &children[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flattenLinkedImage(cluster C.rados_t, spec *C.rbd_linked_image_spec_t) error {
+	var ioctx C.rados_ioctx_t
+	ret := C.rados_ioctx_create2(cluster, C.int64_t(spec.pool_id), &ioctx)
+	if ret < 0 {
+		return getError(ret)
+	}
+	defer C.rados_ioctx_destroy(ioctx)
+	C.rados_ioctx_set_namespace(ioctx, spec.pool_namespace)
+
+	var cImage C.rbd_image_t
+	ret = C.rbd_open_by_id(ioctx, spec.image_id, &cImage, nil)
+	if ret < 0 {
+		return getError(ret)
+	}
+	defer C.rbd_close(cImage)
+
+	return getError(C.rbd_flatten(cImage))
+}
+
+// pruneNow returns the current time for PruneSnapshotsOlderThan; it is
+// replaced by tests.
+var pruneNow = time.Now
+
+// PruneSnapshotsOlderThan removes the snapshots of the image in the given
+// namespace that were created more than d ago. Protected snapshots are
+// skipped. The names of the removed snapshots are returned, in the order
+// they were removed, together with the first error encountered, if any.
+//
+// Snapshots are removed by name, which librbd only supports for user
+// snapshots, so pruning snapshots of other namespaces will fail.
+//  PREVIEW
+func (image *Image) PruneSnapshotsOlderThan(d time.Duration, nsType SnapNamespaceType) ([]string, error) {
+	snaps, err := image.GetSnapshotNames()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := pruneNow().Add(-d)
+	removed := []string{}
+	for _, snap := range snaps {
+		t, err := image.GetSnapNamespaceType(snap.Id)
+		if err != nil {
+			return removed, err
+		}
+		if t != nsType {
+			continue
+		}
+		ts, err := image.GetSnapTimestamp(snap.Id)
+		if err != nil {
+			return removed, err
+		}
+		if !time.Unix(ts.Sec, ts.Nsec).Before(cutoff) {
+			continue
+		}
+		snapshot := image.GetSnapshot(snap.Name)
+		protected, err := snapshot.IsProtected()
+		if err != nil {
+			return removed, err
+		}
+		if protected {
+			continue
+		}
+		if err := snapshot.Remove(); err != nil {
+			return removed, err
+		}
+		removed = append(removed, snap.Name)
+	}
+	return removed, nil
+}
+
+// SnapshotExists returns true if the image has a snapshot with the given
+// name. A missing snapshot is not an error.
+//  PREVIEW
+func (image *Image) SnapshotExists(name string) (bool, error) {
+	if name == "" {
+		return false, ErrSnapshotNoName
+	}
+	snaps, err := image.GetSnapshotNames()
+	if err != nil {
+		return false, err
+	}
+	for _, snap := range snaps {
+		if snap.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// OpenSnapshotReadOnly opens the named image read-only at the named
+// snapshot, for reading the data of the image at the time the snapshot was
+// taken. ErrSnapshotNotFound is returned if the image exists but the
+// snapshot does not, and ErrNotFound if the image does not exist.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_open_read_only(rados_ioctx_t io, const char *name,
+//                         rbd_image_t *image, const char *snap_name);
+func OpenSnapshotReadOnly(ioctx *rados.IOContext, name, snap string) (*Image, error) {
+	if snap == NoSnapshot {
+		return nil, ErrSnapshotNoName
+	}
+	image, err := OpenImageReadOnly(ioctx, name, snap)
+	if err != ErrNotFound {
+		return image, err
+	}
+	// tell a missing snapshot apart from a missing image
+	head, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := head.Close(); err != nil {
+		return nil, err
+	}
+	return nil, ErrSnapshotNotFound
+}
+
+// ListAllSnapshots returns the snapshots of every image in the pool keyed by
+// image name. Images without snapshots are included with an empty list. The
+// images are opened read-only, a few at a time, to list their snapshots.
+// Images that are removed while the snapshots are being collected are left
+// out of the result.
+//  PREVIEW
+func ListAllSnapshots(ioctx *rados.IOContext) (map[string][]PoolSnapInfo, error) {
+	var lock sync.Mutex
+	all := map[string][]PoolSnapInfo{}
+	err := forEachImage(ioctx, listAllSnapshotsWorkers, func(name string) error {
+		snaps, err := imageSnapshots(ioctx, name)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		all[name] = snaps
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func imageSnapshots(ioctx *rados.IOContext, name string) ([]PoolSnapInfo, error) {
+	image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	defer image.Close()
+	snaps, err := image.GetSnapshotNames()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]PoolSnapInfo, len(snaps))
+	for i, snap := range snaps {
+		ts, err := image.GetSnapTimestamp(snap.Id)
+		if err != nil {
+			return nil, err
+		}
+		infos[i] = PoolSnapInfo{SnapInfo: snap, Timestamp: ts}
+	}
+	return infos, nil
+}
@@ -0,0 +1,380 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveSnapshotWithOpts(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	assert.NoError(t,
+		options.SetUint64(ImageOptionFeatures, FeatureLayering|FeatureDeepFlatten))
+
+	parentName := GetUUID()
+	err = CreateImage(ioctx, parentName, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, parentName)) }()
+
+	parent, err := OpenImage(ioctx, parentName, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, parent.Close()) }()
+	_, err = parent.WriteAt([]byte("parent data"), 0)
+	require.NoError(t, err)
+
+	snapName := "snap1"
+	snapshot, err := parent.CreateSnapshot(snapName)
+	require.NoError(t, err)
+	require.NoError(t, snapshot.Protect())
+
+	cloneName := GetUUID()
+	err = CloneImage(ioctx, parentName, snapName, ioctx, cloneName, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, cloneName)) }()
+
+	t.Run("withoutForce", func(t *testing.T) {
+		err := parent.RemoveSnapshotWithOpts(snapName, false)
+		assert.Equal(t, errBusy, err)
+	})
+
+	t.Run("withForce", func(t *testing.T) {
+		err := parent.RemoveSnapshotWithOpts(snapName, true)
+		assert.NoError(t, err)
+
+		snaps, err := parent.GetSnapshotNames()
+		assert.NoError(t, err)
+		assert.Len(t, snaps, 0)
+
+		// the clone was flattened and keeps the data it had from the parent
+		clone, err := OpenImage(ioctx, cloneName, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, clone.Close()) }()
+		_, err = clone.GetParent()
+		assert.Error(t, err)
+		b := make([]byte, 11)
+		_, err = clone.ReadAt(b, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "parent data", string(b))
+	})
+
+	t.Run("noSuchSnapshot", func(t *testing.T) {
+		err := parent.RemoveSnapshotWithOpts("no.such.snap", true)
+		assert.Error(t, err)
+	})
+
+	t.Run("noName", func(t *testing.T) {
+		err := parent.RemoveSnapshotWithOpts("", true)
+		assert.Equal(t, ErrSnapshotNoName, err)
+	})
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		image := GetImage(ioctx, parentName)
+		err := image.RemoveSnapshotWithOpts(snapName, true)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
+
+func TestPruneSnapshotsOlderThan(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		img := GetImage(ioctx, name)
+		_, err := img.PruneSnapshotsOlderThan(time.Hour, SnapNamespaceTypeUser)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	_, err = image.CreateSnapshot("old1")
+	require.NoError(t, err)
+	_, err = image.CreateSnapshot("old2")
+	require.NoError(t, err)
+	protected, err := image.CreateSnapshot("oldProtected")
+	require.NoError(t, err)
+	require.NoError(t, protected.Protect())
+	defer func() {
+		assert.NoError(t, protected.Unprotect())
+		assert.NoError(t, protected.Remove())
+	}()
+	recent, err := image.CreateSnapshot("recent")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, recent.Remove()) }()
+
+	snapTime := func(name string) time.Time {
+		snaps, err := image.GetSnapshotNames()
+		require.NoError(t, err)
+		for _, s := range snaps {
+			if s.Name == name {
+				ts, err := image.GetSnapTimestamp(s.Id)
+				require.NoError(t, err)
+				return time.Unix(ts.Sec, ts.Nsec)
+			}
+		}
+		t.Fatalf("snapshot %s not found", name)
+		return time.Time{}
+	}
+	// pretend an hour passed since the recent snapshot was created, so that
+	// exactly the snapshots created before it are older than an hour
+	recentTime := snapTime("recent")
+	require.True(t, snapTime("oldProtected").Before(recentTime))
+	pruneNow = func() time.Time { return recentTime.Add(time.Hour) }
+	defer func() { pruneNow = time.Now }()
+
+	t.Run("otherNamespace", func(t *testing.T) {
+		removed, err := image.PruneSnapshotsOlderThan(time.Hour, SnapNamespaceTypeTrash)
+		assert.NoError(t, err)
+		assert.Len(t, removed, 0)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		removed, err := image.PruneSnapshotsOlderThan(time.Hour, SnapNamespaceTypeUser)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"old1", "old2"}, removed)
+
+		snaps, err := image.GetSnapshotNames()
+		assert.NoError(t, err)
+		names := []string{}
+		for _, s := range snaps {
+			names = append(names, s.Name)
+		}
+		assert.ElementsMatch(t, []string{"oldProtected", "recent"}, names)
+	})
+
+	t.Run("nothingEligible", func(t *testing.T) {
+		removed, err := image.PruneSnapshotsOlderThan(2*time.Hour, SnapNamespaceTypeUser)
+		assert.NoError(t, err)
+		assert.Len(t, removed, 0)
+	})
+}
+
+func TestOpenSnapshotReadOnly(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	_, err = img.WriteAt([]byte("before"), 0)
+	require.NoError(t, err)
+	snap, err := img.CreateSnapshot("snap1")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snap.Remove()) }()
+	_, err = img.WriteAt([]byte("after!"), 0)
+	require.NoError(t, err)
+	require.NoError(t, img.Close())
+
+	simg, err := OpenSnapshotReadOnly(ioctx, name, "snap1")
+	require.NoError(t, err)
+	buf := make([]byte, 6)
+	_, err = simg.ReadAt(buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "before", string(buf))
+	// the image is opened read-only
+	_, err = simg.WriteAt([]byte("nope"), 0)
+	assert.Error(t, err)
+	assert.NoError(t, simg.Close())
+
+	img, err = OpenImageReadOnly(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	_, err = img.ReadAt(buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "after!", string(buf))
+	assert.NoError(t, img.Close())
+
+	t.Run("missingSnapshot", func(t *testing.T) {
+		_, err := OpenSnapshotReadOnly(ioctx, name, "nosnap")
+		assert.Equal(t, ErrSnapshotNotFound, err)
+	})
+
+	t.Run("missingImage", func(t *testing.T) {
+		_, err := OpenSnapshotReadOnly(ioctx, GetUUID(), "snap1")
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("invalidArgs", func(t *testing.T) {
+		_, err := OpenSnapshotReadOnly(ioctx, name, NoSnapshot)
+		assert.Equal(t, ErrSnapshotNoName, err)
+		_, err = OpenSnapshotReadOnly(nil, name, "snap1")
+		assert.Equal(t, ErrNoIOContext, err)
+	})
+}
+
+func TestListAllSnapshots(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	t.Run("emptyPool", func(t *testing.T) {
+		all, err := ListAllSnapshots(ioctx)
+		assert.NoError(t, err)
+		assert.Len(t, all, 0)
+	})
+
+	t.Run("noIOContext", func(t *testing.T) {
+		_, err := ListAllSnapshots(nil)
+		assert.Equal(t, ErrNoIOContext, err)
+	})
+
+	expected := map[string][]string{
+		GetUUID(): {"a1", "a2"},
+		GetUUID(): {"b1"},
+		GetUUID(): {},
+	}
+	start := time.Now().Add(-time.Minute)
+	for name, snaps := range expected {
+		options := NewRbdImageOptions()
+		assert.NoError(t,
+			options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+		err := CreateImage(ioctx, name, testImageSize, options)
+		options.Destroy()
+		require.NoError(t, err)
+		defer func(name string, snaps []string) {
+			img, err := OpenImage(ioctx, name, NoSnapshot)
+			require.NoError(t, err)
+			for _, snap := range snaps {
+				assert.NoError(t, img.GetSnapshot(snap).Remove())
+			}
+			assert.NoError(t, img.Close())
+			assert.NoError(t, RemoveImage(ioctx, name))
+		}(name, snaps)
+
+		img, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		for _, snap := range snaps {
+			_, err := img.CreateSnapshot(snap)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, img.Close())
+	}
+
+	all, err := ListAllSnapshots(ioctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, len(expected))
+	for name, snaps := range expected {
+		infos, found := all[name]
+		if !assert.True(t, found, "image %s missing", name) {
+			continue
+		}
+		got := []string{}
+		for _, info := range infos {
+			got = append(got, info.Name)
+			assert.NotZero(t, info.Id)
+			assert.True(t,
+				time.Unix(info.Timestamp.Sec, info.Timestamp.Nsec).After(start))
+		}
+		assert.ElementsMatch(t, snaps, got)
+	}
+}
+
+func TestSnapshotExists(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	t.Run("imageNotOpen", func(t *testing.T) {
+		img := GetImage(ioctx, name)
+		_, err := img.SnapshotExists("snap")
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+
+	img, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, img.Close()) }()
+
+	exists, err := img.SnapshotExists("snap")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	snapshot, err := img.CreateSnapshot("snap")
+	require.NoError(t, err)
+
+	exists, err = img.SnapshotExists("snap")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = img.SnapshotExists("snap2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = img.SnapshotExists("")
+	assert.Equal(t, ErrSnapshotNoName, err)
+
+	assert.NoError(t, snapshot.Remove())
+	exists, err = img.SnapshotExists("snap")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,77 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd
+#include <errno.h>
+#include <stdlib.h>
+#include <rbd/librbd.h>
+
+extern int imageSnapRollbackCallback(uint64_t, uint64_t, uintptr_t);
+
+// inline wrapper to cast uintptr_t to void*
+static inline int wrap_rbd_snap_rollback_with_progress(
+		rbd_image_t image, const char *snap_name, uintptr_t arg) {
+	return rbd_snap_rollback_with_progress(
+		image, snap_name, (librbd_progress_fn_t)imageSnapRollbackCallback, (void*)arg);
+};
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/ceph/go-ceph/internal/callbacks"
+)
+
+var imageSnapRollbackCallbacks = callbacks.New()
+
+// RollbackToSnapshotWithProgress rolls back the image to the named snapshot.
+// The given callback is called to report the progress of the rollback with
+// the amount of work done and the total amount of work. Returning a non-zero
+// value from the callback aborts the rollback, which leaves the image
+// partially rolled back.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_snap_rollback_with_progress(rbd_image_t image,
+//                                      const char *snapname,
+//                                      librbd_progress_fn_t cb,
+//                                      void *cbdata);
+func (image *Image) RollbackToSnapshotWithProgress(
+	snap string, cb func(done, total uint64) int) error {
+
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if snap == "" {
+		return ErrSnapshotNoName
+	}
+	// the provided callback must be a real function
+	if cb == nil {
+		return rbdError(C.EINVAL)
+	}
+
+	cSnapName := C.CString(snap)
+	defer C.free(unsafe.Pointer(cSnapName))
+
+	cbIndex := imageSnapRollbackCallbacks.Add(cb)
+	defer imageSnapRollbackCallbacks.Remove(cbIndex)
+
+	ret := C.wrap_rbd_snap_rollback_with_progress(
+		image.image,
+		cSnapName,
+		C.uintptr_t(cbIndex))
+	return getError(ret)
+}
+
+//export imageSnapRollbackCallback
+func imageSnapRollbackCallback(
+	offset, total C.uint64_t, index uintptr) C.int {
+
+	v := imageSnapRollbackCallbacks.Lookup(index)
+	cb := v.(func(done, total uint64) int)
+	return C.int(cb(uint64(offset), uint64(total)))
+}
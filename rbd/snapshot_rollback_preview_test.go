@@ -0,0 +1,90 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackToSnapshotWithProgress(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	name := GetUUID()
+	options := NewRbdImageOptions()
+	defer options.Destroy()
+	assert.NoError(t,
+		options.SetUint64(ImageOptionOrder, uint64(testImageOrder)))
+	err = CreateImage(ioctx, name, testImageSize, options)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	_, err = image.WriteAt([]byte("before the snapshot"), 0)
+	require.NoError(t, err)
+	snapName := "rollme"
+	snapshot, err := image.CreateSnapshot(snapName)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, snapshot.Remove()) }()
+
+	t.Run("rollback", func(t *testing.T) {
+		_, err := image.WriteAt([]byte("after the snapshot!"), 0)
+		require.NoError(t, err)
+
+		cc := 0
+		var lastDone, lastTotal uint64
+		err = image.RollbackToSnapshotWithProgress(snapName,
+			func(done, total uint64) int {
+				cc++
+				lastDone, lastTotal = done, total
+				return 0
+			})
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, cc, 1)
+		assert.Equal(t, lastTotal, lastDone)
+
+		b := make([]byte, 19)
+		_, err = image.ReadAt(b, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "before the snapshot", string(b))
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		_, err := image.WriteAt([]byte("after the snapshot!"), 0)
+		require.NoError(t, err)
+
+		err = image.RollbackToSnapshotWithProgress(snapName,
+			func(done, total uint64) int { return -1 })
+		assert.Error(t, err)
+	})
+
+	t.Run("invalidArgs", func(t *testing.T) {
+		cb := func(done, total uint64) int { return 0 }
+		err := image.RollbackToSnapshotWithProgress(snapName, nil)
+		assert.Error(t, err)
+		err = image.RollbackToSnapshotWithProgress("", cb)
+		assert.Equal(t, ErrSnapshotNoName, err)
+		err = image.RollbackToSnapshotWithProgress("no.such.snap", cb)
+		assert.Error(t, err)
+
+		img := GetImage(ioctx, name)
+		err = img.RollbackToSnapshotWithProgress(snapName, cb)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
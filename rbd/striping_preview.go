@@ -0,0 +1,89 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+// #include <errno.h>
+import "C"
+
+import (
+	"strconv"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// defaultImageOrder is the object size order librbd uses when
+// rbd_default_order is not available from the configuration.
+const defaultImageOrder = 22
+
+// poolDefaultOrder returns the object size order new images in the pool of
+// the given IO context are created with.
+func poolDefaultOrder(ioctx *rados.IOContext) (uint64, error) {
+	options, err := PoolConfigList(ioctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, o := range options {
+		if o.Name != "rbd_default_order" {
+			continue
+		}
+		order, err := strconv.ParseUint(o.Value, 10, 8)
+		if err != nil {
+			break
+		}
+		return order, nil
+	}
+	return defaultImageOrder, nil
+}
+
+// CreateStripedImage creates an image named name with the given size that
+// stripes its data over stripeCount objects in units of stripeUnit bytes.
+// The image uses the default object size of the pool. The stripe unit must
+// be a power of two no larger than the object size and the stripe count
+// must be at least one; otherwise an error is returned without creating the
+// image. If features is zero the default features are used.
+//  PREVIEW
+//
+// Implements:
+//  int rbd_create4(rados_ioctx_t io, const char *name, uint64_t size,
+//                  rbd_image_options_t opts);
+func CreateStripedImage(ioctx *rados.IOContext, name string,
+	size, stripeUnit uint64, stripeCount uint32, features uint64) error {
+
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if name == "" {
+		return ErrNoName
+	}
+	order, err := poolDefaultOrder(ioctx)
+	if err != nil {
+		return err
+	}
+	objectSize := uint64(1) << order
+	if stripeUnit == 0 || stripeUnit&(stripeUnit-1) != 0 ||
+		stripeUnit > objectSize || stripeCount < 1 {
+		return rbdError(C.EINVAL)
+	}
+
+	rio := NewRbdImageOptions()
+	defer rio.Destroy()
+	if err := rio.SetUint64(ImageOptionOrder, order); err != nil {
+		return err
+	}
+	if features != 0 {
+		if stripeUnit != objectSize || stripeCount != 1 {
+			features |= FeatureStripingV2
+		}
+		if err := rio.SetUint64(ImageOptionFeatures, features); err != nil {
+			return err
+		}
+	}
+	if err := rio.SetUint64(ImageOptionStripeUnit, stripeUnit); err != nil {
+		return err
+	}
+	if err := rio.SetUint64(ImageOptionStripeCount, uint64(stripeCount)); err != nil {
+		return err
+	}
+	return CreateImage(ioctx, name, size, rio)
+}
@@ -0,0 +1,96 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateStripedImage(t *testing.T) {
+	conn := radosConnect(t)
+	require.NotNil(t, conn)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	t.Run("striped", func(t *testing.T) {
+		name := GetUUID()
+		err := CreateStripedImage(ioctx, name, testImageSize, 1<<16, 4,
+			FeatureLayering)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+
+		stripeUnit, err := image.GetStripeUnit()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1<<16), stripeUnit)
+
+		stripeCount, err := image.GetStripeCount()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(4), stripeCount)
+
+		features, err := image.GetFeatures()
+		assert.NoError(t, err)
+		assert.Equal(t, FeatureStripingV2, features&FeatureStripingV2)
+	})
+
+	t.Run("defaultFeatures", func(t *testing.T) {
+		name := GetUUID()
+		err := CreateStripedImage(ioctx, name, testImageSize, 1<<20, 2, 0)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, image.Close()) }()
+
+		stripeUnit, err := image.GetStripeUnit()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1<<20), stripeUnit)
+
+		stripeCount, err := image.GetStripeCount()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), stripeCount)
+	})
+
+	t.Run("invalidParameters", func(t *testing.T) {
+		name := GetUUID()
+		// not a power of two
+		err := CreateStripedImage(ioctx, name, testImageSize, 3<<12, 2, 0)
+		assert.Error(t, err)
+		// zero stripe unit
+		err = CreateStripedImage(ioctx, name, testImageSize, 0, 2, 0)
+		assert.Error(t, err)
+		// larger than the default object size
+		err = CreateStripedImage(ioctx, name, testImageSize, 1<<23, 2, 0)
+		assert.Error(t, err)
+		// zero stripe count
+		err = CreateStripedImage(ioctx, name, testImageSize, 1<<16, 0, 0)
+		assert.Error(t, err)
+
+		names, err := GetImageNames(ioctx)
+		assert.NoError(t, err)
+		assert.NotContains(t, names, name)
+	})
+
+	t.Run("missingArgs", func(t *testing.T) {
+		err := CreateStripedImage(nil, "foo", testImageSize, 1<<16, 2, 0)
+		assert.Equal(t, ErrNoIOContext, err)
+		err = CreateStripedImage(ioctx, "", testImageSize, 1<<16, 2, 0)
+		assert.Equal(t, ErrNoName, err)
+	})
+}
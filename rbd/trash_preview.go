@@ -0,0 +1,48 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"github.com/ceph/go-ceph/rados"
+)
+
+// TrashMoveAndRemove deletes the named image by way of the trash: the image
+// is moved to the trash without a deferment period and then removed from
+// it. If the removal fails, for example because the image is still in use,
+// the image is restored under its original name so that it does not linger
+// in the trash, and the error of the removal is returned.
+//  PREVIEW
+func TrashMoveAndRemove(ioctx *rados.IOContext, name string) error {
+	if ioctx == nil {
+		return ErrNoIOContext
+	}
+	if name == "" {
+		return ErrNoName
+	}
+
+	// the trash is keyed by the image id, which must be looked up while
+	// the image can still be opened by name
+	image, err := OpenImageReadOnly(ioctx, name, NoSnapshot)
+	if err != nil {
+		return err
+	}
+	id, err := image.GetId()
+	if cerr := image.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := GetImage(ioctx, name).Trash(0); err != nil {
+		return err
+	}
+	if err := TrashRemove(ioctx, id, true); err != nil {
+		if rerr := TrashRestore(ioctx, id, ""); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	return nil
+}
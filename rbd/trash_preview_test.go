@@ -0,0 +1,72 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashMoveAndRemove(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+
+	t.Run("removed", func(t *testing.T) {
+		name := GetUUID()
+		err := quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+
+		err = TrashMoveAndRemove(ioctx, name)
+		assert.NoError(t, err)
+
+		names, err := GetImageNames(ioctx)
+		assert.NoError(t, err)
+		assert.NotContains(t, names, name)
+		trashList, err := GetTrashList(ioctx)
+		assert.NoError(t, err)
+		assert.Len(t, trashList, 0)
+	})
+
+	t.Run("inUse", func(t *testing.T) {
+		name := GetUUID()
+		err := quickCreate(ioctx, name, testImageSize, testImageOrder)
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+		image, err := OpenImage(ioctx, name, NoSnapshot)
+		require.NoError(t, err)
+		err = TrashMoveAndRemove(ioctx, name)
+		assert.Error(t, err)
+		assert.NoError(t, image.Close())
+
+		// the image was restored rather than left in the trash
+		names, err := GetImageNames(ioctx)
+		assert.NoError(t, err)
+		assert.Contains(t, names, name)
+		trashList, err := GetTrashList(ioctx)
+		assert.NoError(t, err)
+		assert.Len(t, trashList, 0)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		err := TrashMoveAndRemove(ioctx, GetUUID())
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Equal(t, ErrNoIOContext, TrashMoveAndRemove(nil, "foo"))
+		assert.Equal(t, ErrNoName, TrashMoveAndRemove(ioctx, ""))
+	})
+}
@@ -0,0 +1,58 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+/*
+#cgo LDFLAGS: -lrbd -lrados
+#include <rados/librados.h>
+#include <rbd/librbd.h>
+*/
+import "C"
+
+import (
+	"context"
+	"time"
+)
+
+// WaitForNoWatchers polls the watchers of the image every pollInterval
+// until no client other than the one the image was opened with watches
+// it. The context can be used to give up waiting, in which case the error
+// of the context is returned. The poll interval must be positive.
+//  PREVIEW
+//
+// Implements:
+//  uint64_t rados_get_instance_id(rados_t cluster);
+func (image *Image) WaitForNoWatchers(ctx context.Context, pollInterval time.Duration) error {
+	if err := image.validate(imageIsOpen); err != nil {
+		return err
+	}
+	if pollInterval <= 0 {
+		return rbdError(C.EINVAL)
+	}
+	cluster := C.rados_ioctx_get_cluster(cephIoctx(image.ioctx))
+	self := int64(C.rados_get_instance_id(cluster))
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		watchers, err := image.ListWatchers()
+		if err != nil {
+			return err
+		}
+		others := 0
+		for _, w := range watchers {
+			if w.Id != self {
+				others++
+			}
+		}
+		if others == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,82 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package rbd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForNoWatchers(t *testing.T) {
+	conn := radosConnect(t)
+	defer conn.Shutdown()
+	conn2 := radosConnect(t)
+	defer conn2.Shutdown()
+
+	poolname := GetUUID()
+	err := conn.MakePool(poolname)
+	require.NoError(t, err)
+	defer conn.DeletePool(poolname)
+
+	ioctx, err := conn.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx.Destroy()
+	ioctx2, err := conn2.OpenIOContext(poolname)
+	require.NoError(t, err)
+	defer ioctx2.Destroy()
+
+	name := GetUUID()
+	err = quickCreate(ioctx, name, testImageSize, testImageOrder)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, RemoveImage(ioctx, name)) }()
+
+	image, err := OpenImage(ioctx, name, NoSnapshot)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, image.Close()) }()
+
+	t.Run("noOtherWatchers", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		start := time.Now()
+		err := image.WaitForNoWatchers(ctx, time.Second)
+		assert.NoError(t, err)
+		assert.True(t, time.Since(start) < time.Second)
+	})
+
+	t.Run("otherWatcher", func(t *testing.T) {
+		other, err := OpenImage(ioctx2, name, NoSnapshot)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		err = image.WaitForNoWatchers(ctx, 100*time.Millisecond)
+		assert.Equal(t, context.DeadlineExceeded, err)
+
+		// closing the other image ends the wait
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			assert.NoError(t, other.Close())
+		}()
+		ctx2, cancel2 := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel2()
+		err = image.WaitForNoWatchers(ctx2, 100*time.Millisecond)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalidInterval", func(t *testing.T) {
+		err := image.WaitForNoWatchers(context.Background(), 0)
+		assert.Error(t, err)
+		err = image.WaitForNoWatchers(context.Background(), -time.Second)
+		assert.Error(t, err)
+	})
+
+	t.Run("closedImage", func(t *testing.T) {
+		err := GetImage(ioctx, name).WaitForNoWatchers(context.Background(), time.Second)
+		assert.Equal(t, ErrImageNotOpen, err)
+	})
+}
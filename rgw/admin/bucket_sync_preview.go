@@ -0,0 +1,105 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var errMissingBucket = errors.New("missing bucket name")
+
+// BucketShardSyncState is the replication state of a single bucket index
+// shard, as reported by the destination zone.
+type BucketShardSyncState string
+
+const (
+	// BucketShardSyncInit indicates that sync of the shard has not started.
+	BucketShardSyncInit = BucketShardSyncState("init")
+	// BucketShardSyncFull indicates that the existing objects of the shard
+	// are being copied from the source zone.
+	BucketShardSyncFull = BucketShardSyncState("full-sync")
+	// BucketShardSyncIncremental indicates that the shard is following the
+	// changes made in the source zone.
+	BucketShardSyncIncremental = BucketShardSyncState("incremental-sync")
+	// BucketShardSyncStopped indicates that sync of the shard is stopped.
+	BucketShardSyncStopped = BucketShardSyncState("stopped")
+)
+
+// BucketShardSyncStatus is the sync status of one bucket index shard.
+type BucketShardSyncStatus struct {
+	State     BucketShardSyncState `json:"status"`
+	IncMarker struct {
+		Position  string `json:"position"`
+		Timestamp string `json:"timestamp"`
+	} `json:"inc_marker"`
+}
+
+// BucketSyncStatus is the multisite sync status of a bucket.
+type BucketSyncStatus struct {
+	Bucket string
+	Shards []BucketShardSyncStatus
+}
+
+// CaughtUp returns true if every shard of the bucket has completed the
+// initial copy and follows the changes made in the source zone.
+//  PREVIEW
+func (s *BucketSyncStatus) CaughtUp() bool {
+	if len(s.Shards) == 0 {
+		return false
+	}
+	for _, shard := range s.Shards {
+		if shard.State != BucketShardSyncIncremental {
+			return false
+		}
+	}
+	return true
+}
+
+// parseBucketSyncShards accepts both the plain list of shards returned by
+// older gateways and the generation wrapped form returned by newer ones.
+func parseBucketSyncShards(body []byte) ([]BucketShardSyncStatus, error) {
+	shards := []BucketShardSyncStatus{}
+	if err := json.Unmarshal(body, &shards); err == nil {
+		return shards, nil
+	}
+	wrapped := struct {
+		IncStatus []BucketShardSyncStatus `json:"inc_status"`
+	}{}
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, err
+	}
+	if wrapped.IncStatus == nil {
+		wrapped.IncStatus = []BucketShardSyncStatus{}
+	}
+	return wrapped.IncStatus, nil
+}
+
+// GetBucketSyncStatus returns the per shard sync status of the bucket in a
+// multisite configuration. Use CaughtUp on the result to check whether the
+// bucket has caught up with its source zone.
+//  PREVIEW
+func (api *API) GetBucketSyncStatus(ctx context.Context, bucket string) (*BucketSyncStatus, error) {
+	if bucket == "" {
+		return nil, errMissingBucket
+	}
+	args := url.Values{}
+	args.Add("format", "json")
+	args.Add("type", "bucket-index")
+	args.Add("bucket", bucket)
+	body, err := api.call(ctx, http.MethodGet, "/log?status", args)
+	if err != nil {
+		return nil, err
+	}
+
+	shards, err := parseBucketSyncShards(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s. %s. %w", unmarshalError, string(body), err)
+	}
+	return &BucketSyncStatus{Bucket: bucket, Shards: shards}, nil
+}
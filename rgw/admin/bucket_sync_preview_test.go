@@ -0,0 +1,102 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package admin
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	fakeBucketSyncStatusList = []byte(`[
+  {
+    "status": "incremental-sync",
+    "full_marker": {"position": "", "count": 0, "timestamp": "0.000000"},
+    "inc_marker": {"position": "00000000001.12.5", "timestamp": "2021-03-01T10:00:00.000000Z"}
+  },
+  {
+    "status": "full-sync",
+    "full_marker": {"position": "obj7", "count": 7, "timestamp": "0.000000"},
+    "inc_marker": {"position": "", "timestamp": "0.000000"}
+  }
+]`)
+	fakeBucketSyncStatusGen = []byte(`{
+  "incremental_gen": 0,
+  "inc_status": [
+    {
+      "status": "incremental-sync",
+      "inc_marker": {"position": "00000000002.20.5", "timestamp": "2021-03-01T10:00:00.000000Z"}
+    },
+    {
+      "status": "incremental-sync",
+      "inc_marker": {"position": "00000000001.9.5", "timestamp": "2021-03-01T10:00:00.000000Z"}
+    }
+  ]
+}`)
+)
+
+func TestGetBucketSyncStatus(t *testing.T) {
+	var response []byte
+	var lastQuery string
+	mc := &mockClient{
+		mockDo: func(req *http.Request) (*http.Response, error) {
+			lastQuery = req.URL.RawQuery
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader(response)),
+			}, nil
+		},
+	}
+	api, err := New("127.0.0.1", "accessKey", "secretKey", mc)
+	require.NoError(t, err)
+
+	t.Run("missingBucket", func(t *testing.T) {
+		_, err := api.GetBucketSyncStatus(context.Background(), "")
+		assert.Equal(t, errMissingBucket, err)
+	})
+
+	t.Run("behind", func(t *testing.T) {
+		response = fakeBucketSyncStatusList
+		s, err := api.GetBucketSyncStatus(context.Background(), "photos")
+		require.NoError(t, err)
+		assert.Contains(t, lastQuery, "status")
+		assert.Contains(t, lastQuery, "bucket=photos")
+		assert.Contains(t, lastQuery, "type=bucket-index")
+		assert.Equal(t, "photos", s.Bucket)
+		require.Len(t, s.Shards, 2)
+		assert.Equal(t, BucketShardSyncIncremental, s.Shards[0].State)
+		assert.Equal(t, "00000000001.12.5", s.Shards[0].IncMarker.Position)
+		assert.Equal(t, BucketShardSyncFull, s.Shards[1].State)
+		assert.False(t, s.CaughtUp())
+	})
+
+	t.Run("caughtUp", func(t *testing.T) {
+		response = fakeBucketSyncStatusGen
+		s, err := api.GetBucketSyncStatus(context.Background(), "photos")
+		require.NoError(t, err)
+		require.Len(t, s.Shards, 2)
+		assert.Equal(t, "00000000002.20.5", s.Shards[0].IncMarker.Position)
+		assert.True(t, s.CaughtUp())
+	})
+
+	t.Run("noShards", func(t *testing.T) {
+		response = []byte(`[]`)
+		s, err := api.GetBucketSyncStatus(context.Background(), "photos")
+		require.NoError(t, err)
+		assert.Len(t, s.Shards, 0)
+		assert.False(t, s.CaughtUp())
+	})
+
+	t.Run("badResponse", func(t *testing.T) {
+		response = []byte(`"nope"`)
+		_, err := api.GetBucketSyncStatus(context.Background(), "photos")
+		assert.Error(t, err)
+	})
+}
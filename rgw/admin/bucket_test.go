@@ -37,6 +37,13 @@ func (suite *RadosGWTestSuite) TestBucket() {
 		assert.NoError(suite.T(), err)
 	})
 
+	suite.T().Run("get policy existing bucket", func(t *testing.T) {
+		p, err := co.GetBucketPolicy(context.Background(), Bucket{Bucket: suite.bucketTestName})
+		assert.NoError(suite.T(), err)
+		// the bucket was created by the admin user
+		assert.Equal(suite.T(), "admin", p.Owner.ID)
+	})
+
 	suite.T().Run("remove bucket", func(t *testing.T) {
 		err := co.RemoveBucket(context.Background(), Bucket{Bucket: suite.bucketTestName})
 		assert.NoError(suite.T(), err)
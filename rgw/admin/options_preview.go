@@ -0,0 +1,121 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	errNoHTTPClient   = errors.New("http client not set")
+	errNoRoundTripper = errors.New("round tripper not set")
+	errNoSigner       = errors.New("request signer not set")
+	errNoAdminPath    = errors.New("admin path not set")
+)
+
+// RequestSigner is a function that prepares a request to the RGW Admin Ops
+// API for authentication, typically by adding headers to it. It is called
+// for every request before the request is sent.
+type RequestSigner func(request *http.Request) error
+
+// Option is used to customize the API returned by NewWithOptions.
+type Option func(api *API) error
+
+// WithHTTPClient returns an Option that makes the API send requests using
+// the given HTTP client.
+//  PREVIEW
+func WithHTTPClient(client HTTPClient) Option {
+	return func(api *API) error {
+		if client == nil {
+			return errNoHTTPClient
+		}
+		api.HTTPClient = client
+		return nil
+	}
+}
+
+// WithRoundTripper returns an Option that makes the API send requests using
+// an HTTP client based on the given round tripper. This can be used to reach
+// gateways that are behind proxies or that need custom transport settings.
+//  PREVIEW
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(api *API) error {
+		if rt == nil {
+			return errNoRoundTripper
+		}
+		api.HTTPClient = &http.Client{
+			Timeout:   connectionTimeout,
+			Transport: rt,
+		}
+		return nil
+	}
+}
+
+// WithRequestSigner returns an Option that replaces the default S3 request
+// signing with the given signer. When a custom signer is provided the access
+// key and secret key may be left empty.
+//  PREVIEW
+func WithRequestSigner(signer RequestSigner) Option {
+	return func(api *API) error {
+		if signer == nil {
+			return errNoSigner
+		}
+		api.signer = signer
+		return nil
+	}
+}
+
+// WithAdminPath returns an Option that sets the path prefix of the Admin Ops
+// API on the endpoint. The default prefix is "/admin". Leading and trailing
+// slashes are ignored, and a prefix consisting of nothing else is rejected.
+//  PREVIEW
+func WithAdminPath(prefix string) Option {
+	return func(api *API) error {
+		prefix = strings.Trim(prefix, "/")
+		if prefix == "" {
+			return errNoAdminPath
+		}
+		api.adminPath = "/" + prefix
+		return nil
+	}
+}
+
+// NewWithOptions returns a client for Ceph RGW customized by the supplied
+// options. Unless a custom request signer is set with WithRequestSigner the
+// access key and secret key are required.
+//  PREVIEW
+func NewWithOptions(endpoint, accessKey, secretKey string, opts ...Option) (*API, error) {
+	// validate endpoint
+	if endpoint == "" {
+		return nil, errNoEndpoint
+	}
+
+	api := &API{
+		Endpoint:   endpoint,
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		HTTPClient: &http.Client{Timeout: connectionTimeout},
+	}
+	for _, opt := range opts {
+		if err := opt(api); err != nil {
+			return nil, err
+		}
+	}
+
+	if api.signer == nil {
+		// validate access key
+		if accessKey == "" {
+			return nil, errNoAccessKey
+		}
+
+		// validate secret key
+		if secretKey == "" {
+			return nil, errNoSecretKey
+		}
+	}
+
+	return api, nil
+}
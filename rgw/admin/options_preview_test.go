@@ -0,0 +1,116 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingRoundTripper struct {
+	count int
+}
+
+func (rt *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.count++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewWithOptions(t *testing.T) {
+	var lastPath, lastAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath = r.URL.Path
+		lastAuth = r.Header.Get("Authorization")
+		if strings.HasSuffix(r.URL.Path, "/bucket") {
+			w.Write([]byte(`["bucket1"]`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := NewWithOptions("", "foo", "bar")
+		assert.Equal(t, errNoEndpoint, err)
+		_, err = NewWithOptions(srv.URL, "", "bar")
+		assert.Equal(t, errNoAccessKey, err)
+		_, err = NewWithOptions(srv.URL, "foo", "")
+		assert.Equal(t, errNoSecretKey, err)
+		_, err = NewWithOptions(srv.URL, "foo", "bar", WithRoundTripper(nil))
+		assert.Equal(t, errNoRoundTripper, err)
+		_, err = NewWithOptions(srv.URL, "foo", "bar", WithRequestSigner(nil))
+		assert.Equal(t, errNoSigner, err)
+		_, err = NewWithOptions(srv.URL, "foo", "bar", WithHTTPClient(nil))
+		assert.Equal(t, errNoHTTPClient, err)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		co, err := NewWithOptions(srv.URL, "foo", "bar")
+		require.NoError(t, err)
+		buckets, err := co.ListBuckets(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bucket1"}, buckets)
+		assert.Equal(t, "/admin/bucket", lastPath)
+		assert.Contains(t, lastAuth, "AWS4-HMAC-SHA256")
+	})
+
+	t.Run("roundTripper", func(t *testing.T) {
+		rt := &countingRoundTripper{}
+		co, err := NewWithOptions(srv.URL, "foo", "bar", WithRoundTripper(rt))
+		require.NoError(t, err)
+		_, err = co.ListBuckets(context.Background())
+		assert.NoError(t, err)
+		_, err = co.ListBuckets(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rt.count)
+	})
+
+	t.Run("adminPath", func(t *testing.T) {
+		co, err := NewWithOptions(srv.URL, "foo", "bar", WithAdminPath("/proxy/rgw-admin/"))
+		require.NoError(t, err)
+		_, err = co.ListBuckets(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "/proxy/rgw-admin/bucket", lastPath)
+
+		_, err = co.GetUserQuota(context.Background(), QuotaSpec{UID: "foo"})
+		assert.NoError(t, err)
+		assert.Equal(t, "/proxy/rgw-admin/user", lastPath)
+
+		for _, prefix := range []string{"", "/", "//"} {
+			_, err = NewWithOptions(srv.URL, "foo", "bar", WithAdminPath(prefix))
+			assert.Equal(t, errNoAdminPath, err)
+		}
+	})
+
+	t.Run("requestSigner", func(t *testing.T) {
+		signed := 0
+		signer := func(req *http.Request) error {
+			signed++
+			req.Header.Set("Authorization", "Custom token")
+			return nil
+		}
+		co, err := NewWithOptions(srv.URL, "", "", WithRequestSigner(signer))
+		require.NoError(t, err)
+		_, err = co.ListBuckets(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, signed)
+		assert.Equal(t, "Custom token", lastAuth)
+	})
+
+	t.Run("requestSignerError", func(t *testing.T) {
+		errSign := errors.New("no token")
+		signer := func(req *http.Request) error { return errSign }
+		co, err := NewWithOptions(srv.URL, "", "", WithRequestSigner(signer))
+		require.NoError(t, err)
+		_, err = co.ListBuckets(context.Background())
+		assert.Equal(t, errSign, err)
+	})
+}
@@ -37,6 +37,9 @@ type API struct {
 	SecretKey  string
 	Endpoint   string
 	HTTPClient HTTPClient
+
+	signer    func(request *http.Request) error
+	adminPath string
 }
 
 // New returns client for Ceph RGW
@@ -69,14 +72,17 @@ func New(endpoint, accessKey, secretKey string, httpClient HTTPClient) (*API, er
 	}, nil
 }
 
-// call makes request to the RGW Admin Ops API
-func (api *API) call(ctx context.Context, httpMethod, path string, args url.Values) (body []byte, err error) {
-	// Build request
-	request, err := http.NewRequestWithContext(ctx, httpMethod, buildQueryPath(api.Endpoint, path, args.Encode()), nil)
-	if err != nil {
-		return nil, err
+// queryAdminPath returns the path prefix of the RGW Admin Ops API.
+func (api *API) queryAdminPath() string {
+	if api.adminPath == "" {
+		return queryAdminPath
 	}
+	return api.adminPath
+}
 
+// signV4 signs the request with S3 authentication using the access and
+// secret keys of the API.
+func (api *API) signV4(request *http.Request) error {
 	// Build S3 authentication
 	cred := credentials.NewStaticCredentials(api.AccessKey, api.SecretKey, "")
 	signer := v4.NewSigner(cred)
@@ -85,7 +91,24 @@ func (api *API) call(ctx context.Context, httpMethod, path string, args url.Valu
 	// signer.DisableRequestBodyOverwrite = true
 
 	// Sign in S3
-	_, err = signer.Sign(request, nil, service, authRegion, time.Now())
+	_, err := signer.Sign(request, nil, service, authRegion, time.Now())
+	return err
+}
+
+// call makes request to the RGW Admin Ops API
+func (api *API) call(ctx context.Context, httpMethod, path string, args url.Values) (body []byte, err error) {
+	// Build request
+	request, err := http.NewRequestWithContext(ctx, httpMethod, buildQueryPath(api.Endpoint, api.queryAdminPath(), path, args.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign the request
+	signer := api.signer
+	if signer == nil {
+		signer = api.signV4
+	}
+	err = signer(request)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,47 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package admin
+
+// BucketUsage is the usage of a single category of operations on a bucket
+// during one time period of a usage report.
+type BucketUsage struct {
+	User          string
+	Bucket        string
+	Owner         string
+	Time          string
+	Epoch         uint64
+	Category      string
+	BytesSent     uint64
+	BytesReceived uint64
+	Ops           uint64
+	SuccessfulOps uint64
+}
+
+// BucketEntries flattens the per-user entries of the usage report into one
+// record for each category of each bucket. The report must have been
+// requested with ShowEntries enabled, which is the default, for it to
+// contain any entries.
+//  PREVIEW
+func (u Usage) BucketEntries() []BucketUsage {
+	records := []BucketUsage{}
+	for _, entry := range u.Entries {
+		for _, bucket := range entry.Buckets {
+			for _, category := range bucket.Categories {
+				records = append(records, BucketUsage{
+					User:          entry.User,
+					Bucket:        bucket.Bucket,
+					Owner:         bucket.Owner,
+					Time:          bucket.Time,
+					Epoch:         bucket.Epoch,
+					Category:      category.Category,
+					BytesSent:     category.BytesSent,
+					BytesReceived: category.BytesReceived,
+					Ops:           category.Ops,
+					SuccessfulOps: category.SuccessfulOps,
+				})
+			}
+		}
+	}
+	return records
+}
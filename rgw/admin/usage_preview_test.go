@@ -0,0 +1,120 @@
+//go:build ceph_preview
+// +build ceph_preview
+
+package admin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var usageWithBuckets = []byte(`{
+  "entries": [
+    {
+      "user": "alice",
+      "buckets": [
+        {
+          "bucket": "photos",
+          "time": "2021-03-01 10:00:00.000000Z",
+          "epoch": 1614592800,
+          "owner": "alice",
+          "categories": [
+            {"category": "put_obj", "bytes_sent": 0, "bytes_received": 4096, "ops": 2, "successful_ops": 2},
+            {"category": "get_obj", "bytes_sent": 8192, "bytes_received": 0, "ops": 4, "successful_ops": 3}
+          ]
+        },
+        {
+          "bucket": "logs",
+          "time": "2021-03-01 10:00:00.000000Z",
+          "epoch": 1614592800,
+          "owner": "alice",
+          "categories": [
+            {"category": "put_obj", "bytes_sent": 0, "bytes_received": 100, "ops": 1, "successful_ops": 1}
+          ]
+        }
+      ]
+    },
+    {
+      "user": "bob",
+      "buckets": [
+        {
+          "bucket": "backups",
+          "time": "2021-03-01 11:00:00.000000Z",
+          "epoch": 1614596400,
+          "owner": "bob",
+          "categories": [
+            {"category": "list_bucket", "bytes_sent": 512, "bytes_received": 0, "ops": 1, "successful_ops": 1}
+          ]
+        }
+      ]
+    }
+  ],
+  "summary": []
+}`)
+
+func TestUsageBucketEntries(t *testing.T) {
+	var u Usage
+	err := json.Unmarshal(usageWithBuckets, &u)
+	assert.NoError(t, err)
+
+	records := u.BucketEntries()
+	assert.Equal(t, []BucketUsage{
+		{
+			User:          "alice",
+			Bucket:        "photos",
+			Owner:         "alice",
+			Time:          "2021-03-01 10:00:00.000000Z",
+			Epoch:         1614592800,
+			Category:      "put_obj",
+			BytesReceived: 4096,
+			Ops:           2,
+			SuccessfulOps: 2,
+		},
+		{
+			User:          "alice",
+			Bucket:        "photos",
+			Owner:         "alice",
+			Time:          "2021-03-01 10:00:00.000000Z",
+			Epoch:         1614592800,
+			Category:      "get_obj",
+			BytesSent:     8192,
+			Ops:           4,
+			SuccessfulOps: 3,
+		},
+		{
+			User:          "alice",
+			Bucket:        "logs",
+			Owner:         "alice",
+			Time:          "2021-03-01 10:00:00.000000Z",
+			Epoch:         1614592800,
+			Category:      "put_obj",
+			BytesReceived: 100,
+			Ops:           1,
+			SuccessfulOps: 1,
+		},
+		{
+			User:          "bob",
+			Bucket:        "backups",
+			Owner:         "bob",
+			Time:          "2021-03-01 11:00:00.000000Z",
+			Epoch:         1614596400,
+			Category:      "list_bucket",
+			BytesSent:     512,
+			Ops:           1,
+			SuccessfulOps: 1,
+		},
+	}, records)
+
+	t.Run("noEntries", func(t *testing.T) {
+		assert.Len(t, Usage{}.BucketEntries(), 0)
+	})
+}
+
+func TestUsageShowFlagsParams(t *testing.T) {
+	pTrue, pFalse := true, false
+	values := valueToURLParams(Usage{ShowEntries: &pTrue, ShowSummary: &pFalse})
+	assert.Equal(t, "true", values.Get("show-entries"))
+	assert.Equal(t, "false", values.Get("show-summary"))
+}
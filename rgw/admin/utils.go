@@ -11,7 +11,7 @@ const (
 	queryAdminPath = "/admin"
 )
 
-func buildQueryPath(endpoint, path, args string) string {
+func buildQueryPath(endpoint, adminPath, path, args string) string {
 	// Sometimes the API requires single URL key with no values
 	// For instance, the Quota code uses the admin API path to "/user?quota"
 	// This is done this way since url.Values does not support adding keys without values.
@@ -20,10 +20,10 @@ func buildQueryPath(endpoint, path, args string) string {
 	// and add a separator key instead
 	// So we can get something like "/admin/user?quota&" instead of passing two beginning query markers ("?")
 	if strings.Contains(path, "?") {
-		return fmt.Sprintf("%s%s%s&%s", endpoint, queryAdminPath, path, args)
+		return fmt.Sprintf("%s%s%s&%s", endpoint, adminPath, path, args)
 	}
 
-	return fmt.Sprintf("%s%s%s?%s", endpoint, queryAdminPath, path, args)
+	return fmt.Sprintf("%s%s%s?%s", endpoint, adminPath, path, args)
 }
 
 // valueToURLParams encodes structs into URL query parameters.
@@ -16,7 +16,7 @@ func getDefaultValue() url.Values {
 }
 
 func TestBuildQueryPath(t *testing.T) {
-	queryPath := buildQueryPath("http://192.168.0.1", "/user", getDefaultValue().Encode())
+	queryPath := buildQueryPath("http://192.168.0.1", queryAdminPath, "/user", getDefaultValue().Encode())
 	assert.Equal(t, "http://192.168.0.1/admin/user?format=json", queryPath)
 }
 